@@ -1,15 +1,36 @@
 package main
 
 import (
+	"context"
 	"log"
+	"time"
 
+	"future-letter/internal/clock"
 	"future-letter/internal/config"
 	"future-letter/internal/database"
+	accessTokenRepository "future-letter/internal/repository/accesstoken"
 	capsuleRepository "future-letter/internal/repository/capsule"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	exportRepository "future-letter/internal/repository/export"
+	identityRepository "future-letter/internal/repository/identity"
+	inboxRepository "future-letter/internal/repository/inbox"
+	lockRepository "future-letter/internal/repository/lock"
+	loginThrottleRepository "future-letter/internal/repository/loginthrottle"
+	oauthRepository "future-letter/internal/repository/oauth"
+	recoveryRepository "future-letter/internal/repository/recovery"
+	schedulerRepository "future-letter/internal/repository/scheduler"
+	sessionRepository "future-letter/internal/repository/session"
+	statsRepository "future-letter/internal/repository/stats"
+	tokenRepository "future-letter/internal/repository/token"
 	userRepository "future-letter/internal/repository/user"
 	"future-letter/internal/routes"
+	adminService "future-letter/internal/service/admin"
 	capsuleService "future-letter/internal/service/capsule"
 	emailService "future-letter/internal/service/email"
+	exportService "future-letter/internal/service/export"
+	inboxService "future-letter/internal/service/inbox"
+	notifierService "future-letter/internal/service/notifier"
+	oidcService "future-letter/internal/service/oidc"
 	schedulerService "future-letter/internal/service/scheduler"
 	userService "future-letter/internal/service/user"
 	"future-letter/internal/utils"
@@ -35,6 +56,22 @@ func main() {
 	// Initalize jwt
 	utils.InitJWT(cfg.JWT.Secret)
 
+	// Initalize clock, waktu hanya bisa digeser jika DEBUG_CLOCK_ENABLED=true (ditolak di production)
+	appClock := clock.System()
+	var debugClock *clock.OffsetClock
+	if cfg.App.DebugClockEnabled && !cfg.IsProduction() {
+		debugClock = clock.NewOffsetClock()
+		if cfg.App.FakeNow != "" {
+			fakeNow, err := time.Parse(time.RFC3339, cfg.App.FakeNow)
+			if err != nil {
+				log.Fatal("invalid APP_FAKE_NOW, use RFC3339:", err)
+			}
+			debugClock.Set(fakeNow)
+			log.Printf("Using fake clock starting at %s", fakeNow.Format(time.RFC3339))
+		}
+		appClock = debugClock
+	}
+
 	// Setup gin router
 	if cfg.App.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -47,14 +84,53 @@ func main() {
 	// Initalize repository
 	userRepo := userRepository.NewUserRepository(database.DB)
 	capsuleRepo := capsuleRepository.NewCapsuleRepository(database.DB)
+	inboxRepo := inboxRepository.NewInboxRepository(database.DB)
+	deliveryRepo := deliveryRepository.NewDeliveryRepository(database.DB)
+	lockRepo := lockRepository.NewLockRepository(database.DB)
+	schedulerRunRepo := schedulerRepository.NewSchedulerRunRepository(database.DB)
+	tokenRepo := tokenRepository.NewTokenRepository(database.DB)
+	sessionRepo := sessionRepository.NewSessionRepository(database.DB)
+	recoveryRepo := recoveryRepository.NewRecoveryCodeRepository(database.DB)
+	identityRepo := identityRepository.NewIdentityRepository(database.DB)
+	accessTokenRepo := accessTokenRepository.NewAccessTokenRepository(database.DB)
+	statsRepo := statsRepository.NewStatsRepository(database.DB)
+	exportRepo := exportRepository.NewExportRepository(database.DB)
+
+	// State login gagal disimpan di MySQL agar berlaku di semua replica
+	loginThrottleRepo := loginThrottleRepository.NewLoginThrottleRepository(database.DB)
+	if cfg.Auth.LoginThrottleBackend == "memory" {
+		loginThrottleRepo = loginThrottleRepository.NewMemoryLoginThrottleRepository()
+	}
+
+	// Pencarian capsule memakai index FULLTEXT MySQL, backend memory untuk database tanpa index tersebut
+	capsuleSearchRepo := capsuleRepository.NewCapsuleSearchRepository(database.DB)
+	if cfg.Search.Backend == "memory" {
+		capsuleSearchRepo = capsuleRepository.NewMemoryCapsuleSearchRepository(capsuleRepo)
+	}
+	oauthStateRepo := oauthRepository.NewOAuthStateRepository(database.DB)
 
 	// Initalize service
-	userSvc := userService.NewUserService(userRepo)
-	capsuleSvc := capsuleService.NewCapsuleService(capsuleRepo)
 	emailSvc := emailService.NewEmailService(cfg)
+	userSvc := userService.NewUserService(cfg, userRepo, tokenRepo, sessionRepo, recoveryRepo, identityRepo, accessTokenRepo, loginThrottleRepo, capsuleRepo, deliveryRepo, inboxRepo, emailSvc, appClock)
+	inboxSvc := inboxService.NewInboxService(inboxRepo, appClock)
+	adminSvc := adminService.NewAdminService(userRepo, capsuleRepo, sessionRepo, accessTokenRepo, statsRepo, appClock)
+
+	// Admin pertama diambil dari ADMIN_EMAILS, role selanjutnya dikelola lewat admin API
+	if err := adminSvc.BootstrapAdmins(context.Background(), cfg.App.AdminEmails); err != nil {
+		log.Printf("Failed to bootstrap admin roles: %v", err)
+	}
+	notifierRegistry := notifierService.NewDefaultRegistry(cfg, emailSvc, inboxSvc, appClock)
+	capsuleSvc := capsuleService.NewCapsuleService(capsuleRepo, capsuleSearchRepo, userRepo, deliveryRepo, notifierRegistry, appClock)
+
+	// Login OIDC hanya diaktifkan jika provider dikonfigurasi
+	var oidcSvc oidcService.OIDCService
+	if cfg.OIDC.Enabled() {
+		oidcSvc = oidcService.NewOIDCService(cfg.OIDC, oauthStateRepo, appClock)
+		log.Printf("OIDC login enabled with provider %s (%s)", cfg.OIDC.Provider, cfg.OIDC.Issuer)
+	}
 
 	// Scheduler service
-	schedulerSvc := schedulerService.NewSchedulerService(cfg, userRepo, capsuleSvc, emailSvc)
+	schedulerSvc := schedulerService.NewSchedulerService(cfg, userRepo, deliveryRepo, lockRepo, schedulerRunRepo, capsuleSvc, notifierRegistry, appClock)
 	err = schedulerSvc.Start()
 	if err != nil {
 		log.Fatal("failed to start scheduler:", err)
@@ -62,8 +138,16 @@ func main() {
 
 	defer schedulerSvc.Stop()
 
+	// Export service membuat file ZIP data user di background
+	exportSvc := exportService.NewExportService(cfg, exportRepo, userRepo, capsuleRepo, deliveryRepo, inboxRepo, emailSvc, appClock)
+	if err := exportSvc.Start(); err != nil {
+		log.Fatal("failed to start export service:", err)
+	}
+
+	defer exportSvc.Stop()
+
 	// Setup routes
-	routes.SetupRoutes(router, cfg, userSvc, capsuleSvc)
+	routes.SetupRoutes(router, cfg, userSvc, adminSvc, capsuleSvc, inboxSvc, exportSvc, schedulerSvc, oidcSvc, debugClock)
 
 	if err := router.Run(":" + cfg.App.Port); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
@@ -16,6 +16,7 @@ import (
 // ==========================================
 // File ini untuk testing email service
 // PENTING: Pastikan konfigurasi SMTP di .env sudah benar!
+// Jalankan dari root project agar .env terbaca: go run ./cmd/test_email
 func main() {
 	fmt.Println("📧 Testing Email Service...")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
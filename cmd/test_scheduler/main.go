@@ -6,13 +6,26 @@ import (
 	"log"
 	"time"
 
+	"future-letter/internal/clock"
 	"future-letter/internal/config"
 	"future-letter/internal/database"
 	"future-letter/internal/models"
+	accessTokenRepository "future-letter/internal/repository/accesstoken"
 	capsuleRepository "future-letter/internal/repository/capsule"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	identityRepository "future-letter/internal/repository/identity"
+	inboxRepository "future-letter/internal/repository/inbox"
+	lockRepository "future-letter/internal/repository/lock"
+	loginThrottleRepository "future-letter/internal/repository/loginthrottle"
+	recoveryRepository "future-letter/internal/repository/recovery"
+	schedulerRepository "future-letter/internal/repository/scheduler"
+	sessionRepository "future-letter/internal/repository/session"
+	tokenRepository "future-letter/internal/repository/token"
 	userRepository "future-letter/internal/repository/user"
 	capsuleService "future-letter/internal/service/capsule"
 	emailService "future-letter/internal/service/email"
+	inboxService "future-letter/internal/service/inbox"
+	notifierService "future-letter/internal/service/notifier"
 	schedulerService "future-letter/internal/service/scheduler"
 	userService "future-letter/internal/service/user"
 )
@@ -20,6 +33,7 @@ import (
 // ==========================================
 // TEST SCHEDULER SERVICE
 // ==========================================
+// Jalankan dari root project agar .env terbaca: go run ./cmd/test_scheduler
 func main() {
 	fmt.Println("🧪 Testing Scheduler Service")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -47,12 +61,24 @@ func main() {
 
 	userRepo := userRepository.NewUserRepository(database.DB)
 	capsuleRepo := capsuleRepository.NewCapsuleRepository(database.DB)
+	inboxRepo := inboxRepository.NewInboxRepository(database.DB)
+	deliveryRepo := deliveryRepository.NewDeliveryRepository(database.DB)
+	lockRepo := lockRepository.NewLockRepository(database.DB)
+	schedulerRunRepo := schedulerRepository.NewSchedulerRunRepository(database.DB)
+	tokenRepo := tokenRepository.NewTokenRepository(database.DB)
+	sessionRepo := sessionRepository.NewSessionRepository(database.DB)
+	recoveryRepo := recoveryRepository.NewRecoveryCodeRepository(database.DB)
+	identityRepo := identityRepository.NewIdentityRepository(database.DB)
+	accessTokenRepo := accessTokenRepository.NewAccessTokenRepository(database.DB)
+	loginThrottleRepo := loginThrottleRepository.NewMemoryLoginThrottleRepository()
 
-	userSvc := userService.NewUserService(userRepo)
-	capsuleSvc := capsuleService.NewCapsuleService(capsuleRepo)
 	emailSvc := emailService.NewEmailService(cfg)
+	userSvc := userService.NewUserService(cfg, userRepo, tokenRepo, sessionRepo, recoveryRepo, identityRepo, accessTokenRepo, loginThrottleRepo, capsuleRepo, deliveryRepo, inboxRepo, emailSvc, clock.System())
+	inboxSvc := inboxService.NewInboxService(inboxRepo, clock.System())
+	notifierRegistry := notifierService.NewDefaultRegistry(cfg, emailSvc, inboxSvc, clock.System())
+	capsuleSvc := capsuleService.NewCapsuleService(capsuleRepo, capsuleRepository.NewMemoryCapsuleSearchRepository(capsuleRepo), userRepo, deliveryRepo, notifierRegistry, clock.System())
 
-	scheduler := schedulerService.NewSchedulerService(cfg, userRepo, capsuleSvc, emailSvc)
+	scheduler := schedulerService.NewSchedulerService(cfg, userRepo, deliveryRepo, lockRepo, schedulerRunRepo, capsuleSvc, notifierRegistry, clock.System())
 
 	fmt.Println("✅ All layers initialized")
 
@@ -79,12 +105,17 @@ func main() {
 			Email:    registerInput.Email,
 			Password: registerInput.Password,
 		}
-		user, err = userSvc.Login(ctx, loginInput)
+		user, err = userSvc.Login(ctx, loginInput, models.ClientInfo{})
 		if err != nil {
 			log.Fatal("Failed to get user:", err)
 		}
 	}
 
+	// Tandai email terverifikasi agar capsule email tidak ditunda oleh scheduler
+	if err := userRepo.MarkEmailVerified(ctx, user.ID, time.Now()); err != nil {
+		log.Fatal("Failed to verify test user email:", err)
+	}
+
 	fmt.Printf("✅ User ready: %s (%s)\n", user.Name, user.Email)
 
 	// ==========================================
@@ -156,7 +187,9 @@ func main() {
 	fmt.Println("📋 Checking pending capsules for today...")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	pendingCapsules, err := capsuleSvc.GetPendingCapsulesForToday(ctx)
+	// Capsule disimpan dengan waktu UTC, ambil yang jatuh tempo sampai akhir hari ini
+	startOfDay := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.Local)
+	pendingCapsules, err := capsuleSvc.GetDueCapsules(ctx, startOfDay.AddDate(0, 0, 1))
 	if err != nil {
 		log.Fatal("Failed to get pending capsules:", err)
 	}
@@ -191,7 +224,11 @@ func main() {
 		time.Sleep(10 * time.Second)
 	} else {
 		// Panggil RunManually jika tersedia
-		concreteScheduler.RunManually()
+		run, err := concreteScheduler.RunManually()
+		if err != nil {
+			log.Fatal("Failed to run scheduler:", err)
+		}
+		fmt.Printf("✅ Run %d %s: %d sent, %d retried, %d failed\n", run.ID, run.Status, run.Sent, run.Retried, run.Failed)
 	}
 
 	// ==========================================
@@ -0,0 +1,93 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// tolerance selisih waktu yang wajar antara dua panggilan time.Now di test
+const tolerance = time.Second
+
+func within(got, want time.Time) bool {
+	diff := got.Sub(want)
+	return diff > -tolerance && diff < tolerance
+}
+
+func TestOffsetClock(t *testing.T) {
+	target := time.Date(2035, 12, 31, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		apply func(c *OffsetClock)
+		want  func() time.Time
+	}{
+		{
+			name:  "new clock follows system time",
+			apply: func(c *OffsetClock) {},
+			want:  time.Now,
+		},
+		{
+			name:  "set moves now to target",
+			apply: func(c *OffsetClock) { c.Set(target) },
+			want:  func() time.Time { return target },
+		},
+		{
+			name:  "advance adds duration",
+			apply: func(c *OffsetClock) { c.Advance(48 * time.Hour) },
+			want:  func() time.Time { return time.Now().Add(48 * time.Hour) },
+		},
+		{
+			name:  "negative advance moves back",
+			apply: func(c *OffsetClock) { c.Advance(-2 * time.Hour) },
+			want:  func() time.Time { return time.Now().Add(-2 * time.Hour) },
+		},
+		{
+			name: "advance after set is cumulative",
+			apply: func(c *OffsetClock) {
+				c.Set(target)
+				c.Advance(time.Hour)
+			},
+			want: func() time.Time { return target.Add(time.Hour) },
+		},
+		{
+			name: "reset returns to system time",
+			apply: func(c *OffsetClock) {
+				c.Set(target)
+				c.Reset()
+			},
+			want: time.Now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewOffsetClock()
+			tt.apply(c)
+
+			if got, want := c.Now(), tt.want(); !within(got, want) {
+				t.Fatalf("Now() = %s, want about %s", got, want)
+			}
+		})
+	}
+}
+
+func TestOffsetClockKeepsRunning(t *testing.T) {
+	c := NewOffsetClock()
+	c.Set(time.Date(2035, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	first := c.Now()
+	time.Sleep(20 * time.Millisecond)
+
+	if !c.Now().After(first) {
+		t.Fatal("OffsetClock stopped after Set, time must keep running")
+	}
+}
+
+func TestOffsetClockOffset(t *testing.T) {
+	c := NewOffsetClock()
+	c.Advance(90 * time.Minute)
+
+	if got := c.Offset(); got != 90*time.Minute {
+		t.Fatalf("Offset() = %s, want 1h30m", got)
+	}
+}
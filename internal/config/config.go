@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,13 @@ type Config struct {
 	JWT       JWTConfig
 	Email     EmailConfig
 	Schedular SchedularConfig
+	Auth      AuthConfig
+	Webhook   WebhookConfig
+	Telegram  TelegramConfig
+	SMS       SMSConfig
+	OIDC      OIDCConfig
+	Export    ExportConfig
+	Search    SearchConfig
 }
 
 // DatabaseConfig menampung konfigurasi database MYSQL
@@ -30,12 +38,66 @@ type DatabaseConfig struct {
 type AppConfig struct {
 	Port string
 	Env  string
+	// AdminEmails daftar email user yang otomatis dijadikan admin saat aplikasi start
+	AdminEmails []string
+	// BaseURL alamat frontend, dipakai untuk link di email
+	BaseURL string
+	// APIURL alamat publik API ini, dipakai untuk link yang langsung membuka endpoint API
+	APIURL string
+	// DebugClockEnabled mengaktifkan clock yang bisa digeser beserta route debug nya.
+	// Harus diaktifkan secara eksplisit dan ditolak di production
+	DebugClockEnabled bool
+	// FakeNow waktu awal aplikasi dalam format RFC3339, hanya berlaku jika DebugClockEnabled
+	FakeNow string
 }
 
 // JWTConfig menampung konfigurasi JWT
 type JWTConfig struct {
 	Secret string
-	Expiry int
+	// AccessTokenMinutes masa berlaku access token (JWT)
+	AccessTokenMinutes int
+	// RefreshTokenDays masa berlaku sesi login, diperpanjang setiap refresh token dirotasi
+	RefreshTokenDays int
+}
+
+// AuthConfig menampung konfigurasi alur autentikasi akun
+type AuthConfig struct {
+	// PasswordResetTTLMinutes masa berlaku link reset password
+	PasswordResetTTLMinutes int
+	// EmailVerificationTTLHours masa berlaku link verifikasi email
+	EmailVerificationTTLHours int
+	// MagicLinkTTLMinutes masa berlaku link login tanpa password
+	MagicLinkTTLMinutes int
+	// MagicLinkMaxPerHour jumlah maksimal link login yang dikirim ke satu email per jam
+	MagicLinkMaxPerHour int
+	// TwoFactorIssuer nama aplikasi yang tampil di aplikasi authenticator
+	TwoFactorIssuer string
+	// TwoFactorChallengeMinutes masa berlaku challenge token login 2FA
+	TwoFactorChallengeMinutes int
+	// TwoFactorMaxAttempts batas percobaan kode untuk satu challenge token, setelah itu harus login ulang
+	TwoFactorMaxAttempts int
+	// AccessTokenDefaultDays masa berlaku personal access token jika tidak ditentukan user
+	AccessTokenDefaultDays int
+	// AccessTokenMaxDays masa berlaku maksimal personal access token
+	AccessTokenMaxDays int
+	// AccessTokenMaxPerUser jumlah maksimal personal access token aktif per user
+	AccessTokenMaxPerUser int
+	// LoginThrottleBackend penyimpanan percobaan login gagal: "mysql" (default, aman untuk banyak replica) atau "memory"
+	LoginThrottleBackend string
+	// LoginFailureWindowMinutes rentang waktu percobaan login gagal dihitung
+	LoginFailureWindowMinutes int
+	// LoginMaxFailures jumlah login gagal per akun sebelum akun dikunci sementara
+	LoginMaxFailures int
+	// LoginMaxFailuresPerIP jumlah login gagal per IP sebelum IP dikunci sementara
+	LoginMaxFailuresPerIP int
+	// LoginDelayAfterFailures jumlah login gagal sebelum jeda antar percobaan mulai diberlakukan
+	LoginDelayAfterFailures int
+	// LoginMaxDelaySeconds jeda terlama antar percobaan, jeda naik dua kali lipat setiap gagal
+	LoginMaxDelaySeconds int
+	// LoginLockoutMinutes lama akun atau IP dikunci
+	LoginLockoutMinutes int
+	// AccountDeletionGraceDays masa tenggang sebelum akun yang diminta dihapus benar benar dihapus
+	AccountDeletionGraceDays int
 }
 
 // EmailConfig menampung konfigurasi email SMTP
@@ -51,6 +113,108 @@ type EmailConfig struct {
 type SchedularConfig struct {
 	CronExpression string
 	Timezone       string
+	// MaxLatenessMinutes batas keterlambatan, capsule yang terkirim lebih lambat dari ini ditandai overdue
+	MaxLatenessMinutes int
+	// MaxAttempts jumlah maksimal percobaan pengiriman sebelum capsule ditandai failed
+	MaxAttempts int
+	// RetryBaseSeconds jeda retry pertama, dikali dua setiap percobaan (exponential backoff)
+	RetryBaseSeconds int
+	// RetryMaxSeconds batas atas jeda retry
+	RetryMaxSeconds int
+	// LeaseSeconds lama klaim capsule, klaim yang lewat dari ini dianggap macet dan dipulihkan
+	LeaseSeconds int
+	// BatchSize jumlah capsule yang diklaim dalam satu kali query
+	BatchSize int
+	// InstanceID identitas replica pada lock scheduler, default hostname-pid
+	InstanceID string
+	// LockLeaseSeconds lama lease lock scheduler, diperpanjang otomatis selama run berjalan
+	LockLeaseSeconds int
+	// Workers jumlah maksimal capsule yang dikirim bersamaan
+	Workers int
+	// SendTimeoutSeconds batas waktu satu kali pengiriman
+	SendTimeoutSeconds int
+	// RunTimeoutMinutes batas waktu satu kali run scheduler
+	RunTimeoutMinutes int
+	// RateLimits batas pengiriman per detik per channel, contoh SCHEDULER_RATE_LIMITS="email=5,sms=0.5"
+	RateLimits map[string]float64
+	// DefaultRatePerSecond batas pengiriman per detik untuk channel lain, 0 berarti tanpa batas
+	DefaultRatePerSecond float64
+	// UnverifiedDeferMinutes jeda penundaan capsule email untuk user yang emailnya belum diverifikasi
+	UnverifiedDeferMinutes int
+	// RunRetentionDays lama riwayat run scheduler disimpan, 0 berarti disimpan selamanya
+	RunRetentionDays int
+	// AccountPurgeCronExpression jadwal penghapusan permanen akun yang masa tenggangnya sudah habis
+	AccountPurgeCronExpression string
+}
+
+// WebhookConfig menampung konfigurasi channel webhook HTTP
+// Channel hanya aktif jika URL di isi
+type WebhookConfig struct {
+	URL            string
+	Secret         string
+	TimeoutSeconds int
+}
+
+// TelegramConfig menampung konfigurasi channel Telegram bot
+// Channel hanya aktif jika BotToken di isi
+type TelegramConfig struct {
+	APIURL         string
+	BotToken       string
+	TimeoutSeconds int
+}
+
+// SMSConfig menampung konfigurasi channel SMS gateway
+// Channel hanya aktif jika APIURL di isi
+type SMSConfig struct {
+	APIURL         string
+	APIKey         string
+	From           string
+	TimeoutSeconds int
+}
+
+// OIDCConfig menampung konfigurasi login lewat provider OpenID Connect
+// Login OIDC hanya aktif jika Issuer di isi
+type OIDCConfig struct {
+	// Provider nama provider yang disimpan di user_identities, contoh "google"
+	Provider     string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// StateTTLMinutes batas waktu user menyelesaikan login di halaman provider
+	StateTTLMinutes int
+	TimeoutSeconds  int
+}
+
+// Enabled mengecek apakah login OIDC dikonfigurasi
+func (c OIDCConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
+// ExportConfig menampung konfigurasi export data user dalam bentuk ZIP
+type ExportConfig struct {
+	// StorageDir folder lokal tempat file ZIP disimpan sampai link download kedaluwarsa
+	StorageDir string
+	// LinkTTLHours masa berlaku link download, file dihapus setelah link kedaluwarsa
+	LinkTTLHours int
+	// Workers jumlah export yang dibuat bersamaan
+	Workers int
+	// TimeoutMinutes batas waktu membuat satu file export
+	TimeoutMinutes int
+	// MaxAttachmentBytes ukuran maksimal satu lampiran yang diunduh ke dalam ZIP
+	MaxAttachmentBytes int64
+	// AttachmentTimeoutSeconds batas waktu mengunduh satu lampiran
+	AttachmentTimeoutSeconds int
+	// SigningSecret kunci HMAC link download. Jika kosong diturunkan dari JWT secret,
+	// isi agar JWT secret bisa dirotasi tanpa membatalkan link yang sudah dikirim
+	SigningSecret string
+}
+
+// SearchConfig menampung konfigurasi pencarian capsule
+type SearchConfig struct {
+	// Backend pencarian: "mysql" (default, memakai index FULLTEXT) atau "memory"
+	Backend string
 }
 
 func LoadConfig() (*Config, error) {
@@ -70,11 +234,41 @@ func LoadConfig() (*Config, error) {
 		App: AppConfig{
 			Port: os.Getenv("APP_PORT"),
 			Env:  os.Getenv("APP_ENV"),
+
+			AdminEmails: getENVasList("ADMIN_EMAILS"),
+			BaseURL:     getENV("APP_BASE_URL", "http://localhost:8000"),
+			APIURL:      getENV("APP_API_URL", "http://localhost:"+os.Getenv("APP_PORT")),
+			FakeNow:     os.Getenv("APP_FAKE_NOW"),
+
+			DebugClockEnabled: getENVasBool("DEBUG_CLOCK_ENABLED", false),
 		},
 
 		JWT: JWTConfig{
 			Secret: os.Getenv("JWT_SECRET"),
-			Expiry: getENVasInt("JWT_EXPIRY", 72),
+
+			AccessTokenMinutes: getENVasInt("JWT_ACCESS_TTL_MINUTES", 15),
+			RefreshTokenDays:   getENVasInt("JWT_REFRESH_TTL_DAYS", 30),
+		},
+
+		Auth: AuthConfig{
+			PasswordResetTTLMinutes:   getENVasInt("PASSWORD_RESET_TTL_MINUTES", 30),
+			EmailVerificationTTLHours: getENVasInt("EMAIL_VERIFICATION_TTL_HOURS", 24),
+			MagicLinkTTLMinutes:       getENVasInt("MAGIC_LINK_TTL_MINUTES", 15),
+			MagicLinkMaxPerHour:       getENVasInt("MAGIC_LINK_MAX_PER_HOUR", 3),
+			TwoFactorIssuer:           getENV("TOTP_ISSUER", "Future Self Reminders"),
+			TwoFactorChallengeMinutes: getENVasInt("TOTP_CHALLENGE_TTL_MINUTES", 5),
+			TwoFactorMaxAttempts:      getENVasInt("TOTP_CHALLENGE_MAX_ATTEMPTS", 5),
+			AccessTokenDefaultDays:    getENVasInt("ACCESS_TOKEN_DEFAULT_DAYS", 90),
+			AccessTokenMaxDays:        getENVasInt("ACCESS_TOKEN_MAX_DAYS", 365),
+			AccessTokenMaxPerUser:     getENVasInt("ACCESS_TOKEN_MAX_PER_USER", 20),
+			LoginThrottleBackend:      getENV("LOGIN_THROTTLE_BACKEND", "mysql"),
+			LoginFailureWindowMinutes: getENVasInt("LOGIN_FAILURE_WINDOW_MINUTES", 15),
+			LoginMaxFailures:          getENVasInt("LOGIN_MAX_FAILURES", 5),
+			LoginMaxFailuresPerIP:     getENVasInt("LOGIN_MAX_FAILURES_PER_IP", 50),
+			LoginDelayAfterFailures:   getENVasInt("LOGIN_DELAY_AFTER_FAILURES", 2),
+			LoginMaxDelaySeconds:      getENVasInt("LOGIN_MAX_DELAY_SECONDS", 30),
+			LoginLockoutMinutes:       getENVasInt("LOGIN_LOCKOUT_MINUTES", 15),
+			AccountDeletionGraceDays:  getENVasInt("ACCOUNT_DELETION_GRACE_DAYS", 14),
 		},
 
 		Email: EmailConfig{
@@ -86,9 +280,83 @@ func LoadConfig() (*Config, error) {
 		},
 
 		Schedular: SchedularConfig{
-			CronExpression: os.Getenv("SCHEDULER_CRON"),
-			Timezone:       os.Getenv("SCHEDULER_TIMEZONE"),
+			// Default setiap menit (dengan field detik) agar capsule terkirim tepat waktu
+			CronExpression: getENV("SCHEDULER_CRON", "0 * * * * *"),
+			Timezone:       getENV("SCHEDULER_TIMEZONE", "UTC"),
+
+			MaxLatenessMinutes: getENVasInt("SCHEDULER_MAX_LATENESS_MINUTES", 60),
+			MaxAttempts:        getENVasInt("SCHEDULER_MAX_ATTEMPTS", 5),
+			RetryBaseSeconds:   getENVasInt("SCHEDULER_RETRY_BASE_SECONDS", 60),
+			RetryMaxSeconds:    getENVasInt("SCHEDULER_RETRY_MAX_SECONDS", 6*60*60),
+			LeaseSeconds:       getENVasInt("SCHEDULER_LEASE_SECONDS", 5*60),
+			BatchSize:          getENVasInt("SCHEDULER_BATCH_SIZE", 100),
+			InstanceID:         os.Getenv("SCHEDULER_INSTANCE_ID"),
+			LockLeaseSeconds:   getENVasInt("SCHEDULER_LOCK_LEASE_SECONDS", 60),
+			Workers:            getENVasInt("SCHEDULER_WORKERS", 4),
+			SendTimeoutSeconds: getENVasInt("SCHEDULER_SEND_TIMEOUT_SECONDS", 30),
+			RunTimeoutMinutes:  getENVasInt("SCHEDULER_RUN_TIMEOUT_MINUTES", 30),
+
+			RateLimits:           getENVasRateMap("SCHEDULER_RATE_LIMITS"),
+			DefaultRatePerSecond: getENVasFloat("SCHEDULER_DEFAULT_RATE", 0),
+			RunRetentionDays:     getENVasInt("SCHEDULER_RUN_RETENTION_DAYS", 30),
+
+			UnverifiedDeferMinutes: getENVasInt("SCHEDULER_UNVERIFIED_DEFER_MINUTES", 60),
+
+			// Default setiap jam di menit ke 30 agar tidak bersamaan dengan run pengiriman
+			AccountPurgeCronExpression: getENV("SCHEDULER_ACCOUNT_PURGE_CRON", "0 30 * * * *"),
+		},
+
+		Webhook: WebhookConfig{
+			URL:            os.Getenv("WEBHOOK_URL"),
+			Secret:         os.Getenv("WEBHOOK_SECRET"),
+			TimeoutSeconds: getENVasInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+		},
+
+		Telegram: TelegramConfig{
+			APIURL:         getENV("TELEGRAM_API_URL", "https://api.telegram.org"),
+			BotToken:       os.Getenv("TELEGRAM_BOT_TOKEN"),
+			TimeoutSeconds: getENVasInt("TELEGRAM_TIMEOUT_SECONDS", 10),
+		},
+
+		SMS: SMSConfig{
+			APIURL:         os.Getenv("SMS_API_URL"),
+			APIKey:         os.Getenv("SMS_API_KEY"),
+			From:           os.Getenv("SMS_FROM"),
+			TimeoutSeconds: getENVasInt("SMS_TIMEOUT_SECONDS", 10),
+		},
+
+		OIDC: OIDCConfig{
+			Provider:        getENV("OIDC_PROVIDER", "oidc"),
+			Issuer:          strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+			ClientID:        os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret:    os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:     os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:          getENVasList("OIDC_SCOPES"),
+			StateTTLMinutes: getENVasInt("OIDC_STATE_TTL_MINUTES", 10),
+			TimeoutSeconds:  getENVasInt("OIDC_TIMEOUT_SECONDS", 10),
 		},
+
+		Export: ExportConfig{
+			StorageDir:               getENV("EXPORT_STORAGE_DIR", "storage/exports"),
+			LinkTTLHours:             getENVasInt("EXPORT_LINK_TTL_HOURS", 24),
+			Workers:                  getENVasInt("EXPORT_WORKERS", 2),
+			TimeoutMinutes:           getENVasInt("EXPORT_TIMEOUT_MINUTES", 10),
+			MaxAttachmentBytes:       int64(getENVasInt("EXPORT_MAX_ATTACHMENT_BYTES", 10*1024*1024)),
+			AttachmentTimeoutSeconds: getENVasInt("EXPORT_ATTACHMENT_TIMEOUT_SECONDS", 15),
+			SigningSecret:            getENV("EXPORT_SIGNING_SECRET", ""),
+		},
+
+		Search: SearchConfig{
+			Backend: getENV("SEARCH_BACKEND", "mysql"),
+		},
+	}
+
+	// Default redirect mengarah ke endpoint callback API ini sendiri
+	if config.OIDC.RedirectURL == "" {
+		config.OIDC.RedirectURL = strings.TrimSuffix(config.App.APIURL, "/") + "/api/v1/auth/oidc/callback"
+	}
+	if len(config.OIDC.Scopes) == 0 {
+		config.OIDC.Scopes = []string{"openid", "email", "profile"}
 	}
 
 	if err := config.Validate(); err != nil {
@@ -134,13 +402,50 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("SMTP_PASSWORD is required")
 	}
 
+	// Clock debug bisa memajukan waktu pengiriman capsule, jangan pernah aktif di production
+	if c.App.DebugClockEnabled && c.IsProduction() {
+		return fmt.Errorf("DEBUG_CLOCK_ENABLED must not be set in production")
+	}
+	if c.App.FakeNow != "" && !c.App.DebugClockEnabled {
+		return fmt.Errorf("APP_FAKE_NOW requires DEBUG_CLOCK_ENABLED=true")
+	}
+
+	if c.Auth.TwoFactorMaxAttempts < 1 {
+		return fmt.Errorf("TOTP_CHALLENGE_MAX_ATTEMPTS must be at least 1")
+	}
+
+	// Cek backend login throttle
+	if c.Auth.LoginThrottleBackend != "mysql" && c.Auth.LoginThrottleBackend != "memory" {
+		return fmt.Errorf("LOGIN_THROTTLE_BACKEND must be mysql or memory")
+	}
+
+	// Cek backend pencarian capsule
+	if c.Search.Backend != "mysql" && c.Search.Backend != "memory" {
+		return fmt.Errorf("SEARCH_BACKEND must be mysql or memory")
+	}
+
+	// Masa tenggang penghapusan akun minimal satu hari agar link pembatalan sempat dibuka
+	if c.Auth.AccountDeletionGraceDays < 1 {
+		return fmt.Errorf("ACCOUNT_DELETION_GRACE_DAYS must be at least 1")
+	}
+
+	// Cek OIDC config (jika login OIDC diaktifkan)
+	if c.OIDC.Issuer != "" && c.OIDC.ClientID == "" {
+		return fmt.Errorf("OIDC_CLIENT_ID is required when OIDC_ISSUER is set")
+	}
+
 	// Semua validasi passed
 	return nil
 }
 
+// IsProduction mengecek apakah aplikasi berjalan di production
+func (c *Config) IsProduction() bool {
+	return c.App.Env == "production"
+}
+
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		"%s:%s@tcp(%s:%s)/%s?parseTime=true&loc=UTC",
 		c.Database.User,
 		c.Database.Password,
 		c.Database.Host,
@@ -149,6 +454,76 @@ func (c *Config) GetDSN() string {
 	)
 }
 
+func getENV(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+func getENVasFloat(key string, defaultValue float64) float64 {
+	valueSTR := os.Getenv(key)
+
+	if valueSTR == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueSTR, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getENVasList membaca format "a,b,c" menjadi slice, nilai kosong diabaikan
+func getENVasList(key string) []string {
+	values := []string{}
+
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		value := strings.TrimSpace(part)
+		if value == "" {
+			continue
+		}
+
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// getENVasRateMap membaca format "email=5,sms=0.5" menjadi map channel -> rate per detik
+func getENVasRateMap(key string) map[string]float64 {
+	limits := make(map[string]float64)
+
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		channel, rate, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		perSecond, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil {
+			continue
+		}
+
+		limits[strings.TrimSpace(channel)] = perSecond
+	}
+
+	return limits
+}
+
+func getENVasBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
 func getENVasInt(key string, defaultValue int) int {
 	valueSTR := os.Getenv(key)
 
@@ -0,0 +1,86 @@
+// Package handler
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"future-letter/internal/models"
+	service "future-letter/internal/service/scheduler"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas jumlah riwayat run yang dikembalikan
+const (
+	defaultRunsLimit = 20
+	maxRunsLimit     = 100
+)
+
+type SchedulerHandler struct {
+	schedulerService service.SchedulerService
+}
+
+func NewSchedulerHandler(schedulerService service.SchedulerService) *SchedulerHandler {
+	return &SchedulerHandler{
+		schedulerService: schedulerService,
+	}
+}
+
+// GetRuns handler riwayat run scheduler, gunakan ?limit=N (maksimal 100)
+func (h *SchedulerHandler) GetRuns(c *gin.Context) {
+	limit := defaultRunsLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 {
+			utils.BadRequestResponse(c, "Invalid limit")
+			return
+		}
+		limit = min(value, maxRunsLimit)
+	}
+
+	runs, err := h.schedulerService.ListRuns(c.Request.Context(), limit)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get scheduler runs: "+err.Error())
+		return
+	}
+
+	responseRuns := make([]*models.SchedulerRunResponse, 0, len(runs))
+	for i := range runs {
+		responseRuns = append(responseRuns, runs[i].ToResponse())
+	}
+
+	utils.SuccessResponse(c, "Scheduler runs retrieved successfully", responseRuns)
+}
+
+// GetStatus handler status scheduler beserta jadwal run berikutnya
+func (h *SchedulerHandler) GetStatus(c *gin.Context) {
+	status, err := h.schedulerService.Status(c.Request.Context())
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get scheduler status: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, "Scheduler status retrieved successfully", status)
+}
+
+// RunNow handler untuk menjalankan scheduler secara manual
+func (h *SchedulerHandler) RunNow(c *gin.Context) {
+	run, err := h.schedulerService.RunManually()
+	if err != nil {
+		if err.Error() == "scheduler lock held by another instance" {
+			utils.ErrorResponse(c, http.StatusConflict, "Scheduler is already running on another instance")
+			return
+		}
+		if err.Error() == "scheduler run already in progress" {
+			utils.ErrorResponse(c, http.StatusConflict, "Scheduler run is already in progress")
+			return
+		}
+
+		utils.InternalServerErrorResponse(c, "Failed to run scheduler: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, "Scheduler run completed", run.ToResponse())
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"log"
+	"strconv"
+
+	"future-letter/internal/middleware"
+	"future-letter/internal/models"
+	service "future-letter/internal/service/admin"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas jumlah user yang dikembalikan per halaman
+const (
+	defaultUsersLimit = 20
+	maxUsersLimit     = 100
+)
+
+type UserHandler struct {
+	adminService service.AdminService
+}
+
+func NewUserHandler(adminService service.AdminService) *UserHandler {
+	return &UserHandler{
+		adminService: adminService,
+	}
+}
+
+// ListUsers mencari user, gunakan ?q=, ?role=, ?status=active|disabled, ?limit= dan ?offset=
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	filter := models.UserFilter{
+		Query:  c.Query("q"),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+		Limit:  defaultUsersLimit,
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 {
+			utils.BadRequestResponse(c, "Invalid limit")
+			return
+		}
+		filter.Limit = min(value, maxUsersLimit)
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		value, err := strconv.Atoi(offsetStr)
+		if err != nil || value < 0 {
+			utils.BadRequestResponse(c, "Invalid offset")
+			return
+		}
+		filter.Offset = value
+	}
+
+	users, total, err := h.adminService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		if err.Error() == "invalid role" || err.Error() == "invalid status" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to get users")
+		return
+	}
+
+	responseUsers := make([]*models.AdminUserResponse, 0, len(users))
+	for i := range users {
+		responseUsers = append(responseUsers, users[i].ToAdminResponse())
+	}
+
+	utils.SuccessResponse(c, "Users retrieved successfully", &models.UserListResponse{
+		Users:  responseUsers,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// GetUser menampilkan detail satu user
+func (h *UserHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.NotFoundResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to get user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User retrieved successfully", user.ToAdminResponse())
+}
+
+// GetUserCapsules menampilkan capsule user tanpa judul dan isi pesan.
+// Admin bisa menambahkan ?include_content=true, akses ini dicatat di log
+func (h *UserHandler) GetUserCapsules(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	includeContent := c.Query("include_content") == "true"
+	if includeContent {
+		role, _ := middleware.GetRole(c)
+		if role != models.RoleAdmin {
+			utils.ForbiddenResponse(c, "Only admins can view capsule content")
+			return
+		}
+	}
+
+	capsules, err := h.adminService.ListUserCapsules(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.NotFoundResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to get capsules")
+		return
+	}
+
+	if includeContent {
+		actorID, _ := middleware.GetUserID(c)
+		log.Printf("Admin %d viewed capsule content of user %d", actorID, userID)
+
+		responseCapsules := make([]*models.CapsuleResponse, 0, len(capsules))
+		for i := range capsules {
+			responseCapsules = append(responseCapsules, capsules[i].ToResponse())
+		}
+
+		utils.SuccessResponse(c, "Capsules retrieved successfully", responseCapsules)
+		return
+	}
+
+	responseCapsules := make([]*models.CapsuleMetadataResponse, 0, len(capsules))
+	for i := range capsules {
+		responseCapsules = append(responseCapsules, capsules[i].ToMetadataResponse())
+	}
+
+	utils.SuccessResponse(c, "Capsules retrieved successfully", responseCapsules)
+}
+
+// DisableUser menonaktifkan akun user, semua sesi dan access token nya ikut dicabut
+func (h *UserHandler) DisableUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var input models.DisableUserInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	if err := h.adminService.DisableUser(c.Request.Context(), actorID, userID, input.Reason); err != nil {
+		switch err.Error() {
+		case "user not found or already disabled":
+			utils.NotFoundResponse(c, err.Error())
+		case "cannot disable your own account":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to disable user")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "User disabled successfully", nil)
+}
+
+// EnableUser mengaktifkan kembali akun user
+func (h *UserHandler) EnableUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	if err := h.adminService.EnableUser(c.Request.Context(), actorID, userID); err != nil {
+		if err.Error() == "user not found or not disabled" {
+			utils.NotFoundResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to enable user")
+		return
+	}
+
+	utils.SuccessResponse(c, "User enabled successfully", nil)
+}
+
+// UpdateRole mengganti role user
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateRoleInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	if err := h.adminService.UpdateRole(c.Request.Context(), actorID, userID, input.Role); err != nil {
+		switch err.Error() {
+		case "user not found":
+			utils.NotFoundResponse(c, err.Error())
+		case "invalid role", "cannot change your own role":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to update role")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Role updated successfully", nil)
+}
+
+// GetStats menampilkan ringkasan user, capsule dan pengiriman
+func (h *UserHandler) GetStats(c *gin.Context) {
+	stats, err := h.adminService.GetStats(c.Request.Context())
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get stats")
+		return
+	}
+
+	utils.SuccessResponse(c, "Stats retrieved successfully", stats)
+}
+
+// parseUserID membaca :userID dari path, mengirim 400 jika tidak valid
+func parseUserID(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid user ID")
+		return 0, false
+	}
+
+	return userID, true
+}
@@ -2,7 +2,10 @@
 package handler
 
 import (
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"future-letter/internal/middleware"
 	"future-letter/internal/models"
@@ -12,6 +15,12 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Batas jumlah hasil pencarian per halaman
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
 type CapsuleHandler struct {
 	capsuleService service.CapsuleService
 }
@@ -43,7 +52,7 @@ func (h *CapsuleHandler) CreateCapsule(c *gin.Context) {
 	if err != nil {
 		// Handle error yang berbeda
 		errMsg := err.Error()
-		if errMsg == "invalid date format, use YYYY-MM-DD" || errMsg == "due date must be in the future" {
+		if isValidationError(errMsg) {
 			utils.BadRequestResponse(c, errMsg)
 			return
 		}
@@ -55,6 +64,9 @@ func (h *CapsuleHandler) CreateCapsule(c *gin.Context) {
 	utils.CreatedResponse(c, "Capsule created successfully", capsule.ToResponse())
 }
 
+// GetAllCapsules mengambil capsule user per halaman.
+// Filter: ?status=, ?category=, ?mood=, ?delivery_method=, ?due_from=, ?due_to=.
+// Urutan: ?sort=due_date|created_at|title dan ?order=asc|desc, halaman berikutnya dengan ?cursor=
 func (h *CapsuleHandler) GetAllCapsules(c *gin.Context) {
 	// dapatkan user ID
 	userID, ok := middleware.GetUserID(c)
@@ -63,20 +75,90 @@ func (h *CapsuleHandler) GetAllCapsules(c *gin.Context) {
 		return
 	}
 
+	params := &models.CapsuleListParams{
+		Cursor:         c.Query("cursor"),
+		Status:         c.Query("status"),
+		Category:       c.Query("category"),
+		Mood:           c.Query("mood"),
+		DeliveryMethod: c.Query("delivery_method"),
+		DueFrom:        c.Query("due_from"),
+		DueTo:          c.Query("due_to"),
+		Sort:           c.Query("sort"),
+		Order:          c.Query("order"),
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil {
+			utils.BadRequestResponse(c, "Invalid limit")
+			return
+		}
+		params.Limit = value
+	}
+
 	// Panggil servce dengan context
-	capsules, err := h.capsuleService.GetUserCapsule(c.Request.Context(), userID)
+	page, err := h.capsuleService.ListCapsules(c.Request.Context(), userID, params)
 	if err != nil {
+		if isListQueryError(err.Error()) {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
 		utils.InternalServerErrorResponse(c, "Failed to get capsules: "+err.Error())
 		return
 	}
 
 	// Konversikan ke format respons
-	responseCapsules := make([]*models.CapsuleResponse, 0, len(capsules))
-	for i := range capsules {
-		responseCapsules = append(responseCapsules, capsules[i].ToResponse())
+	responseCapsules := make([]*models.CapsuleResponse, 0, len(page.Capsules))
+	for i := range page.Capsules {
+		responseCapsules = append(responseCapsules, page.Capsules[i].ToResponse())
 	}
 
-	utils.SuccessResponse(c, "Capsules retrieved successfully", responseCapsules)
+	utils.SuccessResponse(c, "Capsules retrieved successfully", &models.CapsuleListResponse{
+		Capsules:   responseCapsules,
+		Pagination: page.Pagination,
+	})
+}
+
+// SearchCapsules mencari capsule berdasarkan judul dan isi pesan, gunakan ?q=, ?limit= dan ?offset=
+func (h *CapsuleHandler) SearchCapsules(c *gin.Context) {
+	// dapatkan user ID
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.BadRequestResponse(c, "User not authenticated")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 {
+			utils.BadRequestResponse(c, "Invalid limit")
+			return
+		}
+		limit = min(value, maxSearchLimit)
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		value, err := strconv.Atoi(offsetStr)
+		if err != nil || value < 0 {
+			utils.BadRequestResponse(c, "Invalid offset")
+			return
+		}
+		offset = value
+	}
+
+	result, err := h.capsuleService.SearchCapsules(c.Request.Context(), userID, c.Query("q"), limit, offset)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "search query") {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to search capsules")
+		return
+	}
+
+	utils.SuccessResponse(c, "Capsules retrieved successfully", result)
 }
 
 func (h *CapsuleHandler) GetCapsuleByID(c *gin.Context) {
@@ -136,7 +218,7 @@ func (h *CapsuleHandler) UpdateCapsule(c *gin.Context) {
 	capsule, err := h.capsuleService.UpdateCapsule(c.Request.Context(), capsuleID, userID, &input)
 	if err != nil {
 		errMsg := err.Error()
-		if errMsg == "cannot update capsule that is not pending" || errMsg == "invalid date format, use YYYY-MM-DD" || errMsg == "due date must be in the future" {
+		if errMsg == "cannot update capsule that is not pending" || isValidationError(errMsg) {
 			utils.BadRequestResponse(c, errMsg)
 			return
 		}
@@ -184,3 +266,140 @@ func (h *CapsuleHandler) DeleteCapsule(c *gin.Context) {
 
 	utils.SuccessResponse(c, "Capsule deleted successfully", nil)
 }
+
+func (h *CapsuleHandler) GetDeliveryHistory(c *gin.Context) {
+	// Dapatkan user ID
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.BadRequestResponse(c, "User not authenticated")
+		return
+	}
+
+	// Dapatkan capsule ID
+	capsuleID, err := strconv.Atoi(c.Param("capsuleID"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid capsule ID")
+		return
+	}
+
+	// Panggil service dengan context
+	attempts, err := h.capsuleService.GetDeliveryHistory(c.Request.Context(), capsuleID, userID)
+	if err != nil {
+		if err.Error() == "capsule not found" {
+			utils.NotFoundResponse(c, "Capsule not found")
+			return
+		}
+
+		utils.InternalServerErrorResponse(c, "Failed to get delivery history: "+err.Error())
+		return
+	}
+
+	// Konversikan ke format respons
+	responseAttempts := make([]*models.DeliveryAttemptResponse, 0, len(attempts))
+	for i := range attempts {
+		responseAttempts = append(responseAttempts, attempts[i].ToResponse())
+	}
+
+	utils.SuccessResponse(c, "Delivery history retrieved successfully", responseAttempts)
+}
+
+// maxImportBytes ukuran maksimal request import (semua file)
+const maxImportBytes = 10 << 20
+
+// ImportCapsules handler import capsule dari file multipart (field "file", boleh lebih dari satu).
+// Format JSON, CSV atau Markdown dengan YAML front matter ditentukan dari ekstensi atau field "format".
+// Gunakan dry_run=true untuk memvalidasi tanpa menyimpan
+func (h *CapsuleHandler) ImportCapsules(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid multipart form or file larger than 10MB")
+		return
+	}
+
+	headers := form.File["file"]
+	if len(headers) == 0 {
+		utils.BadRequestResponse(c, "File is required")
+		return
+	}
+
+	format := c.PostForm("format")
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+
+	files := make([]models.CapsuleImportFile, 0, len(headers))
+	for _, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			utils.BadRequestResponse(c, "Failed to read file "+header.Filename)
+			return
+		}
+
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			utils.BadRequestResponse(c, "Failed to read file "+header.Filename)
+			return
+		}
+
+		files = append(files, models.CapsuleImportFile{
+			Name:    header.Filename,
+			Format:  format,
+			Content: content,
+		})
+	}
+
+	// Panggil service dengan context
+	result, err := h.capsuleService.ImportCapsules(c.Request.Context(), userID, files, dryRun)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errMsg == "import has invalid rows":
+			utils.ValidateErrorResponse(c, result)
+		case errMsg == "import file is empty" || strings.HasPrefix(errMsg, "too many capsules"):
+			utils.BadRequestResponse(c, errMsg)
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to import capsules")
+		}
+		return
+	}
+
+	if dryRun {
+		utils.SuccessResponse(c, "Dry run completed, no capsules were saved", result)
+		return
+	}
+
+	utils.CreatedResponse(c, "Capsules imported successfully", result)
+}
+
+// isListQueryError mengecek apakah error dari service disebabkan query string yang tidak valid
+func isListQueryError(errMsg string) bool {
+	switch errMsg {
+	case "invalid cursor",
+		"invalid sort, use one of: due_date, created_at, title",
+		"invalid order, use asc or desc",
+		"invalid due_from, use YYYY-MM-DD or RFC3339",
+		"invalid due_to, use YYYY-MM-DD or RFC3339":
+		return true
+	}
+
+	return strings.HasPrefix(errMsg, "limit must be between") || strings.HasPrefix(errMsg, "invalid status")
+}
+
+// isValidationError mengecek apakah error dari service disebabkan input user
+func isValidationError(errMsg string) bool {
+	switch errMsg {
+	case "invalid date format, use YYYY-MM-DD or YYYY-MM-DD HH:MM",
+		"invalid time format, use HH:MM",
+		"due date must be in the future":
+		return true
+	}
+
+	return strings.HasPrefix(errMsg, "unsupported delivery method")
+}
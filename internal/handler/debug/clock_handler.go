@@ -0,0 +1,85 @@
+// Package handler
+package handler
+
+import (
+	"time"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClockHandler endpoint debug untuk menggeser waktu aplikasi (hanya di luar production)
+type ClockHandler struct {
+	clock *clock.OffsetClock
+}
+
+func NewClockHandler(clock *clock.OffsetClock) *ClockHandler {
+	return &ClockHandler{
+		clock: clock,
+	}
+}
+
+// SetClockInput isi salah satu: now (RFC3339) atau advance (durasi Go, contoh "24h")
+type SetClockInput struct {
+	Now     string `json:"now"`
+	Advance string `json:"advance"`
+}
+
+type clockResponse struct {
+	Now           time.Time `json:"now"`
+	SystemNow     time.Time `json:"system_now"`
+	OffsetSeconds int64     `json:"offset_seconds"`
+}
+
+func (h *ClockHandler) response() clockResponse {
+	return clockResponse{
+		Now:           h.clock.Now().UTC(),
+		SystemNow:     time.Now().UTC(),
+		OffsetSeconds: int64(h.clock.Offset().Seconds()),
+	}
+}
+
+// GetClock handler waktu aplikasi saat ini
+func (h *ClockHandler) GetClock(c *gin.Context) {
+	utils.SuccessResponse(c, "Clock retrieved successfully", h.response())
+}
+
+// SetClock handler untuk menggeser waktu aplikasi
+func (h *ClockHandler) SetClock(c *gin.Context) {
+	var input SetClockInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	switch {
+	case input.Now != "":
+		now, err := time.Parse(time.RFC3339, input.Now)
+		if err != nil {
+			utils.BadRequestResponse(c, "invalid now format, use RFC3339")
+			return
+		}
+		h.clock.Set(now)
+	case input.Advance != "":
+		duration, err := time.ParseDuration(input.Advance)
+		if err != nil {
+			utils.BadRequestResponse(c, "invalid advance format, use duration like 24h")
+			return
+		}
+		h.clock.Advance(duration)
+	default:
+		utils.BadRequestResponse(c, "now or advance is required")
+		return
+	}
+
+	utils.SuccessResponse(c, "Clock updated successfully", h.response())
+}
+
+// ResetClock handler untuk mengembalikan waktu aplikasi ke waktu sistem
+func (h *ClockHandler) ResetClock(c *gin.Context) {
+	h.clock.Reset()
+
+	utils.SuccessResponse(c, "Clock reset successfully", h.response())
+}
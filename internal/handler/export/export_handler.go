@@ -0,0 +1,127 @@
+// Package handler
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"future-letter/internal/middleware"
+	"future-letter/internal/models"
+	service "future-letter/internal/service/export"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exportService service.ExportService
+}
+
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// CreateExport meminta export ZIP baru, file dibuat di background dan link download dikirim ke email
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	export, err := h.exportService.RequestExport(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "export already in progress" {
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to request export")
+		return
+	}
+
+	utils.AcceptedResponse(c, "Export requested, a download link will be sent to your email when it is ready", export.ToResponse(""))
+}
+
+// GetExports mengambil daftar export terbaru milik user
+func (h *ExportHandler) GetExports(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	exports, err := h.exportService.ListExports(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get exports")
+		return
+	}
+
+	// Konversikan ke format respons
+	response := make([]*models.DataExportResponse, 0, len(exports))
+	for i := range exports {
+		response = append(response, exports[i].ToResponse(h.exportService.DownloadURL(&exports[i])))
+	}
+
+	utils.SuccessResponse(c, "Exports retrieved successfully", response)
+}
+
+// GetExport mengambil status satu export, download_url terisi setelah export selesai
+func (h *ExportHandler) GetExport(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	exportID, err := strconv.Atoi(c.Param("exportID"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid export ID")
+		return
+	}
+
+	export, err := h.exportService.GetExport(c.Request.Context(), userID, exportID)
+	if err != nil {
+		if err.Error() == "export not found" {
+			utils.NotFoundResponse(c, "Export not found")
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to get export")
+		return
+	}
+
+	utils.SuccessResponse(c, "Export retrieved successfully", export.ToResponse(h.exportService.DownloadURL(export)))
+}
+
+// DownloadExport mengunduh file ZIP lewat link bertanda tangan dari email, tidak butuh login
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	exportID, err := strconv.Atoi(c.Param("exportID"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid export ID")
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || c.Query("signature") == "" {
+		utils.BadRequestResponse(c, "Invalid download link")
+		return
+	}
+
+	export, err := h.exportService.ResolveDownload(c.Request.Context(), exportID, expires, c.Query("signature"))
+	if err != nil {
+		switch err.Error() {
+		case "invalid download link":
+			utils.ForbiddenResponse(c, err.Error())
+		case "download link expired", "export not found":
+			utils.ErrorResponse(c, http.StatusGone, "download link expired")
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to download export")
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("future-letter-export-%d-%s.zip", export.UserID, export.CompletedAt.Time.Format("20060102"))
+	c.FileAttachment(export.FilePath.String, filename)
+}
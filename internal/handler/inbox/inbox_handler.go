@@ -0,0 +1,113 @@
+// Package handler
+package handler
+
+import (
+	"strconv"
+
+	"future-letter/internal/middleware"
+	"future-letter/internal/models"
+	service "future-letter/internal/service/inbox"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InboxHandler struct {
+	inboxService service.InboxService
+}
+
+func NewInboxHandler(inboxService service.InboxService) *InboxHandler {
+	return &InboxHandler{
+		inboxService: inboxService,
+	}
+}
+
+// GetInbox handler, gunakan ?unread=true untuk pesan yang belum dibaca saja
+func (h *InboxHandler) GetInbox(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+
+	// Panggil service dengan context
+	messages, err := h.inboxService.GetInbox(c.Request.Context(), userID, unreadOnly)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get inbox: "+err.Error())
+		return
+	}
+
+	// Konversikan ke format respons
+	responseMessages := make([]*models.InboxMessageResponse, 0, len(messages))
+	for i := range messages {
+		responseMessages = append(responseMessages, messages[i].ToResponse())
+	}
+
+	utils.SuccessResponse(c, "Inbox retrieved successfully", responseMessages)
+}
+
+func (h *InboxHandler) GetUnreadCount(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	count, err := h.inboxService.CountUnread(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to count unread messages")
+		return
+	}
+
+	utils.SuccessResponse(c, "Unread count retrieved successfully", map[string]any{
+		"unread_count": count,
+	})
+}
+
+func (h *InboxHandler) MarkAsRead(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	// Dapatkan message ID
+	messageID, err := strconv.Atoi(c.Param("messageID"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid message ID")
+		return
+	}
+
+	err = h.inboxService.MarkAsRead(c.Request.Context(), messageID, userID)
+	if err != nil {
+		if err.Error() == "inbox message not found" {
+			utils.NotFoundResponse(c, "Inbox message not found")
+			return
+		}
+
+		utils.InternalServerErrorResponse(c, "Failed to mark message as read: "+err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, "Message marked as read", nil)
+}
+
+func (h *InboxHandler) MarkAllAsRead(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	updated, err := h.inboxService.MarkAllAsRead(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to mark messages as read")
+		return
+	}
+
+	utils.SuccessResponse(c, "All messages marked as read", map[string]any{
+		"updated": updated,
+	})
+}
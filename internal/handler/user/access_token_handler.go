@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"future-letter/internal/middleware"
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAccessToken membuat personal access token, token asli hanya ditampilkan di respons ini
+func (h *authHandler) CreateAccessToken(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var input models.CreateAccessTokenInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	token, err := h.userService.CreateAccessToken(c.Request.Context(), userID, &input)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid scope") ||
+			strings.HasPrefix(err.Error(), "token lifetime cannot exceed") ||
+			err.Error() == "access token limit reached" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to create access token")
+		return
+	}
+
+	utils.CreatedResponse(c, "Access token created successfully, copy it now because it will not be shown again", token)
+}
+
+// GetAccessTokens menampilkan personal access token aktif tanpa token aslinya
+func (h *authHandler) GetAccessTokens(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	tokens, err := h.userService.ListAccessTokens(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get access tokens")
+		return
+	}
+
+	responseTokens := make([]*models.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responseTokens = append(responseTokens, tokens[i].ToResponse())
+	}
+
+	utils.SuccessResponse(c, "Access tokens retrieved successfully", responseTokens)
+}
+
+// RevokeAccessToken mencabut personal access token
+func (h *authHandler) RevokeAccessToken(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	tokenID, err := strconv.Atoi(c.Param("tokenID"))
+	if err != nil {
+		utils.BadRequestResponse(c, "Invalid token ID")
+		return
+	}
+
+	if err := h.userService.RevokeAccessToken(c.Request.Context(), userID, tokenID); err != nil {
+		if err.Error() == "access token not found" {
+			utils.NotFoundResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to revoke access token")
+		return
+	}
+
+	utils.SuccessResponse(c, "Access token revoked successfully", nil)
+}
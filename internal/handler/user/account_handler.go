@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"future-letter/internal/middleware"
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePassword mengganti password dengan password lama sebagai konfirmasi.
+// Semua sesi lain dicabut, perangkat ini mendapat sesi dan token baru
+func (h *authHandler) ChangePassword(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var input models.ChangePasswordInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.ChangePassword(c.Request.Context(), userID, &input)
+	if err != nil {
+		switch err.Error() {
+		case "invalid current password":
+			utils.UnauthorizedResponse(c, err.Error())
+		case "new password must be different", "no password set, use forgot password to create one":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to change password")
+		}
+		return
+	}
+
+	tokens, err := h.userService.IssueTokens(c.Request.Context(), user, clientInfo(c))
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Password changed but failed to generate token, please login again")
+		return
+	}
+
+	utils.SuccessResponse(c, "Password changed successfully", authResponse(user, tokens))
+}
+
+// ChangeEmail mengirim link konfirmasi ke alamat email baru
+func (h *authHandler) ChangeEmail(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var input models.ChangeEmailInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	if err := h.userService.RequestEmailChange(c.Request.Context(), userID, &input); err != nil {
+		switch err.Error() {
+		case "invalid password":
+			utils.UnauthorizedResponse(c, err.Error())
+		case "email already registered", "new email must be different", "no password set, use forgot password to create one":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to request email change")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Confirmation link sent to the new email address", nil)
+}
+
+// ConfirmEmailChange handler untuk link konfirmasi email baru, token dikirim lewat ?token=
+func (h *authHandler) ConfirmEmailChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.BadRequestResponse(c, "Token is required")
+		return
+	}
+
+	user, err := h.userService.ConfirmEmailChange(c.Request.Context(), token)
+	if err != nil {
+		switch err.Error() {
+		case "invalid or expired token", "email already registered":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to change email")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Email changed successfully", user.ToResponse())
+}
+
+// DeleteAccount menjadwalkan penghapusan akun setelah password (dan kode 2FA jika aktif) dicek ulang.
+// Akun baru dihapus permanen setelah masa tenggang, link pembatalan dikirim ke email
+func (h *authHandler) DeleteAccount(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var input models.DeleteAccountInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.DeleteAccount(c.Request.Context(), userID, &input)
+	if err != nil {
+		switch err.Error() {
+		case "invalid password", "invalid two factor code":
+			utils.UnauthorizedResponse(c, err.Error())
+		case "account deletion already scheduled":
+			utils.ErrorResponse(c, http.StatusConflict, err.Error())
+		case "two factor code required", "no password set, use forgot password to create one":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to delete account")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Account scheduled for deletion, a cancel link has been sent to your email", user.ToResponse())
+}
+
+// CancelAccountDeletion handler untuk link pembatalan penghapusan akun, token dikirim lewat ?token=
+func (h *authHandler) CancelAccountDeletion(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.BadRequestResponse(c, "Token is required")
+		return
+	}
+
+	user, err := h.userService.CancelAccountDeletion(c.Request.Context(), token)
+	if err != nil {
+		switch err.Error() {
+		case "invalid or expired token", "account deletion not scheduled":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to cancel account deletion")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Account deletion canceled", user.ToResponse())
+}
+
+// ExportAccount mengunduh seluruh data user (profil, capsule, riwayat pengiriman, inbox) sebagai file JSON
+func (h *authHandler) ExportAccount(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	export, err := h.userService.ExportAccount(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to export account data")
+		return
+	}
+
+	filename := fmt.Sprintf("future-letter-export-%d-%s.json", userID, export.ExportedAt.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.IndentedJSON(http.StatusOK, export)
+}
@@ -2,9 +2,18 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
 	"future-letter/internal/config"
 	"future-letter/internal/middleware"
 	"future-letter/internal/models"
+	oidcService "future-letter/internal/service/oidc"
 	service "future-letter/internal/service/user"
 	"future-letter/internal/utils"
 
@@ -13,12 +22,15 @@ import (
 
 type authHandler struct {
 	userService service.UserService
+	// oidcService nil jika login OIDC tidak dikonfigurasi
+	oidcService oidcService.OIDCService
 	cfg         *config.Config
 }
 
-func NewUserHandler(userService service.UserService, cfg *config.Config) *authHandler {
+func NewUserHandler(userService service.UserService, oidcService oidcService.OIDCService, cfg *config.Config) *authHandler {
 	return &authHandler{
 		userService: userService,
+		oidcService: oidcService,
 		cfg:         cfg,
 	}
 }
@@ -36,7 +48,7 @@ func (h *authHandler) Register(c *gin.Context) {
 	// Panggil service dengan context
 	user, err := h.userService.Register(c.Request.Context(), &input)
 	if err != nil {
-		if err.Error() == "email already registered" {
+		if err.Error() == "email already registered" || err.Error() == "invalid timezone" {
 			utils.BadRequestResponse(c, err.Error())
 			return
 		}
@@ -44,19 +56,14 @@ func (h *authHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate jwt token
-	token, err := utils.GenerateToken(user.ID, user.Email, h.cfg.JWT.Expiry)
+	// Buat sesi baru beserta access dan refresh token
+	tokens, err := h.userService.IssueTokens(c.Request.Context(), user, clientInfo(c))
 	if err != nil {
 		utils.InternalServerErrorResponse(c, "Failed to generate token")
 		return
 	}
 
-	responseData := map[string]any{
-		"user":  user.ToResponse(),
-		"token": token,
-	}
-
-	utils.CreatedResponse(c, "User registered successfully", responseData)
+	utils.CreatedResponse(c, "User registered successfully", authResponse(user, tokens))
 }
 
 func (h *authHandler) Login(c *gin.Context) {
@@ -69,25 +76,64 @@ func (h *authHandler) Login(c *gin.Context) {
 	}
 
 	// Panggil service dengan context
-	user, err := h.userService.Login(c.Request.Context(), &input)
+	user, err := h.userService.Login(c.Request.Context(), &input, clientInfo(c))
 	if err != nil {
-		utils.UnauthorizedResponse(c, err.Error())
+		if throttledResponse(c, err) {
+			return
+		}
+		if err.Error() == "invalid email or password" {
+			utils.UnauthorizedResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to login")
 		return
 	}
 
-	// Generate token
-	token, err := utils.GenerateToken(user.ID, user.Email, h.cfg.JWT.Expiry)
-	if err != nil {
-		utils.InternalServerErrorResponse(c, err.Error())
+	h.completeLogin(c, user)
+}
+
+// throttledResponse membalas 429 jika percobaan login dalam jeda atau akun terkunci,
+// beri tahu kapan boleh mencoba lagi
+func throttledResponse(c *gin.Context, err error) bool {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	utils.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+	return true
+}
+
+// completeLogin menyelesaikan login yang identitasnya sudah terbukti (password, magic link atau OIDC)
+func (h *authHandler) completeLogin(c *gin.Context, user *models.User) {
+	// Akun yang dinonaktifkan admin tidak boleh login dengan cara apa pun
+	if user.IsDisabled() {
+		utils.ForbiddenResponse(c, "account disabled")
+		return
+	}
+
+	// User dengan 2FA aktif harus menukar challenge token dengan kode authenticator
+	if user.IsTwoFactorEnabled() {
+		challenge, err := h.userService.CreateTwoFactorChallenge(c.Request.Context(), user)
+		if err != nil {
+			utils.InternalServerErrorResponse(c, "Failed to generate token")
+			return
+		}
+
+		utils.SuccessResponse(c, "Two factor authentication required", challenge)
 		return
 	}
 
-	responseData := map[string]any{
-		"user":  user.ToResponse(),
-		"token": token,
+	// Buat sesi baru beserta access dan refresh token
+	tokens, err := h.userService.IssueTokens(c.Request.Context(), user, clientInfo(c))
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
 	}
 
-	utils.SuccessResponse(c, "Login successfully", responseData)
+	utils.SuccessResponse(c, "Login successfully", authResponse(user, tokens))
 }
 
 func (h *authHandler) GetProfile(c *gin.Context) {
@@ -127,6 +173,10 @@ func (h *authHandler) UpdateProfile(c *gin.Context) {
 	// Panggil service dengan context
 	user, err := h.userService.UpdateProfile(c.Request.Context(), userID, &input)
 	if err != nil {
+		if err.Error() == "invalid timezone" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
 		utils.InternalServerErrorResponse(c, "Failed to update profile")
 		return
 	}
@@ -134,7 +184,193 @@ func (h *authHandler) UpdateProfile(c *gin.Context) {
 	utils.SuccessResponse(c, "Profile updated successfully", user.ToResponse())
 }
 
+// RefreshToken menukar refresh token dengan pasangan token baru, refresh token lama tidak berlaku lagi
 func (h *authHandler) RefreshToken(c *gin.Context) {
+	var input models.RefreshTokenInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	tokens, err := h.userService.RefreshTokens(c.Request.Context(), input.RefreshToken, clientInfo(c))
+	if err != nil {
+		if err.Error() == "invalid refresh token" || err.Error() == "refresh token reused" {
+			utils.UnauthorizedResponse(c, err.Error())
+			return
+		}
+		if err.Error() == "account disabled" {
+			utils.ForbiddenResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to refresh token")
+		return
+	}
+
+	utils.SuccessResponse(c, "Token refreshed successfully", tokens)
+}
+
+// Logout mencabut sesi yang sedang dipakai
+func (h *authHandler) Logout(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	sessionID, ok := middleware.GetSessionID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	if err := h.userService.Logout(c.Request.Context(), userID, sessionID); err != nil {
+		if err.Error() == "session not found" {
+			utils.NotFoundResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to logout")
+		return
+	}
+
+	utils.SuccessResponse(c, "Logout successfully", nil)
+}
+
+// LogoutAll mencabut semua sesi user di semua perangkat
+func (h *authHandler) LogoutAll(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	revoked, err := h.userService.LogoutAll(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to logout from all devices")
+		return
+	}
+
+	utils.SuccessResponse(c, "Logout from all devices successfully", map[string]any{
+		"revoked_sessions": revoked,
+	})
+}
+
+// GetSessions menampilkan sesi aktif beserta perangkat, IP dan waktu terakhir dipakai
+func (h *authHandler) GetSessions(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	currentSessionID, _ := middleware.GetSessionID(c)
+
+	sessions, err := h.userService.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to get sessions")
+		return
+	}
+
+	responseSessions := make([]*models.SessionResponse, 0, len(sessions))
+	for i := range sessions {
+		responseSessions = append(responseSessions, sessions[i].ToResponse(currentSessionID))
+	}
+
+	utils.SuccessResponse(c, "Sessions retrieved successfully", responseSessions)
+}
+
+// maxUserAgentLength panjang maksimal user agent yang disimpan
+const maxUserAgentLength = 512
+
+// clientInfo mengambil informasi perangkat dari request
+func clientInfo(c *gin.Context) models.ClientInfo {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return models.ClientInfo{
+		UserAgent: userAgent,
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// authResponse respons login dan register berisi user dan token sesi nya
+func authResponse(user *models.User, tokens *models.AuthTokens) map[string]any {
+	return map[string]any{
+		"user":                     user.ToResponse(),
+		"token":                    tokens.AccessToken,
+		"token_expires_at":         tokens.AccessExpiresAt,
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshExpiresAt,
+	}
+}
+
+// forgotPasswordMessage respons yang sama untuk email terdaftar maupun tidak
+const forgotPasswordMessage = "If the email is registered, a password reset link has been sent"
+
+func (h *authHandler) ForgotPassword(c *gin.Context) {
+	var input models.ForgotPasswordInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(c.Request.Context(), &input); err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to process password reset request")
+		return
+	}
+
+	utils.SuccessResponse(c, forgotPasswordMessage, nil)
+}
+
+func (h *authHandler) ResetPassword(c *gin.Context) {
+	var input models.ResetPasswordInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	err := h.userService.ResetPassword(c.Request.Context(), &input)
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to reset password")
+		return
+	}
+
+	utils.SuccessResponse(c, "Password reset successfully, please login again", nil)
+}
+
+// VerifyEmail handler untuk link verifikasi email, token dikirim lewat ?token=
+func (h *authHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.BadRequestResponse(c, "Token is required")
+		return
+	}
+
+	err := h.userService.VerifyEmail(c.Request.Context(), token)
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to verify email")
+		return
+	}
+
+	utils.SuccessResponse(c, "Email verified successfully", nil)
+}
+
+func (h *authHandler) ResendVerification(c *gin.Context) {
 	// Get user id dari context
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
@@ -142,23 +378,251 @@ func (h *authHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Get user email dari context
-	email, ok := middleware.GetEmail(c)
+	err := h.userService.ResendVerificationEmail(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "email already verified" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to resend verification email")
+		return
+	}
+
+	utils.SuccessResponse(c, "Verification email sent", nil)
+}
+
+// LoginTwoFactor langkah kedua login untuk user dengan 2FA aktif
+func (h *authHandler) LoginTwoFactor(c *gin.Context) {
+	var input models.TwoFactorLoginInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.CompleteTwoFactorLogin(c.Request.Context(), &input, clientInfo(c))
+	if err != nil {
+		if throttledResponse(c, err) {
+			return
+		}
+		if err.Error() == "invalid or expired challenge token" || err.Error() == "invalid two factor code" {
+			utils.UnauthorizedResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to login")
+		return
+	}
+
+	tokens, err := h.userService.IssueTokens(c.Request.Context(), user, clientInfo(c))
+	if err != nil {
+		if err.Error() == "account disabled" {
+			utils.ForbiddenResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to generate token")
+		return
+	}
+
+	utils.SuccessResponse(c, "Login successfully", authResponse(user, tokens))
+}
+
+// SetupTwoFactor membuat secret TOTP baru untuk di scan aplikasi authenticator
+func (h *authHandler) SetupTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	setup, err := h.userService.SetupTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "two factor already enabled" {
+			utils.BadRequestResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to setup two factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, "Scan the secret with your authenticator app, then confirm with a code", setup)
+}
+
+// ConfirmTwoFactor mengaktifkan 2FA dan mengembalikan recovery code
+func (h *authHandler) ConfirmTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
 	if !ok {
 		utils.UnauthorizedResponse(c, "User not authenticated")
 		return
 	}
 
-	// Generate token baru
-	newToken, err := utils.GenerateToken(userID, email, h.cfg.JWT.Expiry)
+	var input models.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	codes, err := h.userService.ConfirmTwoFactor(c.Request.Context(), userID, input.Code)
 	if err != nil {
-		utils.InternalServerErrorResponse(c, "Failed to generate new token")
+		switch err.Error() {
+		case "two factor already enabled", "two factor setup required", "invalid two factor code":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to enable two factor authentication")
+		}
 		return
 	}
 
-	resposeData := map[string]any{
-		"token": newToken,
+	utils.SuccessResponse(c, "Two factor authentication enabled, store these recovery codes safely", map[string]any{
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactor menonaktifkan 2FA
+func (h *authHandler) DisableTwoFactor(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		utils.UnauthorizedResponse(c, "User not authenticated")
+		return
+	}
+
+	var input models.DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	err := h.userService.DisableTwoFactor(c.Request.Context(), userID, &input)
+	if err != nil {
+		switch err.Error() {
+		case "two factor not enabled", "invalid password", "invalid two factor code":
+			utils.BadRequestResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to disable two factor authentication")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, "Two factor authentication disabled", nil)
+}
+
+// magicLinkMessage respons yang sama untuk email terdaftar maupun tidak
+const magicLinkMessage = "If the email is registered, a login link has been sent"
+
+// RequestMagicLink mengirim link login tanpa password ke email
+func (h *authHandler) RequestMagicLink(c *gin.Context) {
+	var input models.MagicLinkInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	if err := h.userService.RequestMagicLink(c.Request.Context(), &input); err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to process login link request")
+		return
+	}
+
+	utils.SuccessResponse(c, magicLinkMessage, nil)
+}
+
+// MagicLinkRedirect mengarahkan link login lama yang masih menunjuk ke API ke halaman frontend.
+// GET tidak memakai token, karena scanner link di email ikut membuka link tersebut
+func (h *authHandler) MagicLinkRedirect(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.BadRequestResponse(c, "Token is required")
+		return
+	}
+
+	c.Redirect(http.StatusFound, fmt.Sprintf("%s/magic-link?token=%s", h.cfg.App.BaseURL, url.QueryEscape(token)))
+}
+
+// ConsumeMagicLink menukar token dari link login dengan respons yang sama seperti Login
+func (h *authHandler) ConsumeMagicLink(c *gin.Context) {
+	var input models.ConsumeMagicLinkInput
+
+	// Bind request body
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.BadRequestResponse(c, err.Error())
+		return
+	}
+
+	user, err := h.userService.ConsumeMagicLink(c.Request.Context(), input.Token)
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			utils.UnauthorizedResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to login")
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// oidcStateCookie cookie yang mengikat state OIDC ke browser yang memulai login
+const oidcStateCookie = "oidc_state"
+
+// setOIDCStateCookie menyimpan state di cookie HttpOnly yang hanya dikirim ke endpoint OIDC.
+// SameSite Lax agar cookie tetap ikut saat provider me-redirect balik ke callback
+func (h *authHandler) setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/v1/auth/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(h.cfg.App.APIURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// OIDCLogin mengarahkan user ke halaman login provider OIDC
+func (h *authHandler) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.oidcService.AuthCodeURL(c.Request.Context())
+	if err != nil {
+		utils.InternalServerErrorResponse(c, "Failed to start login")
+		return
+	}
+
+	h.setOIDCStateCookie(c, state, h.cfg.OIDC.StateTTLMinutes*60)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback menerima redirect dari provider dan menyelesaikan login seperti Login biasa
+func (h *authHandler) OIDCCallback(c *gin.Context) {
+	// Provider mengembalikan error jika user membatalkan login atau request ditolak
+	if providerError := c.Query("error"); providerError != "" {
+		utils.UnauthorizedResponse(c, "Login cancelled: "+providerError)
+		return
+	}
+
+	// Cookie state hanya berlaku untuk satu callback
+	browserState, _ := c.Cookie(oidcStateCookie)
+	h.setOIDCStateCookie(c, "", -1)
+
+	identity, err := h.oidcService.Exchange(c.Request.Context(), c.Query("state"), c.Query("code"), browserState)
+	if err != nil {
+		switch err.Error() {
+		case "invalid or expired state", "invalid id token", "failed to exchange authorization code":
+			utils.UnauthorizedResponse(c, err.Error())
+		default:
+			utils.InternalServerErrorResponse(c, "Failed to login")
+		}
+		return
+	}
+
+	user, err := h.userService.LoginWithOIDC(c.Request.Context(), identity)
+	if err != nil {
+		if err.Error() == "email not verified by provider" {
+			utils.ForbiddenResponse(c, err.Error())
+			return
+		}
+		utils.InternalServerErrorResponse(c, "Failed to login")
+		return
 	}
 
-	utils.SuccessResponse(c, "Token refreshed successfully", resposeData)
+	h.completeLogin(c, user)
 }
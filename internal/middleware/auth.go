@@ -2,14 +2,25 @@
 package middleware
 
 import (
+	"context"
 	"strings"
 
+	"future-letter/internal/models"
 	"future-letter/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRequired() gin.HandlerFunc {
+// SessionValidator mengecek apakah sesi pada claims token masih berlaku
+type SessionValidator func(ctx context.Context, claims *utils.JWTClaims) error
+
+// AccessTokenValidator memvalidasi personal access token dan mengembalikan pemilik serta scope nya
+type AccessTokenValidator func(ctx context.Context, token string) (*models.User, []string, error)
+
+// AuthRequired menerima JWT dari sesi login dan personal access token.
+// JWT boleh mengakses semua route. Personal access token hanya diterima di route
+// yang menyebutkan scope, dan token harus memiliki semua scope tersebut
+func AuthRequired(validateSession SessionValidator, validateAccessToken AccessTokenValidator, scopes ...string) gin.HandlerFunc {
 	// Return function akan dijalankan saat ada request
 	return func(c *gin.Context) {
 		// authHeader ini bisasanya berbentuk : Bearer <token>
@@ -24,7 +35,7 @@ func AuthRequired() gin.HandlerFunc {
 		parts := strings.Split(authHeader, " ")
 
 		// Validasi format harus ada 2 parts dan parts pertama harus berisi "Bearer"
-		if len(parts) != 2 || parts[0] == "Bearer" {
+		if len(parts) != 2 || parts[0] != "Bearer" {
 			utils.UnauthorizedResponse(c, "Invalid authorization format.")
 			c.Abort()
 			return
@@ -33,6 +44,12 @@ func AuthRequired() gin.HandlerFunc {
 		// Parts ke 2 adalah token
 		tokenString := parts[1]
 
+		// Personal access token dikenali dari awalannya
+		if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+			authenticateAccessToken(c, validateAccessToken, tokenString, scopes)
+			return
+		}
+
 		// ValidateJWT dari utils akan mengecek token masih valid dan belum expired
 		claims, err := utils.ValidateJWT(tokenString)
 		if err != nil {
@@ -41,16 +58,73 @@ func AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		// Challenge token (misalnya 2FA) bukan access token
+		if claims.Purpose != "" {
+			utils.UnauthorizedResponse(c, "Invalid or expired token")
+			c.Abort()
+			return
+		}
+
+		// Token dari sesi yang sudah dicabut (logout atau password diganti) tidak berlaku
+		if err := validateSession(c.Request.Context(), claims); err != nil {
+			utils.UnauthorizedResponse(c, "Invalid or expired token")
+			c.Abort()
+			return
+		}
+
 		// c.Set untuk menyimpan data ke context dan bisa di ambil
 		// dihandler untuk mengetahui siapa yang login dengan c.Get
 		c.Set("userID", claims.ID)
 		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("sessionID", claims.SessionID)
 
 		// Lanjut ke middleware/handler berikutnya
 		c.Next()
 	}
 }
 
+// authenticateAccessToken memvalidasi personal access token beserta scope yang dibutuhkan route
+func authenticateAccessToken(c *gin.Context, validateAccessToken AccessTokenValidator, token string, scopes []string) {
+	// Route tanpa scope (kelola akun, sesi, admin) hanya bisa diakses lewat login
+	if len(scopes) == 0 {
+		utils.ForbiddenResponse(c, "Personal access tokens cannot access this endpoint")
+		c.Abort()
+		return
+	}
+
+	user, granted, err := validateAccessToken(c.Request.Context(), token)
+	if err != nil {
+		utils.UnauthorizedResponse(c, "Invalid or expired token")
+		c.Abort()
+		return
+	}
+
+	for _, scope := range scopes {
+		if !containsScope(granted, scope) {
+			utils.ForbiddenResponse(c, "Token is missing required scope: "+scope)
+			c.Abort()
+			return
+		}
+	}
+
+	c.Set("userID", user.ID)
+	c.Set("email", user.Email)
+	c.Set("role", user.Role)
+
+	c.Next()
+}
+
+func containsScope(granted []string, scope string) bool {
+	for _, g := range granted {
+		if g == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetUserID untuk mengambil userID
 func GetUserID(c *gin.Context) (int, bool) {
 	// Ambil data dari context
@@ -81,3 +155,18 @@ func GetEmail(c *gin.Context) (string, bool) {
 
 	return emailSTR, true
 }
+
+// GetSessionID untuk mengambil ID sesi yang sedang login
+func GetSessionID(c *gin.Context) (int, bool) {
+	sessionID, exists := c.Get("sessionID")
+	if !exists {
+		return 0, false
+	}
+
+	id, ok := sessionID.(int)
+	if !ok {
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testAccessToken = models.PersonalAccessTokenPrefix + "test"
+
+func rejectSession(ctx context.Context, claims *utils.JWTClaims) error {
+	return errors.New("session revoked")
+}
+
+// grantScopes validator personal access token yang menerima testAccessToken dengan scope tertentu
+func grantScopes(scopes ...string) AccessTokenValidator {
+	return func(ctx context.Context, token string) (*models.User, []string, error) {
+		if token != testAccessToken {
+			return nil, nil, errors.New("access token not found")
+		}
+		return &models.User{ID: 7, Email: "user@example.com", Role: models.RoleUser}, scopes, nil
+	}
+}
+
+func TestAuthRequiredAccessTokenScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		granted   []string
+		required  []string
+		token     string
+		wantCode  int
+		wantReach bool
+	}{
+		{name: "has required scope", granted: []string{models.ScopeCapsulesRead}, required: []string{models.ScopeCapsulesRead}, token: testAccessToken, wantCode: http.StatusOK, wantReach: true},
+		{name: "has all required scopes", granted: []string{models.ScopeCapsulesRead, models.ScopeCapsulesWrite}, required: []string{models.ScopeCapsulesRead, models.ScopeCapsulesWrite}, token: testAccessToken, wantCode: http.StatusOK, wantReach: true},
+		{name: "missing one scope", granted: []string{models.ScopeCapsulesRead}, required: []string{models.ScopeCapsulesRead, models.ScopeCapsulesWrite}, token: testAccessToken, wantCode: http.StatusForbidden},
+		{name: "read scope cannot write", granted: []string{models.ScopeInboxRead}, required: []string{models.ScopeInboxWrite}, token: testAccessToken, wantCode: http.StatusForbidden},
+		{name: "route without scopes is login only", granted: []string{models.ScopeCapsulesRead}, token: testAccessToken, wantCode: http.StatusForbidden},
+		{name: "unknown token", granted: []string{models.ScopeCapsulesRead}, required: []string{models.ScopeCapsulesRead}, token: models.PersonalAccessTokenPrefix + "unknown", wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+
+			router := gin.New()
+			router.GET("/", AuthRequired(rejectSession, grantScopes(tt.granted...), tt.required...), func(c *gin.Context) {
+				reached = true
+				userID, _ := GetUserID(c)
+				if userID != 7 {
+					t.Errorf("userID = %d, want 7", userID)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode || reached != tt.wantReach {
+				t.Fatalf("status = %d, handler reached = %v, want %d and %v", rec.Code, reached, tt.wantCode, tt.wantReach)
+			}
+		})
+	}
+}
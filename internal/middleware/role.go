@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"future-letter/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole hanya mengizinkan user dengan salah satu role yang disebutkan.
+// Harus dipasang setelah AuthRequired karena membutuhkan role dari context
+func RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(c *gin.Context) {
+		role, ok := GetRole(c)
+		if !ok {
+			utils.UnauthorizedResponse(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		if !allowed[role] {
+			utils.ForbiddenResponse(c, "Insufficient role")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetRole untuk mengambil role user yang sedang login
+func GetRole(c *gin.Context) (string, bool) {
+	role, exists := c.Get("role")
+	if !exists {
+		return "", false
+	}
+
+	roleSTR, ok := role.(string)
+	if !ok {
+		return "", false
+	}
+
+	return roleSTR, true
+}
@@ -0,0 +1,110 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PersonalAccessTokenPrefix awalan personal access token, membedakannya dari JWT di header Authorization
+const PersonalAccessTokenPrefix = "flp_"
+
+// Scope yang bisa diberikan ke personal access token
+const (
+	ScopeCapsulesRead  = "capsules:read"
+	ScopeCapsulesWrite = "capsules:write"
+	ScopeInboxRead     = "inbox:read"
+	ScopeInboxWrite    = "inbox:write"
+)
+
+// AccessTokenScopes semua scope yang valid
+var AccessTokenScopes = []string{
+	ScopeCapsulesRead,
+	ScopeCapsulesWrite,
+	ScopeInboxRead,
+	ScopeInboxWrite,
+}
+
+// IsValidScope mengecek apakah scope dikenal
+func IsValidScope(scope string) bool {
+	for _, valid := range AccessTokenScopes {
+		if scope == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PersonalAccessToken token API untuk script dan integrasi, hanya hash nya yang disimpan
+type PersonalAccessToken struct {
+	ID     int    `json:"id" db:"id"`
+	UserID int    `json:"user_id" db:"user_id"`
+	Name   string `json:"name" db:"name"`
+	// TokenPrefix beberapa karakter awal token untuk membantu user mengenali token nya
+	TokenPrefix string       `json:"token_prefix" db:"token_prefix"`
+	TokenHash   string       `json:"-" db:"token_hash"`
+	Scopes      []string     `json:"scopes" db:"scopes"`
+	ExpiresAt   time.Time    `json:"expires_at" db:"expires_at"`
+	LastUsedAt  sql.NullTime `json:"last_used_at" db:"last_used_at"`
+	RevokedAt   sql.NullTime `json:"revoked_at" db:"revoked_at"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+}
+
+// HasScope mengecek apakah token diberi scope tertentu
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, granted := range t.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsActive mengecek token belum dicabut dan belum expired
+func (t *PersonalAccessToken) IsActive(now time.Time) bool {
+	return !t.RevokedAt.Valid && t.ExpiresAt.After(now)
+}
+
+type CreateAccessTokenInput struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1"`
+	// ExpiresInDays kosong berarti memakai masa berlaku default
+	ExpiresInDays int `json:"expires_in_days" binding:"omitempty,min=1"`
+}
+
+type AccessTokenResponse struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// CreatedAccessTokenResponse respons pembuatan token, satu satunya saat token asli ditampilkan
+type CreatedAccessTokenResponse struct {
+	*AccessTokenResponse
+	Token string `json:"token"`
+}
+
+// ToResponse mengkonversi PersonalAccessToken ke AccessTokenResponse
+func (t *PersonalAccessToken) ToResponse() *AccessTokenResponse {
+	response := &AccessTokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.Scopes,
+		ExpiresAt:   t.ExpiresAt,
+		CreatedAt:   t.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if t.LastUsedAt.Valid {
+		response.LastUsedAt = &t.LastUsedAt.Time
+	}
+
+	return response
+}
@@ -0,0 +1,51 @@
+// Package models
+package models
+
+import "time"
+
+// AccountExport seluruh data milik user yang bisa diunduh sebelum akun dihapus
+type AccountExport struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	Profile       *UserResponse          `json:"profile"`
+	Capsules      []CapsuleExport        `json:"capsules"`
+	InboxMessages []InboxMessageResponse `json:"inbox_messages"`
+}
+
+// CapsuleExport capsule beserta riwayat pengirimannya
+type CapsuleExport struct {
+	*CapsuleResponse
+	Deliveries []DeliveryAttemptResponse `json:"deliveries"`
+}
+
+// NewAccountExport menyusun AccountExport, riwayat pengiriman dikelompokkan ke capsule masing masing
+func NewAccountExport(user *User, capsules []Capsule, attempts []DeliveryAttempt, messages []InboxMessage, exportedAt time.Time) *AccountExport {
+	deliveries := make(map[int][]DeliveryAttemptResponse)
+	for _, attempt := range attempts {
+		deliveries[attempt.CapsuleID] = append(deliveries[attempt.CapsuleID], *attempt.ToResponse())
+	}
+
+	export := &AccountExport{
+		ExportedAt:    exportedAt.UTC(),
+		Profile:       user.ToResponse(),
+		Capsules:      make([]CapsuleExport, 0, len(capsules)),
+		InboxMessages: make([]InboxMessageResponse, 0, len(messages)),
+	}
+
+	for _, capsule := range capsules {
+		capsuleDeliveries := deliveries[capsule.ID]
+		if capsuleDeliveries == nil {
+			capsuleDeliveries = []DeliveryAttemptResponse{}
+		}
+
+		export.Capsules = append(export.Capsules, CapsuleExport{
+			CapsuleResponse: capsule.ToResponse(),
+			Deliveries:      capsuleDeliveries,
+		})
+	}
+
+	for _, message := range messages {
+		export.InboxMessages = append(export.InboxMessages, *message.ToResponse())
+	}
+
+	return export
+}
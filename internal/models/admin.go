@@ -0,0 +1,137 @@
+// Package models
+package models
+
+import "time"
+
+// Filter status akun di pencarian user admin
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+)
+
+// UserFilter filter pencarian user di admin API
+type UserFilter struct {
+	// Query dicari di nama dan email
+	Query  string
+	Role   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+type DisableUserInput struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type UpdateRoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AdminUserResponse data user untuk admin, termasuk status akun
+type AdminUserResponse struct {
+	*UserResponse
+	Disabled       bool       `json:"disabled"`
+	DisabledAt     *time.Time `json:"disabled_at"`
+	DisabledReason *string    `json:"disabled_reason"`
+}
+
+// ToAdminResponse mengkonversi User ke AdminUserResponse
+func (u *User) ToAdminResponse() *AdminUserResponse {
+	response := &AdminUserResponse{
+		UserResponse: u.ToResponse(),
+		Disabled:     u.IsDisabled(),
+	}
+
+	// Handle nullable fields
+	if u.DisabledAt.Valid {
+		response.DisabledAt = &u.DisabledAt.Time
+	}
+	if u.DisabledReason.Valid {
+		response.DisabledReason = &u.DisabledReason.String
+	}
+
+	return response
+}
+
+// UserListResponse satu halaman hasil pencarian user
+type UserListResponse struct {
+	Users  []*AdminUserResponse `json:"users"`
+	Total  int                  `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+// CapsuleMetadataResponse capsule tanpa judul dan isi pesan,
+// dipakai admin dan support agar isi surat user tetap privat
+type CapsuleMetadataResponse struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	DueDate        string     `json:"due_date"`
+	DeliveryMethod string     `json:"delivery_method"`
+	Status         string     `json:"status"`
+	Category       *string    `json:"category"`
+	SentAt         *time.Time `json:"sent_at"`
+	Overdue        bool       `json:"overdue"`
+	AttemptCount   int        `json:"attempt_count"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ToMetadataResponse mengkonversi capsule ke CapsuleMetadataResponse
+func (c *Capsule) ToMetadataResponse() *CapsuleMetadataResponse {
+	response := &CapsuleMetadataResponse{
+		ID:             c.ID,
+		UserID:         c.UserID,
+		DueDate:        c.DueDate.UTC().Format(time.RFC3339),
+		DeliveryMethod: c.DeliveryMethod,
+		Status:         c.Status,
+		Overdue:        c.Overdue,
+		AttemptCount:   c.AttemptCount,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+	}
+
+	// Handle nullable fields
+	if c.Category.Valid {
+		response.Category = &c.Category.String
+	}
+	if c.SentAt.Valid {
+		response.SentAt = &c.SentAt.Time
+	}
+	if c.NextAttemptAt.Valid {
+		response.NextAttemptAt = &c.NextAttemptAt.Time
+	}
+
+	return response
+}
+
+// SystemStats ringkasan kondisi sistem untuk admin
+type SystemStats struct {
+	Users       UserStats     `json:"users"`
+	Capsules    CapsuleStats  `json:"capsules"`
+	Deliveries  DeliveryStats `json:"deliveries"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+type UserStats struct {
+	Total      int            `json:"total"`
+	Verified   int            `json:"verified"`
+	Disabled   int            `json:"disabled"`
+	TwoFactor  int            `json:"two_factor_enabled"`
+	NewLast24h int            `json:"new_last_24h"`
+	ByRole     map[string]int `json:"by_role"`
+}
+
+type CapsuleStats struct {
+	Total    int            `json:"total"`
+	ByStatus map[string]int `json:"by_status"`
+	// DueNext24h capsule pending yang jatuh tempo dalam 24 jam ke depan
+	DueNext24h int `json:"due_next_24h"`
+}
+
+// DeliveryStats hasil percobaan pengiriman dalam 24 jam terakhir
+type DeliveryStats struct {
+	SuccessLast24h int `json:"success_last_24h"`
+	FailedLast24h  int `json:"failed_last_24h"`
+}
@@ -3,9 +3,19 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 )
 
+// Status capsule
+const (
+	CapsuleStatusPending   = "pending"
+	CapsuleStatusSending   = "sending"
+	CapsuleStatusSent      = "sent"
+	CapsuleStatusCancelled = "cancelled"
+	CapsuleStatusFailed    = "failed"
+)
+
 // Capsule struct
 type Capsule struct {
 	ID             int            `json:"id" db:"id"`
@@ -19,6 +29,9 @@ type Capsule struct {
 	Mood           sql.NullString `json:"mood" db:"mood"`
 	ImageURL       sql.NullString `json:"image_url" db:"image_url"`
 	SentAt         sql.NullTime   `json:"sent_at" db:"sent_at"`
+	Overdue        bool           `json:"overdue" db:"overdue"`
+	AttemptCount   int            `json:"attempt_count" db:"attempt_count"`
+	NextAttemptAt  sql.NullTime   `json:"next_attempt_at" db:"next_attempt_at"`
 	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
 }
@@ -30,6 +43,7 @@ type CreateCapsuleInput struct {
 	Title          string `json:"title" binding:"required"`
 	Message        string `json:"message" binding:"required"`
 	DueDate        string `json:"due_date" binding:"required"`
+	DueTime        string `json:"due_time"`
 	DeliveryMethod string `json:"delivery_method" binding:"required"`
 	Status         string `json:"status"`
 	Category       string `json:"category"`
@@ -42,6 +56,7 @@ type UpdateCapsuleInput struct {
 	Title          string `json:"title"`
 	Message        string `json:"message"`
 	DueDate        string `json:"due_date"`
+	DueTime        string `json:"due_time"`
 	DeliveryMethod string `json:"delivery_method"`
 	Status         string `json:"status"`
 	Category       string `json:"category"`
@@ -60,10 +75,19 @@ type CapsuleResponse struct {
 	Mood           *string    `json:"mood"`
 	ImageURL       *string    `json:"image_url"`
 	SentAt         *time.Time `json:"sent_at"`
+	Overdue        bool       `json:"overdue"`
+	AttemptCount   int        `json:"attempt_count"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 }
 
+// IdempotencyKey kunci unik pengiriman capsule, dikirim ke channel agar
+// pengiriman ulang capsule yang sama bisa dikenali dan diabaikan penerima
+func (c *Capsule) IdempotencyKey() string {
+	return fmt.Sprintf("capsule-%d", c.ID)
+}
+
 // ToResponse mengkonversi capsule ke CapsuleResponse
 func (c *Capsule) ToResponse() *CapsuleResponse {
 	response := &CapsuleResponse{
@@ -71,8 +95,11 @@ func (c *Capsule) ToResponse() *CapsuleResponse {
 		UserID:         c.UserID,
 		Title:          c.Title,
 		Message:        c.Message,
+		DueDate:        c.DueDate.UTC().Format(time.RFC3339),
 		DeliveryMethod: c.DeliveryMethod,
 		Status:         c.Status,
+		Overdue:        c.Overdue,
+		AttemptCount:   c.AttemptCount,
 		CreatedAt:      c.CreatedAt,
 		UpdatedAt:      c.UpdatedAt,
 	}
@@ -90,6 +117,9 @@ func (c *Capsule) ToResponse() *CapsuleResponse {
 	if c.SentAt.Valid {
 		response.SentAt = &c.SentAt.Time
 	}
+	if c.NextAttemptAt.Valid {
+		response.NextAttemptAt = &c.NextAttemptAt.Time
+	}
 
 	return response
 }
@@ -0,0 +1,36 @@
+// Package models
+package models
+
+// Format file import capsule yang didukung
+const (
+	ImportFormatJSON     = "json"
+	ImportFormatCSV      = "csv"
+	ImportFormatMarkdown = "markdown"
+)
+
+// CapsuleImportFile satu file yang diupload untuk import capsule
+type CapsuleImportFile struct {
+	Name string
+	// Format kosong berarti ditentukan dari ekstensi file
+	Format  string
+	Content []byte
+}
+
+// CapsuleImportRowError error validasi satu baris import.
+// Row dimulai dari 1 per file, Row 0 berarti file nya sendiri tidak bisa dibaca
+type CapsuleImportRowError struct {
+	File   string   `json:"file"`
+	Row    int      `json:"row"`
+	Title  string   `json:"title,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+// CapsuleImportResult hasil import, pada dry run tidak ada capsule yang disimpan
+type CapsuleImportResult struct {
+	DryRun     bool                    `json:"dry_run"`
+	Total      int                     `json:"total"`
+	Valid      int                     `json:"valid"`
+	Imported   int                     `json:"imported"`
+	Errors     []CapsuleImportRowError `json:"errors"`
+	CapsuleIDs []int                   `json:"capsule_ids,omitempty"`
+}
@@ -0,0 +1,76 @@
+// Package models
+package models
+
+import "time"
+
+// Urutan yang didukung pada daftar capsule
+const (
+	CapsuleSortDueDate   = "due_date"
+	CapsuleSortCreatedAt = "created_at"
+	CapsuleSortTitle     = "title"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// CapsuleListParams parameter query string GET /capsules, divalidasi oleh service
+type CapsuleListParams struct {
+	Limit          int
+	Cursor         string
+	Status         string
+	Category       string
+	Mood           string
+	DeliveryMethod string
+	// DueFrom dan DueTo format YYYY-MM-DD (timezone user, DueTo inklusif) atau RFC3339
+	DueFrom string
+	DueTo   string
+	Sort    string
+	Order   string
+}
+
+// CapsuleQuery query capsule yang sudah divalidasi, dipakai CapsuleRepository.Find
+type CapsuleQuery struct {
+	UserID         int
+	Status         string
+	Category       string
+	Mood           string
+	DeliveryMethod string
+	// DueFrom batas bawah due date (inklusif), DueBefore batas atas (eksklusif)
+	DueFrom   *time.Time
+	DueBefore *time.Time
+	Sort      string
+	Order     string
+	// After posisi terakhir halaman sebelumnya, nil untuk halaman pertama
+	After *CapsuleCursor
+	// Limit 0 berarti tanpa batas
+	Limit int
+}
+
+// CapsuleCursor posisi capsule terakhir pada satu halaman (keyset pagination).
+// Value berisi nilai kolom sort capsule tersebut, ID sebagai pembeda jika nilainya sama
+type CapsuleCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// CapsulePagination metadata halaman daftar capsule
+type CapsulePagination struct {
+	Limit      int     `json:"limit"`
+	Sort       string  `json:"sort"`
+	Order      string  `json:"order"`
+	HasMore    bool    `json:"has_more"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// CapsulePage satu halaman daftar capsule
+type CapsulePage struct {
+	Capsules   []Capsule
+	Pagination CapsulePagination
+}
+
+type CapsuleListResponse struct {
+	Capsules   []*CapsuleResponse `json:"capsules"`
+	Pagination CapsulePagination  `json:"pagination"`
+}
@@ -0,0 +1,52 @@
+// Package models
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CapsuleSearchQuery parameter pencarian full-text capsule milik satu user
+type CapsuleSearchQuery struct {
+	UserID int
+	// Terms kata yang dicari, sudah huruf kecil dan tanpa duplikat
+	Terms  []string
+	Limit  int
+	Offset int
+}
+
+// CapsuleSearchHit satu capsule yang cocok beserta skor relevansinya
+type CapsuleSearchHit struct {
+	Capsule Capsule
+	Score   float64
+}
+
+// CapsuleSearchHighlights potongan teks dengan kata yang cocok dibungkus <mark>.
+// Teks lain sudah di-escape sehingga aman ditampilkan sebagai HTML
+type CapsuleSearchHighlights struct {
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
+
+// CapsuleSearchResult capsule hasil pencarian beserta skor dan highlight nya
+type CapsuleSearchResult struct {
+	*CapsuleResponse
+	Score      float64                 `json:"score"`
+	Highlights CapsuleSearchHighlights `json:"highlights"`
+}
+
+// CapsuleSearchResponse satu halaman hasil pencarian, diurutkan dari yang paling relevan
+type CapsuleSearchResponse struct {
+	Query   string                `json:"query"`
+	Results []CapsuleSearchResult `json:"results"`
+	Total   int                   `json:"total"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
+}
+
+// SearchTokens memecah teks menjadi kata huruf kecil, pemisahnya semua karakter selain huruf dan angka
+func SearchTokens(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
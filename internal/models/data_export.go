@@ -0,0 +1,82 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Status export data user
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusCompleted  = "completed"
+	ExportStatusFailed     = "failed"
+	// ExportStatusExpired link download sudah kedaluwarsa dan file nya sudah dihapus
+	ExportStatusExpired = "expired"
+)
+
+// DataExport satu permintaan export data user ke file ZIP
+type DataExport struct {
+	ID     int    `json:"id" db:"id"`
+	UserID int    `json:"user_id" db:"user_id"`
+	Status string `json:"status" db:"status"`
+	// FilePath lokasi file ZIP di storage lokal, terisi setelah export selesai
+	FilePath    sql.NullString `json:"-" db:"file_path"`
+	FileSize    sql.NullInt64  `json:"file_size" db:"file_size"`
+	Error       sql.NullString `json:"error" db:"error"`
+	StartedAt   sql.NullTime   `json:"started_at" db:"started_at"`
+	CompletedAt sql.NullTime   `json:"completed_at" db:"completed_at"`
+	// ExpiresAt batas waktu link download, setelah itu file dihapus
+	ExpiresAt sql.NullTime `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+type DataExportResponse struct {
+	ID          int        `json:"id"`
+	Status      string     `json:"status"`
+	FileSize    *int64     `json:"file_size"`
+	Error       *string    `json:"error"`
+	DownloadURL *string    `json:"download_url"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ToResponse mengkonversi DataExport ke DataExportResponse.
+// downloadURL hanya diisi untuk export yang sudah selesai dan belum kedaluwarsa
+func (e *DataExport) ToResponse(downloadURL string) *DataExportResponse {
+	response := &DataExportResponse{
+		ID:        e.ID,
+		Status:    e.Status,
+		CreatedAt: e.CreatedAt,
+	}
+
+	// Handle nullable fields
+	if e.FileSize.Valid {
+		response.FileSize = &e.FileSize.Int64
+	}
+	if e.Error.Valid {
+		response.Error = &e.Error.String
+	}
+	if downloadURL != "" {
+		response.DownloadURL = &downloadURL
+	}
+	if e.StartedAt.Valid {
+		response.StartedAt = &e.StartedAt.Time
+	}
+	if e.CompletedAt.Valid {
+		response.CompletedAt = &e.CompletedAt.Time
+	}
+	if e.ExpiresAt.Valid {
+		response.ExpiresAt = &e.ExpiresAt.Time
+	}
+
+	return response
+}
+
+// IsDownloadable mengecek apakah file export masih bisa diunduh
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == ExportStatusCompleted && e.FilePath.Valid && e.ExpiresAt.Valid && now.Before(e.ExpiresAt.Time)
+}
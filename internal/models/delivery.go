@@ -0,0 +1,54 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Status delivery attempt
+const (
+	DeliveryStatusSuccess = "success"
+	DeliveryStatusFailed  = "failed"
+)
+
+// DeliveryAttempt riwayat satu kali percobaan pengiriman capsule
+type DeliveryAttempt struct {
+	ID             int            `json:"id" db:"id"`
+	CapsuleID      int            `json:"capsule_id" db:"capsule_id"`
+	Channel        string         `json:"channel" db:"channel"`
+	IdempotencyKey string         `json:"idempotency_key" db:"idempotency_key"`
+	AttemptNumber  int            `json:"attempt_number" db:"attempt_number"`
+	Status         string         `json:"status" db:"status"`
+	Error          sql.NullString `json:"error" db:"error"`
+	Overdue        bool           `json:"overdue" db:"overdue"`
+	AttemptedAt    time.Time      `json:"attempted_at" db:"attempted_at"`
+}
+
+type DeliveryAttemptResponse struct {
+	ID            int       `json:"id"`
+	Channel       string    `json:"channel"`
+	AttemptNumber int       `json:"attempt_number"`
+	Status        string    `json:"status"`
+	Error         *string   `json:"error"`
+	Overdue       bool      `json:"overdue"`
+	AttemptedAt   time.Time `json:"attempted_at"`
+}
+
+// ToResponse mengkonversi DeliveryAttempt ke DeliveryAttemptResponse
+func (d *DeliveryAttempt) ToResponse() *DeliveryAttemptResponse {
+	response := &DeliveryAttemptResponse{
+		ID:            d.ID,
+		Channel:       d.Channel,
+		AttemptNumber: d.AttemptNumber,
+		Status:        d.Status,
+		Overdue:       d.Overdue,
+		AttemptedAt:   d.AttemptedAt,
+	}
+
+	if d.Error.Valid {
+		response.Error = &d.Error.String
+	}
+
+	return response
+}
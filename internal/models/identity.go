@@ -0,0 +1,32 @@
+// Package models
+package models
+
+import "time"
+
+// UserIdentity akun di provider OIDC yang terhubung ke user
+type UserIdentity struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Provider  string    `json:"provider" db:"provider"`
+	Subject   string    `json:"subject" db:"subject"`
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// OAuthState state login OIDC yang sedang berjalan, menyimpan PKCE verifier dan nonce
+type OAuthState struct {
+	ID           int       `json:"id" db:"id"`
+	StateHash    string    `json:"-" db:"state_hash"`
+	CodeVerifier string    `json:"-" db:"code_verifier"`
+	Nonce        string    `json:"-" db:"nonce"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// ExternalIdentity identitas user hasil verifikasi ID token provider
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
@@ -0,0 +1,66 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Delivery method yang didukung oleh capsule
+const (
+	DeliveryMethodEmail = "email"
+	DeliveryMethodInApp = "in_app"
+)
+
+// InboxMessage capsule yang dikirim ke inbox user (delivery method in_app)
+type InboxMessage struct {
+	ID          int            `json:"id" db:"id"`
+	UserID      int            `json:"user_id" db:"user_id"`
+	CapsuleID   int            `json:"capsule_id" db:"capsule_id"`
+	Title       string         `json:"title" db:"title"`
+	Message     string         `json:"message" db:"message"`
+	Category    sql.NullString `json:"category" db:"category"`
+	Mood        sql.NullString `json:"mood" db:"mood"`
+	WrittenAt   time.Time      `json:"written_at" db:"written_at"`
+	ReadAt      sql.NullTime   `json:"read_at" db:"read_at"`
+	DeliveredAt time.Time      `json:"delivered_at" db:"delivered_at"`
+}
+
+type InboxMessageResponse struct {
+	ID          int        `json:"id"`
+	CapsuleID   int        `json:"capsule_id"`
+	Title       string     `json:"title"`
+	Message     string     `json:"message"`
+	Category    *string    `json:"category"`
+	Mood        *string    `json:"mood"`
+	WrittenAt   time.Time  `json:"written_at"`
+	IsRead      bool       `json:"is_read"`
+	ReadAt      *time.Time `json:"read_at"`
+	DeliveredAt time.Time  `json:"delivered_at"`
+}
+
+// ToResponse mengkonversi InboxMessage ke InboxMessageResponse
+func (m *InboxMessage) ToResponse() *InboxMessageResponse {
+	response := &InboxMessageResponse{
+		ID:          m.ID,
+		CapsuleID:   m.CapsuleID,
+		Title:       m.Title,
+		Message:     m.Message,
+		WrittenAt:   m.WrittenAt,
+		IsRead:      m.ReadAt.Valid,
+		DeliveredAt: m.DeliveredAt,
+	}
+
+	// Handle nullable fields
+	if m.Category.Valid {
+		response.Category = &m.Category.String
+	}
+	if m.Mood.Valid {
+		response.Mood = &m.Mood.String
+	}
+	if m.ReadAt.Valid {
+		response.ReadAt = &m.ReadAt.Time
+	}
+
+	return response
+}
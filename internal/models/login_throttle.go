@@ -0,0 +1,45 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Jenis kunci percobaan login gagal
+const (
+	ThrottleKindAccount = "account"
+	ThrottleKindIP      = "ip"
+)
+
+// LoginThrottle catatan login gagal untuk satu akun (email) atau satu IP
+type LoginThrottle struct {
+	Kind     string `json:"kind" db:"kind"`
+	Key      string `json:"key" db:"throttle_key"`
+	Failures int    `json:"failures" db:"failures"`
+	// WindowStartedAt awal rentang perhitungan, failures dihitung ulang setelah rentang lewat
+	WindowStartedAt time.Time `json:"window_started_at" db:"window_started_at"`
+	// NextAttemptAt percobaan berikutnya baru boleh dilakukan setelah waktu ini (jeda progresif)
+	NextAttemptAt sql.NullTime `json:"next_attempt_at" db:"next_attempt_at"`
+	LockedUntil   sql.NullTime `json:"locked_until" db:"locked_until"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// BlockedUntil waktu paling akhir percobaan login ditolak, zero jika tidak diblokir
+func (t *LoginThrottle) BlockedUntil(now time.Time) time.Time {
+	var until time.Time
+
+	if t.LockedUntil.Valid && t.LockedUntil.Time.After(now) {
+		until = t.LockedUntil.Time
+	}
+	if t.NextAttemptAt.Valid && t.NextAttemptAt.Time.After(now) && t.NextAttemptAt.Time.After(until) {
+		until = t.NextAttemptAt.Time
+	}
+
+	return until
+}
+
+// IsLocked mengecek apakah kunci sedang dikunci (bukan sekedar jeda)
+func (t *LoginThrottle) IsLocked(now time.Time) bool {
+	return t.LockedUntil.Valid && t.LockedUntil.Time.After(now)
+}
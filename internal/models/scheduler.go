@@ -0,0 +1,114 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SchedulerLock lease lock di database agar hanya satu replica yang menjalankan scheduler
+type SchedulerLock struct {
+	Name       string    `json:"name" db:"name"`
+	Holder     string    `json:"holder" db:"holder"`
+	AcquiredAt time.Time `json:"acquired_at" db:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at" db:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// IsHeld mengecek apakah lock masih dipegang pada waktu now
+func (l *SchedulerLock) IsHeld(now time.Time) bool {
+	return l.ExpiresAt.After(now)
+}
+
+// Trigger dan status scheduler run
+const (
+	SchedulerTriggerCron   = "cron"
+	SchedulerTriggerManual = "manual"
+
+	SchedulerRunRunning   = "running"
+	SchedulerRunCompleted = "completed"
+	SchedulerRunFailed    = "failed"
+)
+
+// SchedulerRun riwayat satu kali run scheduler pengiriman capsule
+type SchedulerRun struct {
+	ID            int            `json:"id" db:"id"`
+	TriggerSource string         `json:"trigger" db:"trigger_source"`
+	InstanceID    string         `json:"instance_id" db:"instance_id"`
+	Status        string         `json:"status" db:"status"`
+	Total         int            `json:"total" db:"total"`
+	Sent          int            `json:"sent" db:"sent"`
+	Retried       int            `json:"retried" db:"retried"`
+	Failed        int            `json:"failed" db:"failed"`
+	Deferred      int            `json:"deferred" db:"deferred"`
+	Overdue       int            `json:"overdue" db:"overdue"`
+	Error         sql.NullString `json:"error" db:"error"`
+	StartedAt     time.Time      `json:"started_at" db:"started_at"`
+	FinishedAt    sql.NullTime   `json:"finished_at" db:"finished_at"`
+}
+
+type SchedulerRunResponse struct {
+	ID            int        `json:"id"`
+	Trigger       string     `json:"trigger"`
+	InstanceID    string     `json:"instance_id"`
+	Status        string     `json:"status"`
+	Total         int        `json:"total"`
+	Sent          int        `json:"sent"`
+	Retried       int        `json:"retried"`
+	Failed        int        `json:"failed"`
+	Deferred      int        `json:"deferred"`
+	Overdue       int        `json:"overdue"`
+	Error         *string    `json:"error"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	DurationMS    *int64     `json:"duration_ms"`
+	ThroughputSec *float64   `json:"throughput_per_second"`
+}
+
+// ToResponse mengkonversi SchedulerRun ke SchedulerRunResponse
+func (r *SchedulerRun) ToResponse() *SchedulerRunResponse {
+	response := &SchedulerRunResponse{
+		ID:         r.ID,
+		Trigger:    r.TriggerSource,
+		InstanceID: r.InstanceID,
+		Status:     r.Status,
+		Total:      r.Total,
+		Sent:       r.Sent,
+		Retried:    r.Retried,
+		Failed:     r.Failed,
+		Deferred:   r.Deferred,
+		Overdue:    r.Overdue,
+		StartedAt:  r.StartedAt,
+	}
+
+	if r.Error.Valid {
+		response.Error = &r.Error.String
+	}
+
+	if r.FinishedAt.Valid {
+		response.FinishedAt = &r.FinishedAt.Time
+
+		duration := r.FinishedAt.Time.Sub(r.StartedAt)
+		durationMS := duration.Milliseconds()
+		response.DurationMS = &durationMS
+
+		if duration > 0 {
+			throughput := float64(r.Total) / duration.Seconds()
+			response.ThroughputSec = &throughput
+		}
+	}
+
+	return response
+}
+
+// SchedulerStatus status scheduler untuk admin
+type SchedulerStatus struct {
+	InstanceID     string                `json:"instance_id"`
+	CronExpression string                `json:"cron_expression"`
+	Timezone       string                `json:"timezone"`
+	Running        bool                  `json:"running"`
+	NextRunAt      *time.Time            `json:"next_run_at"`
+	Lock           *SchedulerLock        `json:"lock"`
+	LockHeld       bool                  `json:"lock_held"`
+	LastRun        *SchedulerRunResponse `json:"last_run"`
+}
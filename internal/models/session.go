@@ -0,0 +1,95 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Alasan sesi dicabut
+const (
+	SessionRevokedLogout          = "logout"
+	SessionRevokedLogoutAll       = "logout_all"
+	SessionRevokedTokenReuse      = "token_reuse"
+	SessionRevokedPasswordReset   = "password_reset"
+	SessionRevokedPasswordChange  = "password_change"
+	SessionRevokedEmailChange     = "email_change"
+	SessionRevokedAccountLinked   = "account_linked"
+	SessionRevokedAccountDisabled = "account_disabled"
+)
+
+// Session sesi login satu perangkat, dipertahankan lewat refresh token yang dirotasi
+type Session struct {
+	ID            int            `json:"id" db:"id"`
+	UserID        int            `json:"user_id" db:"user_id"`
+	UserAgent     sql.NullString `json:"user_agent" db:"user_agent"`
+	IPAddress     sql.NullString `json:"ip_address" db:"ip_address"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	LastSeenAt    time.Time      `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt     time.Time      `json:"expires_at" db:"expires_at"`
+	RevokedAt     sql.NullTime   `json:"revoked_at" db:"revoked_at"`
+	RevokedReason sql.NullString `json:"revoked_reason" db:"revoked_reason"`
+}
+
+// IsActive mengecek apakah sesi belum dicabut dan belum expired
+func (s *Session) IsActive(now time.Time) bool {
+	return !s.RevokedAt.Valid && s.ExpiresAt.After(now)
+}
+
+// RefreshToken token refresh opaque milik satu sesi, hanya hash nya yang disimpan
+type RefreshToken struct {
+	ID        int          `json:"id" db:"id"`
+	SessionID int          `json:"session_id" db:"session_id"`
+	TokenHash string       `json:"-" db:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at" db:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at" db:"used_at"`
+}
+
+// AuthTokens pasangan access token (JWT) dan refresh token yang dikirim ke client
+type AuthTokens struct {
+	AccessToken      string    `json:"token"`
+	AccessExpiresAt  time.Time `json:"token_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// ClientInfo informasi perangkat yang membuat sesi
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+type RefreshTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type SessionResponse struct {
+	ID         int       `json:"id"`
+	UserAgent  *string   `json:"user_agent"`
+	IPAddress  *string   `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ToResponse mengkonversi Session ke SessionResponse
+func (s *Session) ToResponse(currentSessionID int) *SessionResponse {
+	response := &SessionResponse{
+		ID:         s.ID,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		Current:    s.ID == currentSessionID,
+	}
+
+	// Handle nullable fields
+	if s.UserAgent.Valid {
+		response.UserAgent = &s.UserAgent.String
+	}
+	if s.IPAddress.Valid {
+		response.IPAddress = &s.IPAddress.String
+	}
+
+	return response
+}
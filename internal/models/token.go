@@ -0,0 +1,58 @@
+// Package models
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Kegunaan token sekali pakai yang dikirim ke user
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMagicLink         = "magic_link"
+	TokenPurposeEmailChange       = "email_change"
+	TokenPurposeDeletionCancel    = "account_deletion_cancel"
+	// TokenPurposeTwoFactorChallenge id challenge login 2FA, dipakai sekali saat login selesai
+	TokenPurposeTwoFactorChallenge = "2fa_challenge"
+)
+
+// UserToken token sekali pakai milik user, yang disimpan hanya hash nya
+type UserToken struct {
+	ID        int    `json:"id" db:"id"`
+	UserID    int    `json:"user_id" db:"user_id"`
+	Purpose   string `json:"purpose" db:"purpose"`
+	TokenHash string `json:"-" db:"token_hash"`
+	// NewEmail alamat tujuan untuk token email_change
+	NewEmail  sql.NullString `json:"-" db:"new_email"`
+	ExpiresAt time.Time      `json:"expires_at" db:"expires_at"`
+	UsedAt    sql.NullTime   `json:"used_at" db:"used_at"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type MagicLinkInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ConsumeMagicLinkInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type ChangeEmailInput struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,34 @@
+// Package models
+package models
+
+import "time"
+
+// TwoFactorSetupResponse secret dan URI untuk didaftarkan ke aplikasi authenticator
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TwoFactorChallenge respons login saat user masih harus memasukkan kode 2FA
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorLoginInput struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code kode TOTP 6 digit atau salah satu recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type DisableTwoFactorInput struct {
+	// Password wajib untuk akun yang punya password, akun OIDC cukup kode 2FA
+	Password string `json:"password"`
+	// Code kode TOTP 6 digit atau salah satu recovery code
+	Code string `json:"code" binding:"required"`
+}
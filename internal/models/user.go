@@ -1,16 +1,51 @@
 // Package models
 package models
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
+
+// Role user, menentukan endpoint admin yang boleh diakses
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+// IsValidRole mengecek apakah role dikenal
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin || role == RoleSupport
+}
 
 type User struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	Password  string    `json:"-" db:"password"`
-	Timezone  string    `json:"timezone" db:"timezone"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdateAt  time.Time `json:"update_at" db:"update_at"`
+	ID    int    `json:"id" db:"id"`
+	Name  string `json:"name" db:"name"`
+	Email string `json:"email" db:"email"`
+	// EmailVerifiedAt terisi setelah user membuka link verifikasi email
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at" db:"email_verified_at"`
+	// Password kosong untuk akun yang hanya login lewat OIDC
+	Password       string         `json:"-" db:"password"`
+	Timezone       string         `json:"timezone" db:"timezone"`
+	PhoneNumber    sql.NullString `json:"phone_number" db:"phone_number"`
+	TelegramChatID sql.NullString `json:"telegram_chat_id" db:"telegram_chat_id"`
+	Role           string         `json:"role" db:"role"`
+	// DisabledAt terisi jika akun dinonaktifkan admin, akun nonaktif tidak bisa login
+	DisabledAt     sql.NullTime   `json:"disabled_at" db:"disabled_at"`
+	DisabledReason sql.NullString `json:"disabled_reason" db:"disabled_reason"`
+	// DeletionRequestedAt dan DeletionScheduledAt terisi selama masa tenggang penghapusan akun,
+	// akun dihapus permanen setelah DeletionScheduledAt terlewati
+	DeletionRequestedAt sql.NullTime `json:"deletion_requested_at" db:"deletion_requested_at"`
+	DeletionScheduledAt sql.NullTime `json:"deletion_scheduled_at" db:"deletion_scheduled_at"`
+	// TokenVersion bertambah setiap password diganti, JWT dengan versi lama otomatis tidak berlaku
+	TokenVersion int `json:"-" db:"token_version"`
+	// TOTPSecret secret authenticator, baru aktif setelah TOTPEnabledAt terisi
+	TOTPSecret    sql.NullString `json:"-" db:"totp_secret"`
+	TOTPEnabledAt sql.NullTime   `json:"-" db:"totp_enabled_at"`
+	// TOTPLastStep langkah waktu kode TOTP terakhir yang dipakai, mencegah kode yang sama dipakai dua kali
+	TOTPLastStep sql.NullInt64 `json:"-" db:"totp_last_step"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+	UpdateAt     time.Time     `json:"update_at" db:"update_at"`
 }
 
 type RegisterInput struct {
@@ -25,27 +60,87 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// DeleteAccountInput konfirmasi ulang identitas sebelum akun dijadwalkan untuk dihapus
+type DeleteAccountInput struct {
+	// Password wajib untuk akun yang punya password
+	Password string `json:"password"`
+	// Code kode TOTP 6 digit atau recovery code, wajib jika 2FA aktif
+	Code string `json:"code"`
+}
+
 type UpdateProfileInput struct {
-	Name     string `json:"name" binding:"required"`
-	Timezone string `json:"timezone" binding:"required"`
+	Name           string `json:"name" binding:"required"`
+	Timezone       string `json:"timezone" binding:"required"`
+	PhoneNumber    string `json:"phone_number"`
+	TelegramChatID string `json:"telegram_chat_id"`
 }
 
 type UserResponse struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Timezone  string    `json:"timezone"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdateAt  time.Time `json:"update_at"`
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Email          string  `json:"email"`
+	EmailVerified  bool    `json:"email_verified"`
+	TwoFactor      bool    `json:"two_factor_enabled"`
+	HasPassword    bool    `json:"has_password"`
+	Role           string  `json:"role"`
+	Timezone       string  `json:"timezone"`
+	PhoneNumber    *string `json:"phone_number"`
+	TelegramChatID *string `json:"telegram_chat_id"`
+	// DeletionScheduledAt waktu akun akan dihapus permanen, null jika tidak ada penghapusan yang dijadwalkan
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdateAt            time.Time  `json:"update_at"`
 }
 
 func (u *User) ToResponse() *UserResponse {
-	return &UserResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Timezone:  u.Timezone,
-		CreatedAt: u.CreatedAt,
-		UpdateAt:  u.UpdateAt,
+	response := &UserResponse{
+		ID:            u.ID,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.IsEmailVerified(),
+		TwoFactor:     u.IsTwoFactorEnabled(),
+		HasPassword:   u.HasPassword(),
+		Role:          u.Role,
+		Timezone:      u.Timezone,
+		CreatedAt:     u.CreatedAt,
+		UpdateAt:      u.UpdateAt,
 	}
+
+	// Handle nullable fields
+	if u.PhoneNumber.Valid {
+		response.PhoneNumber = &u.PhoneNumber.String
+	}
+	if u.TelegramChatID.Valid {
+		response.TelegramChatID = &u.TelegramChatID.String
+	}
+	if u.DeletionScheduledAt.Valid {
+		response.DeletionScheduledAt = &u.DeletionScheduledAt.Time
+	}
+
+	return response
+}
+
+// IsEmailVerified mengecek apakah email user sudah diverifikasi
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt.Valid
+}
+
+// HasPassword mengecek apakah user bisa login dengan password
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
+// IsDisabled mengecek apakah akun dinonaktifkan admin
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt.Valid
+}
+
+// IsDeletionScheduled mengecek apakah akun sedang dalam masa tenggang penghapusan
+func (u *User) IsDeletionScheduled() bool {
+	return u.DeletionScheduledAt.Valid
+}
+
+// IsTwoFactorEnabled mengecek apakah user sudah mengaktifkan 2FA
+func (u *User) IsTwoFactorEnabled() bool {
+	return u.TOTPEnabledAt.Valid && u.TOTPSecret.Valid
 }
@@ -0,0 +1,19 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error)
+	ListActiveByUser(ctx context.Context, userID int, now time.Time) ([]models.PersonalAccessToken, error)
+	CountActiveByUser(ctx context.Context, userID int, now time.Time) (int, error)
+	Touch(ctx context.Context, id int, now time.Time, minInterval time.Duration) error
+	Revoke(ctx context.Context, id, userID int, now time.Time) error
+	RevokeAllByUser(ctx context.Context, userID int, now time.Time) error
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type accessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewAccessTokenRepository(db *sql.DB) AccessTokenRepository {
+	return &accessTokenRepository{
+		db: db,
+	}
+}
+
+// accessTokenColumns kolom yang diambil setiap kali membaca token, urutannya harus sama dengan scanAccessToken
+const accessTokenColumns = "id, user_id, name, token_prefix, token_hash, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAccessToken membaca satu baris token, scopes disimpan dipisah koma
+func scanAccessToken(row rowScanner) (*models.PersonalAccessToken, error) {
+	token := &models.PersonalAccessToken{}
+
+	var scopes string
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenPrefix,
+		&token.TokenHash,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+
+	return token, nil
+}
+
+// Create menyimpan token baru (hanya hash nya)
+func (r *accessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	query := `INSERT INTO personal_access_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		token.UserID,
+		token.Name,
+		token.TokenPrefix,
+		token.TokenHash,
+		strings.Join(token.Scopes, ","),
+		token.ExpiresAt.UTC(),
+		token.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	token.ID = int(id)
+	return nil
+}
+
+// GetByHash mencari token berdasarkan hash, termasuk yang sudah dicabut atau expired
+func (r *accessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	query := "SELECT " + accessTokenColumns + " FROM personal_access_tokens WHERE token_hash = ?"
+
+	token, err := scanAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("access token not found")
+		}
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListActiveByUser mengambil token user yang belum dicabut dan belum expired, terbaru di atas
+func (r *accessTokenRepository) ListActiveByUser(ctx context.Context, userID int, now time.Time) ([]models.PersonalAccessToken, error) {
+	query := "SELECT " + accessTokenColumns + ` FROM personal_access_tokens
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// CountActiveByUser menghitung token aktif milik user
+func (r *accessTokenRepository) CountActiveByUser(ctx context.Context, userID int, now time.Time) (int, error) {
+	var count int
+
+	query := "SELECT COUNT(*) FROM personal_access_tokens WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?"
+
+	err := r.db.QueryRowContext(ctx, query, userID, now.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count access tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// Touch memperbarui last_used_at, paling sering sekali setiap minInterval agar tidak menulis di setiap request
+func (r *accessTokenRepository) Touch(ctx context.Context, id int, now time.Time, minInterval time.Duration) error {
+	query := "UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)"
+
+	_, err := r.db.ExecContext(ctx, query, now.UTC(), id, now.Add(-minInterval).UTC())
+	if err != nil {
+		return fmt.Errorf("failed to touch access token: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke mencabut satu token milik user
+func (r *accessTokenRepository) Revoke(ctx context.Context, id, userID int, now time.Time) error {
+	query := "UPDATE personal_access_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, now.UTC(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("access token not found")
+	}
+
+	return nil
+}
+
+// RevokeAllByUser mencabut semua token aktif milik user
+func (r *accessTokenRepository) RevokeAllByUser(ctx context.Context, userID int, now time.Time) error {
+	query := "UPDATE personal_access_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL"
+
+	_, err := r.db.ExecContext(ctx, query, now.UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access tokens: %w", err)
+	}
+
+	return nil
+}
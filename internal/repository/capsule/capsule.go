@@ -3,16 +3,24 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"future-letter/internal/models"
 )
 
 type CapsuleRepository interface {
 	Create(ctx context.Context, capsule *models.Capsule) error
+	CreateBatch(ctx context.Context, capsules []*models.Capsule) error
 	GetByID(ctx context.Context, id int, userID int) (*models.Capsule, error)
 	GetByUserID(ctx context.Context, userID int) ([]models.Capsule, error)
+	Find(ctx context.Context, query models.CapsuleQuery) ([]models.Capsule, error)
 	Update(ctx context.Context, capsule *models.Capsule) error
 	Delete(ctx context.Context, id, userID int) error
-	GetPendingForToday(ctx context.Context) ([]models.Capsule, error)
-	MarkAsSent(ctx context.Context, id int) error
+	GetDuePending(ctx context.Context, until time.Time) ([]models.Capsule, error)
+	ClaimDue(ctx context.Context, now time.Time, limit int, claimToken string, leaseUntil time.Time) ([]models.Capsule, error)
+	MarkAsSent(ctx context.Context, id int, claimToken string, overdue bool, sentAt time.Time) error
+	ScheduleRetry(ctx context.Context, id int, claimToken string, nextAttemptAt time.Time) error
+	MarkAsFailed(ctx context.Context, id int, claimToken string) error
+	Defer(ctx context.Context, id int, claimToken string, nextAttemptAt time.Time) error
+	RecoverStaleClaims(ctx context.Context, now time.Time, maxAttempts int) (finalized, requeued, failed int, err error)
 }
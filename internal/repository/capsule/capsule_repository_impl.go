@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"future-letter/internal/models"
 )
@@ -19,6 +21,63 @@ func NewCapsuleRepository(db *sql.DB) CapsuleRepository {
 	}
 }
 
+// capsuleColumns kolom yang diambil setiap kali membaca capsule, urutannya harus sama dengan scanCapsule
+const capsuleColumns = "id, user_id, title, message, due_date, delivery_method, status, category, mood, image_url, sent_at, overdue, attempt_count, next_attempt_at, created_at, updated_at"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCapsule(row rowScanner) (*models.Capsule, error) {
+	capsule := &models.Capsule{}
+
+	err := row.Scan(
+		&capsule.ID,
+		&capsule.UserID,
+		&capsule.Title,
+		&capsule.Message,
+		&capsule.DueDate,
+		&capsule.DeliveryMethod,
+		&capsule.Status,
+		&capsule.Category,
+		&capsule.Mood,
+		&capsule.ImageURL,
+		&capsule.SentAt,
+		&capsule.Overdue,
+		&capsule.AttemptCount,
+		&capsule.NextAttemptAt,
+		&capsule.CreatedAt,
+		&capsule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return capsule, nil
+}
+
+// queryCapsules menjalankan query SELECT capsule dan membaca semua baris
+func (r *capsuleRepository) queryCapsules(ctx context.Context, query string, args ...any) ([]models.Capsule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	capsules := []models.Capsule{}
+	for rows.Next() {
+		capsule, err := scanCapsule(rows)
+		if err != nil {
+			return nil, err
+		}
+		capsules = append(capsules, *capsule)
+	}
+
+	return capsules, rows.Err()
+}
+
 // Create
 func (r *capsuleRepository) Create(ctx context.Context, capsule *models.Capsule) error {
 	query := "INSERT INTO capsules (user_id, title, message, due_date, delivery_method, category, mood, image_url, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
@@ -37,29 +96,45 @@ func (r *capsuleRepository) Create(ctx context.Context, capsule *models.Capsule)
 	return nil
 }
 
+// CreateBatch menyimpan banyak capsule dalam satu transaksi, gagal satu berarti tidak ada yang tersimpan
+func (r *capsuleRepository) CreateBatch(ctx context.Context, capsules []*models.Capsule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO capsules (user_id, title, message, due_date, delivery_method, category, mood, image_url, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare capsule insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, capsule := range capsules {
+		result, err := stmt.ExecContext(ctx, capsule.UserID, capsule.Title, capsule.Message, capsule.DueDate, capsule.DeliveryMethod, capsule.Category, capsule.Mood, capsule.ImageURL, capsule.Status)
+		if err != nil {
+			return fmt.Errorf("failed to create capsule: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		capsule.ID = int(id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit capsule import: %w", err)
+	}
+
+	return nil
+}
+
 func (r capsuleRepository) GetByID(ctx context.Context, id int, userID int) (*models.Capsule, error) {
-	capsule := &models.Capsule{}
-	query := `SELECT 
-		id, user_id, title, message, due_date, delivery_method, status, category, mood, image_url, sent_at, created_at, updated_at
-		FROM capsules
-		WHERE id = ? AND user_id = ?
-	`
+	query := "SELECT " + capsuleColumns + " FROM capsules WHERE id = ? AND user_id = ?"
 
-	err := r.db.QueryRowContext(ctx, query, id, userID).Scan(
-		&capsule.ID,
-		&capsule.UserID,
-		&capsule.Title,
-		&capsule.Message,
-		&capsule.DueDate,
-		&capsule.DeliveryMethod,
-		&capsule.Status,
-		&capsule.Category,
-		&capsule.Mood,
-		&capsule.ImageURL,
-		&capsule.SentAt,
-		&capsule.CreatedAt,
-		&capsule.UpdatedAt,
-	)
+	capsule, err := scanCapsule(r.db.QueryRowContext(ctx, query, id, userID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("capsule not found")
@@ -71,50 +146,98 @@ func (r capsuleRepository) GetByID(ctx context.Context, id int, userID int) (*mo
 }
 
 func (r *capsuleRepository) GetByUserID(ctx context.Context, userID int) ([]models.Capsule, error) {
-	query := `SELECT 
-		id, user_id, title, message, due_date, delivery_method, status, category, mood, image_url, sent_at, created_at, updated_at
-		FROM capsules
-		WHERE user_id = ?
-		ORDER BY due_date ASC
-	`
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	capsules, err := r.Find(ctx, models.CapsuleQuery{
+		UserID: userID,
+		Sort:   models.CapsuleSortDueDate,
+		Order:  models.SortOrderAsc,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get capsules by id: %w", err)
 	}
 
-	defer rows.Close()
+	return capsules, nil
+}
 
-	capsules := []models.Capsule{}
+// capsuleSortColumns kolom yang boleh dipakai untuk mengurutkan capsule
+var capsuleSortColumns = map[string]string{
+	models.CapsuleSortDueDate:   "due_date",
+	models.CapsuleSortCreatedAt: "created_at",
+	models.CapsuleSortTitle:     "title",
+}
 
-	for rows.Next() {
-		var capsule models.Capsule
-		err := rows.Scan(
-			&capsule.ID,
-			&capsule.UserID,
-			&capsule.Title,
-			&capsule.Message,
-			&capsule.DueDate,
-			&capsule.DeliveryMethod,
-			&capsule.Status,
-			&capsule.Category,
-			&capsule.Mood,
-			&capsule.ImageURL,
-			&capsule.SentAt,
-			&capsule.CreatedAt,
-			&capsule.UpdatedAt,
-		)
+// Find mengambil capsule milik user sesuai filter, urutan dan posisi cursor pada query.
+// Urutan selalu ditambah id agar posisi cursor tetap unik walau nilai kolom sort nya sama
+func (r *capsuleRepository) Find(ctx context.Context, q models.CapsuleQuery) ([]models.Capsule, error) {
+	builder := newSelect(capsuleColumns, "capsules").Where("user_id = ?", q.UserID)
+
+	if q.Status != "" {
+		builder.Where("status = ?", q.Status)
+	}
+	if q.Category != "" {
+		builder.Where("category = ?", q.Category)
+	}
+	if q.Mood != "" {
+		builder.Where("mood = ?", q.Mood)
+	}
+	if q.DeliveryMethod != "" {
+		builder.Where("delivery_method = ?", q.DeliveryMethod)
+	}
+	if q.DueFrom != nil {
+		builder.Where("due_date >= ?", q.DueFrom.UTC())
+	}
+	if q.DueBefore != nil {
+		builder.Where("due_date < ?", q.DueBefore.UTC())
+	}
+
+	column, ok := capsuleSortColumns[q.Sort]
+	if !ok {
+		return nil, errors.New("invalid sort")
+	}
+
+	direction, operator := "ASC", ">"
+	if q.Order == models.SortOrderDesc {
+		direction, operator = "DESC", "<"
+	}
+
+	// Keyset pagination: lanjutkan setelah capsule terakhir di halaman sebelumnya
+	if q.After != nil {
+		value, err := cursorValue(q.Sort, q.After.Value)
 		if err != nil {
 			return nil, err
 		}
-		capsules = append(capsules, capsule)
+
+		builder.Where(
+			"("+column+" "+operator+" ? OR ("+column+" = ? AND id "+operator+" ?))",
+			value, value, q.After.ID,
+		)
 	}
-	return capsules, nil
+
+	query, args := builder.
+		OrderBy(column+" "+direction, "id "+direction).
+		Limit(q.Limit).
+		Build()
+
+	return r.queryCapsules(ctx, query, args...)
+}
+
+// cursorValue mengubah nilai cursor ke tipe kolom sort nya
+func cursorValue(sort, value string) (any, error) {
+	if sort == models.CapsuleSortTitle {
+		return value, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return t.UTC(), nil
 }
 
 func (r *capsuleRepository) Update(ctx context.Context, capsule *models.Capsule) error {
 	query := `UPDATE capsules
 		SET title = ?, message = ?, due_date = ?, delivery_method = ?, category = ?, mood = ?
-		WHERE id = ? AND user_id = ?
+		WHERE id = ? AND user_id = ? AND status = 'pending'
 	`
 
 	_, err := r.db.ExecContext(ctx, query, capsule.Title, capsule.Message, capsule.DueDate, capsule.DeliveryMethod, nullIfEmpty(capsule.Category.String), nullIfEmpty(capsule.Mood.String), capsule.ID, capsule.UserID)
@@ -142,58 +265,227 @@ func (r *capsuleRepository) Delete(ctx context.Context, id, userID int) error {
 	return nil
 }
 
-func (r *capsuleRepository) GetPendingForToday(ctx context.Context) ([]models.Capsule, error) {
-	query := `
-		SELECT id, user_id, title, message, due_date, delivery_method,
-			status, category, mood, image_url, sent_at, created_at, updated_at
-		FROM capsules
-		WHERE DATE(due_date) = CURDATE() AND status = 'pending'
-		ORDER BY created_at ASC
+// GetDuePending mengambil semua capsule pending yang due date (UTC) nya sudah lewat until,
+// termasuk capsule yang terlewat saat service mati. Capsule yang menunggu retry
+// baru diambil setelah next_attempt_at lewat
+func (r *capsuleRepository) GetDuePending(ctx context.Context, until time.Time) ([]models.Capsule, error) {
+	query := `SELECT ` + capsuleColumns + ` FROM capsules
+		WHERE status = 'pending' AND due_date <= ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+		ORDER BY due_date ASC, id ASC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	capsules, err := r.queryCapsules(ctx, query, until.UTC(), until.UTC())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get rows: %w", err)
+		return nil, fmt.Errorf("failed to get due capsules: %w", err)
 	}
 
-	defer rows.Close()
+	return capsules, nil
+}
+
+// ClaimDue mengklaim capsule pending yang jatuh tempo secara atomik untuk dikirim.
+// Baris dikunci dengan FOR UPDATE SKIP LOCKED sehingga capsule yang sedang diklaim
+// proses lain dilewati, lalu statusnya diubah ke 'sending' dengan claim token dan lease
+func (r *capsuleRepository) ClaimDue(ctx context.Context, now time.Time, limit int, claimToken string, leaseUntil time.Time) ([]models.Capsule, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + capsuleColumns + ` FROM capsules
+		WHERE status = 'pending' AND due_date <= ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+		ORDER BY due_date ASC, id ASC
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.QueryContext(ctx, query, now.UTC(), now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select due capsules: %w", err)
+	}
 
 	capsules := []models.Capsule{}
 	for rows.Next() {
-		var capsule models.Capsule
-		err := rows.Scan(
-			&capsule.ID,
-			&capsule.UserID,
-			&capsule.Title,
-			&capsule.Message,
-			&capsule.DueDate,
-			&capsule.DeliveryMethod,
-			&capsule.Status,
-			&capsule.Category,
-			&capsule.Mood,
-			&capsule.ImageURL,
-			&capsule.SentAt,
-			&capsule.CreatedAt,
-			&capsule.UpdatedAt,
-		)
+		capsule, err := scanCapsule(rows)
 		if err != nil {
+			rows.Close()
 			return nil, err
 		}
+		capsules = append(capsules, *capsule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(capsules) == 0 {
+		return capsules, nil
+	}
+
+	ids := make([]any, 0, len(capsules)+2)
+	ids = append(ids, claimToken, leaseUntil.UTC())
+	for _, capsule := range capsules {
+		ids = append(ids, capsule.ID)
+	}
 
-		capsules = append(capsules, capsule)
+	update := `UPDATE capsules
+		SET status = 'sending', claim_token = ?, lease_expires_at = ?
+		WHERE id IN (` + placeholders(len(capsules)) + `)`
+
+	if _, err := tx.ExecContext(ctx, update, ids...); err != nil {
+		return nil, fmt.Errorf("failed to claim capsules: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit claim: %w", err)
+	}
+
+	for i := range capsules {
+		capsules[i].Status = models.CapsuleStatusSending
 	}
 
 	return capsules, nil
 }
 
-func (r *capsuleRepository) MarkAsSent(ctx context.Context, id int) error {
+// MarkAsSent menandai capsule terkirim, overdue true jika terkirim melewati batas keterlambatan.
+// Hanya berhasil jika claim token masih milik pemanggil
+func (r *capsuleRepository) MarkAsSent(ctx context.Context, id int, claimToken string, overdue bool, sentAt time.Time) error {
 	query := `UPDATE capsules 
-		SET status = 'sent', sent_at = NOW()
-		WHERE id = ?
+		SET status = 'sent', sent_at = ?, overdue = ?, attempt_count = attempt_count + 1,
+			next_attempt_at = NULL, claim_token = NULL, lease_expires_at = NULL
+		WHERE id = ? AND status = 'sending' AND claim_token = ?
 	`
 
-	_, err := r.db.ExecContext(ctx, query, id)
-	return err
+	result, err := r.db.ExecContext(ctx, query, sentAt.UTC(), overdue, id, claimToken)
+	return claimResult(result, err)
+}
+
+// ScheduleRetry mencatat percobaan yang gagal, melepas klaim dan menjadwalkan percobaan berikutnya
+func (r *capsuleRepository) ScheduleRetry(ctx context.Context, id int, claimToken string, nextAttemptAt time.Time) error {
+	query := `UPDATE capsules
+		SET status = 'pending', attempt_count = attempt_count + 1, next_attempt_at = ?,
+			claim_token = NULL, lease_expires_at = NULL
+		WHERE id = ? AND status = 'sending' AND claim_token = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, nextAttemptAt.UTC(), id, claimToken)
+	return claimResult(result, err)
+}
+
+// MarkAsFailed menandai capsule gagal permanen setelah semua percobaan habis (dead letter)
+func (r *capsuleRepository) MarkAsFailed(ctx context.Context, id int, claimToken string) error {
+	query := `UPDATE capsules
+		SET status = 'failed', attempt_count = attempt_count + 1, next_attempt_at = NULL,
+			claim_token = NULL, lease_expires_at = NULL
+		WHERE id = ? AND status = 'sending' AND claim_token = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, claimToken)
+	return claimResult(result, err)
+}
+
+// Defer melepas klaim dan menunda pengiriman tanpa menghitungnya sebagai percobaan,
+// dipakai saat capsule belum boleh dikirim (misalnya email user belum diverifikasi)
+func (r *capsuleRepository) Defer(ctx context.Context, id int, claimToken string, nextAttemptAt time.Time) error {
+	query := `UPDATE capsules
+		SET status = 'pending', next_attempt_at = ?, claim_token = NULL, lease_expires_at = NULL
+		WHERE id = ? AND status = 'sending' AND claim_token = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, nextAttemptAt.UTC(), id, claimToken)
+	return claimResult(result, err)
+}
+
+// staleAttemptCount jumlah percobaan capsule yang lease nya hilang. Lease yang hilang dihitung
+// sebagai satu percobaan, atau jumlah attempt di delivery_attempts jika proses sempat mencatatnya,
+// sehingga capsule yang selalu membuat proses mati tidak dicoba ulang tanpa batas
+const staleAttemptCount = `GREATEST(c.attempt_count + 1,
+	(SELECT COUNT(*) FROM delivery_attempts d WHERE d.capsule_id = c.id))`
+
+// RecoverStaleClaims memulihkan capsule 'sending' yang lease nya sudah habis (proses mati di tengah jalan).
+// Capsule yang sudah punya attempt sukses ditandai sent agar tidak terkirim dua kali.
+// Sisanya dihitung satu percobaan: masuk dead letter (failed) jika sudah mencapai maxAttempts,
+// selain itu dikembalikan ke pending untuk dikirim ulang
+func (r *capsuleRepository) RecoverStaleClaims(ctx context.Context, now time.Time, maxAttempts int) (int, int, int, error) {
+	finalize := `UPDATE capsules c
+		SET c.status = 'sent', c.sent_at = COALESCE(c.sent_at, ?), c.next_attempt_at = NULL,
+			c.claim_token = NULL, c.lease_expires_at = NULL,
+			c.attempt_count = (SELECT COUNT(*) FROM delivery_attempts d WHERE d.capsule_id = c.id)
+		WHERE c.status = 'sending' AND c.lease_expires_at < ?
+			AND EXISTS (SELECT 1 FROM delivery_attempts d WHERE d.capsule_id = c.id AND d.status = 'success')
+	`
+
+	result, err := r.db.ExecContext(ctx, finalize, now.UTC(), now.UTC())
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to finalize stale claims: %w", err)
+	}
+
+	finalized, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	deadLetter := `UPDATE capsules c
+		SET c.status = 'failed', c.attempt_count = ` + staleAttemptCount + `,
+			c.next_attempt_at = NULL, c.claim_token = NULL, c.lease_expires_at = NULL
+		WHERE c.status = 'sending' AND c.lease_expires_at < ?
+			AND ` + staleAttemptCount + ` >= ?
+	`
+
+	result, err = r.db.ExecContext(ctx, deadLetter, now.UTC(), maxAttempts)
+	if err != nil {
+		return int(finalized), 0, 0, fmt.Errorf("failed to dead-letter stale claims: %w", err)
+	}
+
+	failed, err := result.RowsAffected()
+	if err != nil {
+		return int(finalized), 0, 0, err
+	}
+
+	requeue := `UPDATE capsules c
+		SET c.status = 'pending', c.attempt_count = ` + staleAttemptCount + `,
+			c.claim_token = NULL, c.lease_expires_at = NULL
+		WHERE c.status = 'sending' AND c.lease_expires_at < ?
+	`
+
+	result, err = r.db.ExecContext(ctx, requeue, now.UTC())
+	if err != nil {
+		return int(finalized), 0, int(failed), fmt.Errorf("failed to requeue stale claims: %w", err)
+	}
+
+	requeued, err := result.RowsAffected()
+	if err != nil {
+		return int(finalized), 0, int(failed), err
+	}
+
+	return int(finalized), int(requeued), int(failed), nil
+}
+
+// claimResult mengubah hasil UPDATE bersyarat claim token menjadi error jika klaim sudah hilang
+func claimResult(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("capsule claim lost")
+	}
+
+	return nil
+}
+
+// placeholders membuat "?, ?, ?" sebanyak n untuk klausa IN
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	return strings.Repeat("?, ", n-1) + "?"
 }
 
 func nullIfEmpty(s string) any {
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newMockRepository(t *testing.T) (*capsuleRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return &capsuleRepository{db: db}, mock
+}
+
+func expectationsMet(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// capsuleRows baris capsule pending dengan kolom sesuai capsuleColumns
+func capsuleRows(ids ...int) *sqlmock.Rows {
+	rows := sqlmock.NewRows(strings.Split(capsuleColumns, ", "))
+	for _, id := range ids {
+		rows.AddRow(id, 1, "Judul", "Isi", testNow.Add(-time.Hour), "email", "pending",
+			nil, nil, nil, nil, false, 0, nil, testNow, testNow)
+	}
+	return rows
+}
+
+func TestClaimDue(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	leaseUntil := testNow.Add(5 * time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .+ FROM capsules\s+WHERE status = 'pending' AND due_date <= \? .+FOR UPDATE SKIP LOCKED`).
+		WithArgs(testNow, testNow, 10).
+		WillReturnRows(capsuleRows(3, 4))
+	mock.ExpectExec(`UPDATE capsules\s+SET status = 'sending', claim_token = \?, lease_expires_at = \?\s+WHERE id IN \(\?, \?\)`).
+		WithArgs("claim", leaseUntil, 3, 4).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	capsules, err := repo.ClaimDue(context.Background(), testNow, 10, "claim", leaseUntil)
+	if err != nil {
+		t.Fatalf("ClaimDue: %v", err)
+	}
+
+	if len(capsules) != 2 || capsules[0].ID != 3 || capsules[1].ID != 4 {
+		t.Fatalf("capsules = %+v", capsules)
+	}
+	for _, capsule := range capsules {
+		if capsule.Status != models.CapsuleStatusSending {
+			t.Fatalf("capsule %d status = %q, want sending", capsule.ID, capsule.Status)
+		}
+	}
+
+	expectationsMet(t, mock)
+}
+
+func TestClaimDueNothingDue(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .+ FROM capsules`).WillReturnRows(capsuleRows())
+	mock.ExpectRollback()
+
+	capsules, err := repo.ClaimDue(context.Background(), testNow, 10, "claim", testNow.Add(time.Minute))
+	if err != nil || len(capsules) != 0 {
+		t.Fatalf("ClaimDue = %v, %v, want no capsules", capsules, err)
+	}
+
+	expectationsMet(t, mock)
+}
+
+func TestClaimDueRollsBackWhenUpdateFails(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .+ FROM capsules`).WillReturnRows(capsuleRows(3))
+	mock.ExpectExec(`UPDATE capsules\s+SET status = 'sending'`).WillReturnError(errors.New("deadlock"))
+	mock.ExpectRollback()
+
+	if _, err := repo.ClaimDue(context.Background(), testNow, 10, "claim", testNow.Add(time.Minute)); err == nil {
+		t.Fatal("ClaimDue succeeded, want error")
+	}
+
+	expectationsMet(t, mock)
+}
+
+func TestClaimConditionalUpdates(t *testing.T) {
+	ctx := context.Background()
+	next := testNow.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		pattern string
+		args    []driver.Value
+		call    func(r *capsuleRepository) error
+	}{
+		{
+			name:    "mark as sent",
+			pattern: `SET status = 'sent', sent_at = \?, overdue = \?, attempt_count = attempt_count \+ 1`,
+			args:    []driver.Value{testNow, true, 7, "claim"},
+			call: func(r *capsuleRepository) error {
+				return r.MarkAsSent(ctx, 7, "claim", true, testNow)
+			},
+		},
+		{
+			name:    "schedule retry",
+			pattern: `SET status = 'pending', attempt_count = attempt_count \+ 1, next_attempt_at = \?`,
+			args:    []driver.Value{next, 7, "claim"},
+			call: func(r *capsuleRepository) error {
+				return r.ScheduleRetry(ctx, 7, "claim", next)
+			},
+		},
+		{
+			name:    "mark as failed",
+			pattern: `SET status = 'failed', attempt_count = attempt_count \+ 1`,
+			args:    []driver.Value{7, "claim"},
+			call: func(r *capsuleRepository) error {
+				return r.MarkAsFailed(ctx, 7, "claim")
+			},
+		},
+		{
+			name:    "defer",
+			pattern: `SET status = 'pending', next_attempt_at = \?, claim_token = NULL`,
+			args:    []driver.Value{next, 7, "claim"},
+			call: func(r *capsuleRepository) error {
+				return r.Defer(ctx, 7, "claim", next)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		// Semua update hanya berlaku selama klaim masih milik pemanggil
+		pattern := tt.pattern + `.+WHERE id = \? AND status = 'sending' AND claim_token = \?`
+
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			mock.ExpectExec(pattern).WithArgs(tt.args...).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			if err := tt.call(repo); err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+			expectationsMet(t, mock)
+		})
+
+		t.Run(tt.name+" claim lost", func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			mock.ExpectExec(pattern).WithArgs(tt.args...).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			if err := tt.call(repo); err == nil || err.Error() != "capsule claim lost" {
+				t.Fatalf("error = %v, want capsule claim lost", err)
+			}
+			expectationsMet(t, mock)
+		})
+	}
+}
+
+func TestRecoverStaleClaims(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectExec(`SET c.status = 'sent'.+WHERE c.status = 'sending' AND c.lease_expires_at < \?\s+AND EXISTS .+d.status = 'success'`).
+		WithArgs(testNow, testNow).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// Lease yang hilang dihitung sebagai percobaan, capsule yang mencapai maxAttempts masuk dead letter
+	mock.ExpectExec(`SET c.status = 'failed', c.attempt_count = GREATEST\(c.attempt_count \+ 1,.+WHERE c.status = 'sending' AND c.lease_expires_at < \?\s+AND GREATEST\(c.attempt_count \+ 1,.+>= \?`).
+		WithArgs(testNow, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`SET c.status = 'pending', c.attempt_count = GREATEST\(c.attempt_count \+ 1,.+WHERE c.status = 'sending' AND c.lease_expires_at < \?`).
+		WithArgs(testNow).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	finalized, requeued, failed, err := repo.RecoverStaleClaims(context.Background(), testNow, 5)
+	if err != nil {
+		t.Fatalf("RecoverStaleClaims: %v", err)
+	}
+	if finalized != 2 || requeued != 3 || failed != 1 {
+		t.Fatalf("finalized/requeued/failed = %d/%d/%d, want 2/3/1", finalized, requeued, failed)
+	}
+
+	expectationsMet(t, mock)
+}
+
+func TestRecoverStaleClaimsStopsOnError(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectExec(`SET c.status = 'sent'`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`SET c.status = 'failed'`).WillReturnError(errors.New("lock wait timeout"))
+
+	// Requeue tidak dijalankan jika dead letter gagal, capsule yang sudah habis percobaannya
+	// tidak boleh dikembalikan ke pending
+	finalized, requeued, failed, err := repo.RecoverStaleClaims(context.Background(), testNow, 5)
+	if err == nil || finalized != 1 || requeued != 0 || failed != 0 {
+		t.Fatalf("RecoverStaleClaims = %d/%d/%d, %v", finalized, requeued, failed, err)
+	}
+
+	expectationsMet(t, mock)
+}
@@ -0,0 +1,16 @@
+// Package repository
+package repository
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+// CapsuleSearchRepository mencari capsule berdasarkan judul dan isi pesan.
+// Implementasi MySQL memakai index FULLTEXT, implementasi memory untuk pengujian
+// dan database tanpa index FULLTEXT
+type CapsuleSearchRepository interface {
+	// Search mengembalikan capsule yang cocok diurutkan dari skor tertinggi beserta jumlah seluruh hasil
+	Search(ctx context.Context, query models.CapsuleSearchQuery) ([]models.CapsuleSearchHit, int, error)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"future-letter/internal/models"
+)
+
+type capsuleSearchRepository struct {
+	db *sql.DB
+}
+
+func NewCapsuleSearchRepository(db *sql.DB) CapsuleSearchRepository {
+	return &capsuleSearchRepository{
+		db: db,
+	}
+}
+
+// capsuleMatch harus sama persis dengan kolom index ft_capsules_title_message agar index nya terpakai
+const capsuleMatch = "MATCH(title, message) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+// scoredRow membaca kolom skor relevansi setelah kolom capsule
+type scoredRow struct {
+	row   rowScanner
+	score *float64
+}
+
+func (s scoredRow) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.score)...)
+}
+
+// Search memakai natural language mode, kata dicocokkan utuh dan skornya dihitung MySQL
+func (r *capsuleSearchRepository) Search(ctx context.Context, q models.CapsuleSearchQuery) ([]models.CapsuleSearchHit, int, error) {
+	against := strings.Join(q.Terms, " ")
+
+	var total int
+	countQuery := "SELECT COUNT(*) FROM capsules WHERE user_id = ? AND " + capsuleMatch
+	if err := r.db.QueryRowContext(ctx, countQuery, q.UserID, against).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count capsule search results: %w", err)
+	}
+
+	query := "SELECT " + capsuleColumns + ", " + capsuleMatch + " AS score FROM capsules" +
+		" WHERE user_id = ? AND " + capsuleMatch +
+		" ORDER BY score DESC, id DESC LIMIT ? OFFSET ?"
+
+	rows, err := r.db.QueryContext(ctx, query, against, q.UserID, against, q.Limit, q.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search capsules: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []models.CapsuleSearchHit{}
+	for rows.Next() {
+		var score float64
+
+		capsule, err := scanCapsule(scoredRow{row: rows, score: &score})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan capsule: %w", err)
+		}
+		hits = append(hits, models.CapsuleSearchHit{Capsule: *capsule, Score: score})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating capsule search results: %w", err)
+	}
+
+	return hits, total, nil
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"future-letter/internal/models"
+)
+
+// titleWeight kata yang cocok di judul dihitung lebih relevan dari kata di isi pesan
+const titleWeight = 2
+
+// memoryCapsuleSearchRepository mencari dengan membaca semua capsule user lalu menghitung skor di memory.
+// Cocok untuk pengujian dan jumlah capsule kecil, tidak butuh index FULLTEXT
+type memoryCapsuleSearchRepository struct {
+	capsules CapsuleRepository
+}
+
+func NewMemoryCapsuleSearchRepository(capsules CapsuleRepository) CapsuleSearchRepository {
+	return &memoryCapsuleSearchRepository{
+		capsules: capsules,
+	}
+}
+
+func (r *memoryCapsuleSearchRepository) Search(ctx context.Context, q models.CapsuleSearchQuery) ([]models.CapsuleSearchHit, int, error) {
+	capsules, err := r.capsules.GetByUserID(ctx, q.UserID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search capsules: %w", err)
+	}
+
+	hits := []models.CapsuleSearchHit{}
+	for _, capsule := range capsules {
+		score := titleWeight*countTerms(models.SearchTokens(capsule.Title), q.Terms) +
+			countTerms(models.SearchTokens(capsule.Message), q.Terms)
+		if score == 0 {
+			continue
+		}
+		hits = append(hits, models.CapsuleSearchHit{Capsule: capsule, Score: float64(score)})
+	}
+
+	// Urutan sama dengan implementasi MySQL: skor tertinggi dulu, lalu capsule terbaru
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Capsule.ID > hits[j].Capsule.ID
+	})
+
+	total := len(hits)
+	if q.Offset >= total {
+		return []models.CapsuleSearchHit{}, total, nil
+	}
+
+	end := total
+	if q.Limit > 0 {
+		end = min(q.Offset+q.Limit, total)
+	}
+
+	return hits[q.Offset:end], total, nil
+}
+
+// countTerms menghitung berapa kali kata yang dicari muncul di tokens
+func countTerms(tokens, terms []string) int {
+	count := 0
+	for _, token := range tokens {
+		for _, term := range terms {
+			if token == term {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
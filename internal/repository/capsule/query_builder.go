@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+// selectBuilder menyusun query SELECT dengan kondisi WHERE, ORDER BY dan LIMIT.
+// Nilai selalu dikirim sebagai argumen (?), nama kolom hanya boleh berasal dari kode, bukan input user
+type selectBuilder struct {
+	columns    string
+	table      string
+	conditions []string
+	args       []any
+	orderBy    []string
+	limit      int
+}
+
+func newSelect(columns, table string) *selectBuilder {
+	return &selectBuilder{
+		columns: columns,
+		table:   table,
+	}
+}
+
+// Where menambahkan kondisi yang digabung dengan AND
+func (b *selectBuilder) Where(condition string, args ...any) *selectBuilder {
+	b.conditions = append(b.conditions, condition)
+	b.args = append(b.args, args...)
+	return b
+}
+
+// OrderBy menambahkan ekspresi pengurutan, contoh "due_date ASC"
+func (b *selectBuilder) OrderBy(expressions ...string) *selectBuilder {
+	b.orderBy = append(b.orderBy, expressions...)
+	return b
+}
+
+// Limit membatasi jumlah baris, 0 berarti tanpa batas
+func (b *selectBuilder) Limit(limit int) *selectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Build menghasilkan query beserta argumennya
+func (b *selectBuilder) Build() (string, []any) {
+	var query strings.Builder
+
+	query.WriteString("SELECT ")
+	query.WriteString(b.columns)
+	query.WriteString(" FROM ")
+	query.WriteString(b.table)
+
+	if len(b.conditions) > 0 {
+		query.WriteString(" WHERE ")
+		query.WriteString(strings.Join(b.conditions, " AND "))
+	}
+
+	if len(b.orderBy) > 0 {
+		query.WriteString(" ORDER BY ")
+		query.WriteString(strings.Join(b.orderBy, ", "))
+	}
+
+	if b.limit > 0 {
+		query.WriteString(" LIMIT ")
+		query.WriteString(strconv.Itoa(b.limit))
+	}
+
+	return query.String(), b.args
+}
@@ -0,0 +1,15 @@
+// Package repository
+package repository
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+type DeliveryRepository interface {
+	Create(ctx context.Context, attempt *models.DeliveryAttempt) error
+	GetByCapsuleID(ctx context.Context, capsuleID int) ([]models.DeliveryAttempt, error)
+	GetByUserID(ctx context.Context, userID int) ([]models.DeliveryAttempt, error)
+	HasSuccessfulAttempt(ctx context.Context, capsuleID int) (bool, error)
+}
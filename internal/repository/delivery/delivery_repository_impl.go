@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"future-letter/internal/models"
+)
+
+type deliveryRepository struct {
+	db *sql.DB
+}
+
+func NewDeliveryRepository(db *sql.DB) DeliveryRepository {
+	return &deliveryRepository{
+		db: db,
+	}
+}
+
+// Create menyimpan satu percobaan pengiriman
+func (r *deliveryRepository) Create(ctx context.Context, attempt *models.DeliveryAttempt) error {
+	query := "INSERT INTO delivery_attempts (capsule_id, channel, idempotency_key, attempt_number, status, error, overdue, attempted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+
+	result, err := r.db.ExecContext(ctx, query,
+		attempt.CapsuleID,
+		attempt.Channel,
+		attempt.IdempotencyKey,
+		attempt.AttemptNumber,
+		attempt.Status,
+		attempt.Error,
+		attempt.Overdue,
+		attempt.AttemptedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create delivery attempt: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	attempt.ID = int(id)
+	return nil
+}
+
+// GetByCapsuleID mengambil riwayat pengiriman capsule, urut dari percobaan pertama
+func (r *deliveryRepository) GetByCapsuleID(ctx context.Context, capsuleID int) ([]models.DeliveryAttempt, error) {
+	query := `SELECT
+		id, capsule_id, channel, idempotency_key, attempt_number, status, error, overdue, attempted_at
+		FROM delivery_attempts
+		WHERE capsule_id = ?
+		ORDER BY attempt_number ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, capsuleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery attempts: %w", err)
+	}
+
+	return scanAttempts(rows)
+}
+
+// GetByUserID mengambil riwayat pengiriman semua capsule milik user, dikelompokkan per capsule
+func (r *deliveryRepository) GetByUserID(ctx context.Context, userID int) ([]models.DeliveryAttempt, error) {
+	query := `SELECT
+		d.id, d.capsule_id, d.channel, d.idempotency_key, d.attempt_number, d.status, d.error, d.overdue, d.attempted_at
+		FROM delivery_attempts d
+		JOIN capsules c ON c.id = d.capsule_id
+		WHERE c.user_id = ?
+		ORDER BY d.capsule_id ASC, d.attempt_number ASC, d.id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery attempts: %w", err)
+	}
+
+	return scanAttempts(rows)
+}
+
+// scanAttempts membaca semua baris delivery attempt lalu menutup rows
+func scanAttempts(rows *sql.Rows) ([]models.DeliveryAttempt, error) {
+	defer rows.Close()
+
+	attempts := []models.DeliveryAttempt{}
+	for rows.Next() {
+		var attempt models.DeliveryAttempt
+		err := rows.Scan(
+			&attempt.ID,
+			&attempt.CapsuleID,
+			&attempt.Channel,
+			&attempt.IdempotencyKey,
+			&attempt.AttemptNumber,
+			&attempt.Status,
+			&attempt.Error,
+			&attempt.Overdue,
+			&attempt.AttemptedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+// HasSuccessfulAttempt mengecek apakah capsule sudah pernah berhasil dikirim
+func (r *deliveryRepository) HasSuccessfulAttempt(ctx context.Context, capsuleID int) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM delivery_attempts WHERE capsule_id = ? AND status = 'success')"
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, capsuleID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivery attempts: %w", err)
+	}
+
+	return exists, nil
+}
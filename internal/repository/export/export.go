@@ -0,0 +1,24 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type ExportRepository interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	GetByID(ctx context.Context, id int) (*models.DataExport, error)
+	GetByIDForUser(ctx context.Context, id, userID int) (*models.DataExport, error)
+	ListByUser(ctx context.Context, userID, limit int) ([]models.DataExport, error)
+	HasActive(ctx context.Context, userID int) (bool, error)
+	ListPending(ctx context.Context, limit int) ([]models.DataExport, error)
+	Claim(ctx context.Context, id int, startedAt time.Time) error
+	Complete(ctx context.Context, id int, filePath string, fileSize int64, completedAt, expiresAt time.Time) error
+	Fail(ctx context.Context, id int, reason string, failedAt time.Time) error
+	FailStale(ctx context.Context, startedBefore, now time.Time) (int, error)
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error)
+	MarkExpired(ctx context.Context, id int) error
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type exportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) ExportRepository {
+	return &exportRepository{
+		db: db,
+	}
+}
+
+// exportColumns kolom yang diambil setiap kali membaca export, urutannya harus sama dengan scanExport
+const exportColumns = "id, user_id, status, file_path, file_size, error, started_at, completed_at, expires_at, created_at"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanExport(row rowScanner) (*models.DataExport, error) {
+	export := &models.DataExport{}
+
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.FilePath,
+		&export.FileSize,
+		&export.Error,
+		&export.StartedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+		&export.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// queryExports menjalankan query SELECT export dan membaca semua barisnya
+func (r *exportRepository) queryExports(ctx context.Context, query string, args ...any) ([]models.DataExport, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []models.DataExport{}
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan export: %w", err)
+		}
+		exports = append(exports, *export)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exports: %w", err)
+	}
+
+	return exports, nil
+}
+
+// Create menyimpan permintaan export baru dengan status pending
+func (r *exportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	query := "INSERT INTO data_exports (user_id, status, created_at) VALUES (?, ?, ?)"
+
+	result, err := r.db.ExecContext(ctx, query, export.UserID, export.Status, export.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create export: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	export.ID = int(id)
+	return nil
+}
+
+// GetByID mengambil export berdasarkan ID, dipakai worker dan link download
+func (r *exportRepository) GetByID(ctx context.Context, id int) (*models.DataExport, error) {
+	query := "SELECT " + exportColumns + " FROM data_exports WHERE id = ?"
+
+	export, err := scanExport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("export not found")
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	return export, nil
+}
+
+// GetByIDForUser mengambil export milik user tertentu
+func (r *exportRepository) GetByIDForUser(ctx context.Context, id, userID int) (*models.DataExport, error) {
+	query := "SELECT " + exportColumns + " FROM data_exports WHERE id = ? AND user_id = ?"
+
+	export, err := scanExport(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("export not found")
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	return export, nil
+}
+
+// ListByUser mengambil export terbaru milik user
+func (r *exportRepository) ListByUser(ctx context.Context, userID, limit int) ([]models.DataExport, error) {
+	query := "SELECT " + exportColumns + " FROM data_exports WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?"
+
+	return r.queryExports(ctx, query, userID, limit)
+}
+
+// HasActive mengecek apakah user masih punya export yang sedang antri atau diproses
+func (r *exportRepository) HasActive(ctx context.Context, userID int) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM data_exports WHERE user_id = ? AND status IN ('pending', 'processing'))"
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check active exports: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListPending mengambil export yang belum diproses, paling lama di atas
+func (r *exportRepository) ListPending(ctx context.Context, limit int) ([]models.DataExport, error) {
+	query := "SELECT " + exportColumns + " FROM data_exports WHERE status = 'pending' ORDER BY created_at ASC, id ASC LIMIT ?"
+
+	return r.queryExports(ctx, query, limit)
+}
+
+// Claim mengubah status export pending menjadi processing.
+// Hanya satu worker (di replica mana pun) yang berhasil mengklaim export yang sama
+func (r *exportRepository) Claim(ctx context.Context, id int, startedAt time.Time) error {
+	query := "UPDATE data_exports SET status = 'processing', started_at = ? WHERE id = ? AND status = 'pending'"
+
+	result, err := r.db.ExecContext(ctx, query, startedAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to claim export: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("export already claimed")
+	}
+
+	return nil
+}
+
+// Complete menyimpan lokasi file ZIP dan batas waktu link download nya
+func (r *exportRepository) Complete(ctx context.Context, id int, filePath string, fileSize int64, completedAt, expiresAt time.Time) error {
+	query := "UPDATE data_exports SET status = 'completed', file_path = ?, file_size = ?, completed_at = ?, expires_at = ? WHERE id = ? AND status = 'processing'"
+
+	result, err := r.db.ExecContext(ctx, query, filePath, fileSize, completedAt.UTC(), expiresAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to complete export: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("export not found")
+	}
+
+	return nil
+}
+
+// Fail menandai export gagal beserta alasannya
+func (r *exportRepository) Fail(ctx context.Context, id int, reason string, failedAt time.Time) error {
+	query := "UPDATE data_exports SET status = 'failed', error = ?, completed_at = ? WHERE id = ? AND status IN ('pending', 'processing')"
+
+	_, err := r.db.ExecContext(ctx, query, truncate(reason, 500), failedAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark export as failed: %w", err)
+	}
+
+	return nil
+}
+
+// FailStale menandai gagal export yang macet di status processing, misalnya karena replica mati di tengah proses
+func (r *exportRepository) FailStale(ctx context.Context, startedBefore, now time.Time) (int, error) {
+	query := "UPDATE data_exports SET status = 'failed', error = 'export interrupted', completed_at = ? WHERE status = 'processing' AND started_at < ?"
+
+	result, err := r.db.ExecContext(ctx, query, now.UTC(), startedBefore.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail stale exports: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ListExpired mengambil export selesai yang link download nya sudah kedaluwarsa
+func (r *exportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	query := "SELECT " + exportColumns + " FROM data_exports WHERE status = 'completed' AND expires_at <= ? ORDER BY expires_at ASC LIMIT ?"
+
+	return r.queryExports(ctx, query, now.UTC(), limit)
+}
+
+// MarkExpired menandai export kedaluwarsa setelah file nya dihapus
+func (r *exportRepository) MarkExpired(ctx context.Context, id int) error {
+	query := "UPDATE data_exports SET status = 'expired', file_path = NULL WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark export as expired: %w", err)
+	}
+
+	return nil
+}
+
+// truncate memotong pesan error agar muat di kolom database
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
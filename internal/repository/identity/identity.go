@@ -0,0 +1,13 @@
+// Package repository
+package repository
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"future-letter/internal/models"
+)
+
+type identityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) IdentityRepository {
+	return &identityRepository{
+		db: db,
+	}
+}
+
+// Create menghubungkan akun provider OIDC ke user
+func (r *identityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	query := "INSERT INTO user_identities (user_id, provider, subject, email, created_at) VALUES (?, ?, ?, ?, ?)"
+
+	result, err := r.db.ExecContext(ctx, query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		identity.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user identity: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	identity.ID = int(id)
+	return nil
+}
+
+// GetByProviderSubject mencari identity berdasarkan provider dan subject (claim "sub" di ID token)
+func (r *identityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	query := `SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at
+		FROM user_identities
+		WHERE provider = ? AND subject = ?
+	`
+
+	identity := &models.UserIdentity{}
+	err := r.db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("identity not found")
+		}
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,17 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type InboxRepository interface {
+	Create(ctx context.Context, userID, capsuleID int, deliveredAt time.Time) error
+	GetByUserID(ctx context.Context, userID int, unreadOnly bool) ([]models.InboxMessage, error)
+	MarkAsRead(ctx context.Context, id, userID int, readAt time.Time) error
+	MarkAllAsRead(ctx context.Context, userID int, readAt time.Time) (int, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+}
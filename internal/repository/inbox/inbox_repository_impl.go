@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type inboxRepository struct {
+	db *sql.DB
+}
+
+func NewInboxRepository(db *sql.DB) InboxRepository {
+	return &inboxRepository{
+		db: db,
+	}
+}
+
+// Create memasukkan capsule ke inbox user
+// INSERT IGNORE agar capsule yang sama tidak masuk inbox dua kali
+func (r *inboxRepository) Create(ctx context.Context, userID, capsuleID int, deliveredAt time.Time) error {
+	query := "INSERT IGNORE INTO inbox_messages (user_id, capsule_id, delivered_at) VALUES (?, ?, ?)"
+
+	_, err := r.db.ExecContext(ctx, query, userID, capsuleID, deliveredAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create inbox message: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID mengambil semua pesan inbox milik user, terbaru di atas
+func (r *inboxRepository) GetByUserID(ctx context.Context, userID int, unreadOnly bool) ([]models.InboxMessage, error) {
+	query := `SELECT
+		i.id, i.user_id, i.capsule_id, c.title, c.message, c.category, c.mood, c.created_at, i.read_at, i.delivered_at
+		FROM inbox_messages i
+		JOIN capsules c ON c.id = i.capsule_id
+		WHERE i.user_id = ?
+	`
+	if unreadOnly {
+		query += " AND i.read_at IS NULL"
+	}
+	query += " ORDER BY i.delivered_at DESC, i.id DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox messages: %w", err)
+	}
+
+	defer rows.Close()
+
+	messages := []models.InboxMessage{}
+	for rows.Next() {
+		var message models.InboxMessage
+		err := rows.Scan(
+			&message.ID,
+			&message.UserID,
+			&message.CapsuleID,
+			&message.Title,
+			&message.Message,
+			&message.Category,
+			&message.Mood,
+			&message.WrittenAt,
+			&message.ReadAt,
+			&message.DeliveredAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// MarkAsRead menandai satu pesan sudah dibaca
+func (r *inboxRepository) MarkAsRead(ctx context.Context, id, userID int, readAt time.Time) error {
+	query := "UPDATE inbox_messages SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?"
+
+	result, err := r.db.ExecContext(ctx, query, readAt.UTC(), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark inbox message as read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// rows affected 0 bisa berarti pesan tidak ada, atau sudah dibaca
+		var exists int
+		err := r.db.QueryRowContext(ctx, "SELECT 1 FROM inbox_messages WHERE id = ? AND user_id = ?", id, userID).Scan(&exists)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.New("inbox message not found")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarkAllAsRead menandai semua pesan user sudah dibaca
+func (r *inboxRepository) MarkAllAsRead(ctx context.Context, userID int, readAt time.Time) (int, error) {
+	query := "UPDATE inbox_messages SET read_at = ? WHERE user_id = ? AND read_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, readAt.UTC(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark inbox messages as read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// CountUnread menghitung pesan yang belum dibaca
+func (r *inboxRepository) CountUnread(ctx context.Context, userID int) (int, error) {
+	query := "SELECT COUNT(*) FROM inbox_messages WHERE user_id = ? AND read_at IS NULL"
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread inbox messages: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,16 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type LockRepository interface {
+	TryAcquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	Renew(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+	Get(ctx context.Context, name string) (*models.SchedulerLock, error)
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry kode error MySQL untuk duplicate primary key
+const mysqlDuplicateEntry = 1062
+
+type lockRepository struct {
+	db *sql.DB
+}
+
+func NewLockRepository(db *sql.DB) LockRepository {
+	return &lockRepository{
+		db: db,
+	}
+}
+
+// TryAcquire mencoba mengambil lock. Berhasil jika lock belum ada, sudah expired,
+// atau masih dipegang oleh holder yang sama (diperpanjang)
+func (r *lockRepository) TryAcquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now = now.UTC()
+	expiresAt := now.Add(ttl)
+
+	lock, err := scanLock(tx.QueryRowContext(ctx, "SELECT name, holder, acquired_at, renewed_at, expires_at FROM scheduler_locks WHERE name = ? FOR UPDATE", name))
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return false, fmt.Errorf("failed to read lock: %w", err)
+		}
+
+		// Lock belum pernah dibuat
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO scheduler_locks (name, holder, acquired_at, renewed_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+			name, holder, now, now, expiresAt,
+		)
+		if err != nil {
+			// Replica lain membuat lock lebih dulu
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to create lock: %w", err)
+		}
+
+		return true, tx.Commit()
+	}
+
+	// Lock masih dipegang replica lain
+	if lock.Holder != holder && lock.IsHeld(now) {
+		return false, nil
+	}
+
+	acquiredAt := lock.AcquiredAt
+	if lock.Holder != holder {
+		acquiredAt = now
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE scheduler_locks SET holder = ?, acquired_at = ?, renewed_at = ?, expires_at = ? WHERE name = ?",
+		holder, acquiredAt, now, expiresAt, name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to take over lock: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// Renew memperpanjang lock yang masih dipegang holder, false jika lock sudah diambil replica lain
+func (r *lockRepository) Renew(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	now = now.UTC()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE scheduler_locks SET renewed_at = ?, expires_at = ? WHERE name = ? AND holder = ? AND expires_at >= ?",
+		now, now.Add(ttl), name, holder, now,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lock: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// Release melepas lock dengan membuatnya langsung expired, riwayat holder tetap tersimpan
+func (r *lockRepository) Release(ctx context.Context, name, holder string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE scheduler_locks SET expires_at = renewed_at WHERE name = ? AND holder = ?",
+		name, holder,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+
+	return nil
+}
+
+// Get mengambil status lock, dipakai untuk observability
+func (r *lockRepository) Get(ctx context.Context, name string) (*models.SchedulerLock, error) {
+	lock, err := scanLock(r.db.QueryRowContext(ctx, "SELECT name, holder, acquired_at, renewed_at, expires_at FROM scheduler_locks WHERE name = ?", name))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("lock not found")
+		}
+		return nil, fmt.Errorf("failed to get lock: %w", err)
+	}
+
+	return lock, nil
+}
+
+func scanLock(row *sql.Row) (*models.SchedulerLock, error) {
+	lock := &models.SchedulerLock{}
+
+	err := row.Scan(&lock.Name, &lock.Holder, &lock.AcquiredAt, &lock.RenewedAt, &lock.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+var testNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	selectLockForUpdate = `SELECT name, holder, acquired_at, renewed_at, expires_at FROM scheduler_locks WHERE name = \? FOR UPDATE`
+	lockColumns         = "name, holder, acquired_at, renewed_at, expires_at"
+)
+
+func newMockRepository(t *testing.T) (LockRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return NewLockRepository(db), mock
+}
+
+func lockRow(holder string, acquiredAt, expiresAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"name", "holder", "acquired_at", "renewed_at", "expires_at"}).
+		AddRow("capsule-delivery", holder, acquiredAt, acquiredAt, expiresAt)
+}
+
+func TestTryAcquire(t *testing.T) {
+	ttl := 30 * time.Second
+	expiresAt := testNow.Add(ttl)
+	acquiredEarlier := testNow.Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+		want   bool
+	}{
+		{
+			name: "create new lock",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLockForUpdate).WithArgs("capsule-delivery").WillReturnRows(sqlmock.NewRows([]string{lockColumns}))
+				mock.ExpectExec(`INSERT INTO scheduler_locks`).
+					WithArgs("capsule-delivery", "a", testNow, testNow, expiresAt).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "another replica created the lock first",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLockForUpdate).WillReturnRows(sqlmock.NewRows([]string{lockColumns}))
+				mock.ExpectExec(`INSERT INTO scheduler_locks`).WillReturnError(&mysql.MySQLError{Number: mysqlDuplicateEntry})
+				mock.ExpectRollback()
+			},
+			want: false,
+		},
+		{
+			name: "refused while another holder's lease is active",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLockForUpdate).WillReturnRows(lockRow("b", acquiredEarlier, testNow.Add(time.Second)))
+				mock.ExpectRollback()
+			},
+			want: false,
+		},
+		{
+			name: "take over after expiry",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLockForUpdate).WillReturnRows(lockRow("b", acquiredEarlier, testNow.Add(-time.Second)))
+				// acquired_at diganti karena holder berubah
+				mock.ExpectExec(`UPDATE scheduler_locks SET holder = \?, acquired_at = \?, renewed_at = \?, expires_at = \? WHERE name = \?`).
+					WithArgs("a", testNow, testNow, expiresAt, "capsule-delivery").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+		{
+			name: "same holder extends its lease",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectLockForUpdate).WillReturnRows(lockRow("a", acquiredEarlier, testNow.Add(time.Second)))
+				mock.ExpectExec(`UPDATE scheduler_locks SET holder = \?`).
+					WithArgs("a", acquiredEarlier, testNow, expiresAt, "capsule-delivery").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			tt.expect(mock)
+
+			acquired, err := repo.TryAcquire(context.Background(), "capsule-delivery", "a", testNow, ttl)
+			if err != nil {
+				t.Fatalf("TryAcquire: %v", err)
+			}
+			if acquired != tt.want {
+				t.Fatalf("acquired = %v, want %v", acquired, tt.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRenew(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		rows int64
+		want bool
+	}{
+		{name: "still holder", rows: 1, want: true},
+		{name: "lock taken over", rows: 0, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			mock.ExpectExec(`UPDATE scheduler_locks SET renewed_at = \?, expires_at = \? WHERE name = \? AND holder = \? AND expires_at >= \?`).
+				WithArgs(testNow, testNow.Add(time.Minute), "capsule-delivery", "a", testNow).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			renewed, err := repo.Renew(context.Background(), "capsule-delivery", "a", testNow, time.Minute)
+			if err != nil || renewed != tt.want {
+				t.Fatalf("Renew = %v, %v, want %v", renewed, err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestReleaseOnlyByHolder(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	mock.ExpectExec(`UPDATE scheduler_locks SET expires_at = renewed_at WHERE name = \? AND holder = \?`).
+		WithArgs("capsule-delivery", "a").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repo.Release(context.Background(), "capsule-delivery", "a"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
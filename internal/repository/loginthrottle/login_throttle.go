@@ -0,0 +1,31 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// BlockPolicy menentukan jeda dan penguncian dari jumlah percobaan (sudah termasuk percobaan ini).
+// Nilai zero berarti tidak ada jeda atau tidak dikunci
+type BlockPolicy func(failures int) (nextAttemptAt, lockedUntil time.Time)
+
+// LoginThrottleRepository menyimpan percobaan login gagal per akun dan per IP.
+// Implementasi MySQL dipakai agar semua replica berbagi state yang sama,
+// implementasi memory untuk pengujian dan instance tunggal
+type LoginThrottleRepository interface {
+	// Get mengembalikan catatan kunci, atau catatan kosong (failures 0) jika belum ada
+	Get(ctx context.Context, kind, key string) (*models.LoginThrottle, error)
+	// Attempt mencatat satu percobaan sebelum kredensial dicek. Pengecekan blokir, penambahan
+	// failures dan penyimpanan jeda dari policy terjadi atomik, jadi request paralel tidak bisa
+	// lolos bersamaan. Mengembalikan false (tanpa menambah failures) jika kunci masih diblokir.
+	// Failures dihitung ulang dari 1 jika window sudah lewat
+	Attempt(ctx context.Context, kind, key string, now time.Time, window time.Duration, policy BlockPolicy) (*models.LoginThrottle, bool, error)
+	// Release mengembalikan satu percobaan yang ternyata berhasil dan menghapus jedanya.
+	// Penguncian tidak dihapus
+	Release(ctx context.Context, kind, key string, now time.Time) error
+	Reset(ctx context.Context, kind, key string) error
+	DeleteStale(ctx context.Context, before time.Time) error
+}
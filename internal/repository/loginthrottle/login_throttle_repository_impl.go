@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type loginThrottleRepository struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{
+		db: db,
+	}
+}
+
+const loginThrottleColumns = "kind, throttle_key, failures, window_started_at, next_attempt_at, locked_until, updated_at"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLoginThrottle(row rowScanner) (*models.LoginThrottle, error) {
+	throttle := &models.LoginThrottle{}
+
+	err := row.Scan(
+		&throttle.Kind,
+		&throttle.Key,
+		&throttle.Failures,
+		&throttle.WindowStartedAt,
+		&throttle.NextAttemptAt,
+		&throttle.LockedUntil,
+		&throttle.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return throttle, nil
+}
+
+func (r *loginThrottleRepository) Get(ctx context.Context, kind, key string) (*models.LoginThrottle, error) {
+	query := "SELECT " + loginThrottleColumns + " FROM login_throttles WHERE kind = ? AND throttle_key = ?"
+
+	throttle, err := scanLoginThrottle(r.db.QueryRowContext(ctx, query, kind, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.LoginThrottle{Kind: kind, Key: key}, nil
+		}
+		return nil, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+
+	return throttle, nil
+}
+
+// Attempt mengunci baris dengan SELECT ... FOR UPDATE sehingga percobaan paralel dari
+// beberapa replica diproses berurutan, percobaan berikutnya melihat jeda yang disimpan sebelumnya
+func (r *loginThrottleRepository) Attempt(ctx context.Context, kind, key string, now time.Time, window time.Duration, policy BlockPolicy) (*models.LoginThrottle, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Pastikan baris ada agar FOR UPDATE selalu mengunci baris yang sama
+	insertQuery := `INSERT INTO login_throttles (kind, throttle_key, failures, window_started_at, updated_at)
+		VALUES (?, ?, 0, ?, ?)
+		ON DUPLICATE KEY UPDATE kind = kind
+	`
+
+	_, err = tx.ExecContext(ctx, insertQuery, kind, key, now.UTC(), now.UTC())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	selectQuery := "SELECT " + loginThrottleColumns + " FROM login_throttles WHERE kind = ? AND throttle_key = ? FOR UPDATE"
+
+	throttle, err := scanLoginThrottle(tx.QueryRowContext(ctx, selectQuery, kind, key))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+
+	if !throttle.BlockedUntil(now).IsZero() {
+		if err := tx.Commit(); err != nil {
+			return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return throttle, false, nil
+	}
+
+	applyAttempt(throttle, now, window, policy)
+
+	updateQuery := `UPDATE login_throttles
+		SET failures = ?, window_started_at = ?, next_attempt_at = ?, locked_until = ?, updated_at = ?
+		WHERE kind = ? AND throttle_key = ?
+	`
+
+	_, err = tx.ExecContext(ctx, updateQuery,
+		throttle.Failures,
+		throttle.WindowStartedAt.UTC(),
+		throttle.NextAttemptAt,
+		throttle.LockedUntil,
+		throttle.UpdatedAt.UTC(),
+		kind,
+		key,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return throttle, true, nil
+}
+
+// applyAttempt menambah satu percobaan ke throttle yang tidak sedang diblokir lalu menyimpan jeda dari policy
+func applyAttempt(throttle *models.LoginThrottle, now time.Time, window time.Duration, policy BlockPolicy) {
+	if throttle.Failures == 0 || !throttle.WindowStartedAt.After(now.Add(-window)) {
+		throttle.Failures = 0
+		throttle.WindowStartedAt = now
+		throttle.LockedUntil = sql.NullTime{}
+	}
+
+	throttle.Failures++
+	throttle.UpdatedAt = now
+
+	nextAttemptAt, lockedUntil := policy(throttle.Failures)
+	throttle.NextAttemptAt = sql.NullTime{Time: nextAttemptAt.UTC(), Valid: !nextAttemptAt.IsZero()}
+	if !lockedUntil.IsZero() {
+		throttle.LockedUntil = sql.NullTime{Time: lockedUntil.UTC(), Valid: true}
+	}
+}
+
+// Release tidak menyentuh locked_until, percobaan yang berhasil tidak membuka kunci yang sudah dipasang
+func (r *loginThrottleRepository) Release(ctx context.Context, kind, key string, now time.Time) error {
+	query := `UPDATE login_throttles
+		SET failures = GREATEST(failures - 1, 0), next_attempt_at = NULL, updated_at = ?
+		WHERE kind = ? AND throttle_key = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, now.UTC(), kind, key)
+	if err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+
+	return nil
+}
+
+// Reset menghapus catatan kunci setelah login berhasil
+func (r *loginThrottleRepository) Reset(ctx context.Context, kind, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_throttles WHERE kind = ? AND throttle_key = ?", kind, key)
+	if err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStale membersihkan catatan yang sudah lama tidak berubah
+func (r *loginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM login_throttles
+		WHERE updated_at < ?
+		AND (locked_until IS NULL OR locked_until < ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, before.UTC(), before.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete stale login throttles: %w", err)
+	}
+
+	return nil
+}
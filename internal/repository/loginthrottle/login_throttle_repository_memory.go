@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// memoryLoginThrottleRepository menyimpan state di memory proses.
+// Hanya untuk pengujian atau satu instance, state tidak dibagi antar replica
+type memoryLoginThrottleRepository struct {
+	mu        sync.Mutex
+	throttles map[string]*models.LoginThrottle
+}
+
+func NewMemoryLoginThrottleRepository() LoginThrottleRepository {
+	return &memoryLoginThrottleRepository{
+		throttles: make(map[string]*models.LoginThrottle),
+	}
+}
+
+func memoryKey(kind, key string) string {
+	return kind + ":" + key
+}
+
+func (r *memoryLoginThrottleRepository) Get(ctx context.Context, kind, key string) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, ok := r.throttles[memoryKey(kind, key)]
+	if !ok {
+		return &models.LoginThrottle{Kind: kind, Key: key}, nil
+	}
+
+	// Kembalikan salinan agar pemanggil tidak mengubah state langsung
+	copied := *throttle
+	return &copied, nil
+}
+
+func (r *memoryLoginThrottleRepository) Attempt(ctx context.Context, kind, key string, now time.Time, window time.Duration, policy BlockPolicy) (*models.LoginThrottle, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, ok := r.throttles[memoryKey(kind, key)]
+	if !ok {
+		throttle = &models.LoginThrottle{Kind: kind, Key: key}
+		r.throttles[memoryKey(kind, key)] = throttle
+	}
+
+	if !throttle.BlockedUntil(now).IsZero() {
+		copied := *throttle
+		return &copied, false, nil
+	}
+
+	applyAttempt(throttle, now, window, policy)
+
+	copied := *throttle
+	return &copied, true, nil
+}
+
+func (r *memoryLoginThrottleRepository) Release(ctx context.Context, kind, key string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	throttle, ok := r.throttles[memoryKey(kind, key)]
+	if !ok {
+		return nil
+	}
+
+	throttle.Failures = max(throttle.Failures-1, 0)
+	throttle.NextAttemptAt = sql.NullTime{}
+	throttle.UpdatedAt = now
+
+	return nil
+}
+
+func (r *memoryLoginThrottleRepository) Reset(ctx context.Context, kind, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.throttles, memoryKey(kind, key))
+	return nil
+}
+
+func (r *memoryLoginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, throttle := range r.throttles {
+		if throttle.UpdatedAt.Before(before) && (!throttle.LockedUntil.Valid || throttle.LockedUntil.Time.Before(before)) {
+			delete(r.throttles, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+var testNow = time.Date(2030, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// delayPolicy jeda 1 detik mulai percobaan ke-delayAfter, dikunci 15 menit mulai percobaan ke-maxFailures
+func delayPolicy(now time.Time, delayAfter, maxFailures int) BlockPolicy {
+	return func(failures int) (time.Time, time.Time) {
+		if failures >= maxFailures {
+			lockedUntil := now.Add(15 * time.Minute)
+			return lockedUntil, lockedUntil
+		}
+		if failures >= delayAfter {
+			return now.Add(time.Second), time.Time{}
+		}
+		return time.Time{}, time.Time{}
+	}
+}
+
+func noBlock(failures int) (time.Time, time.Time) {
+	return time.Time{}, time.Time{}
+}
+
+func TestMemoryGetMissing(t *testing.T) {
+	repo := NewMemoryLoginThrottleRepository()
+
+	throttle, err := repo.Get(context.Background(), models.ThrottleKindAccount, "a@example.com")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if throttle.Failures != 0 || !throttle.BlockedUntil(testNow).IsZero() {
+		t.Fatalf("throttle = %+v, want empty", throttle)
+	}
+}
+
+func TestMemoryAttemptCountsAndBlocks(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+	policy := delayPolicy(testNow, 2, 4)
+
+	// Percobaan pertama tanpa jeda
+	throttle, allowed, err := repo.Attempt(ctx, models.ThrottleKindAccount, "a", testNow, time.Hour, policy)
+	if err != nil || !allowed || throttle.Failures != 1 {
+		t.Fatalf("attempt 1 = %+v, %v, %v", throttle, allowed, err)
+	}
+
+	// Percobaan kedua memasang jeda untuk percobaan berikutnya
+	throttle, allowed, err = repo.Attempt(ctx, models.ThrottleKindAccount, "a", testNow, time.Hour, policy)
+	if err != nil || !allowed || throttle.Failures != 2 {
+		t.Fatalf("attempt 2 = %+v, %v, %v", throttle, allowed, err)
+	}
+	if got := throttle.BlockedUntil(testNow); !got.Equal(testNow.Add(time.Second)) {
+		t.Fatalf("BlockedUntil = %v, want %v", got, testNow.Add(time.Second))
+	}
+
+	// Selama jeda ditolak dan tidak dihitung
+	throttle, allowed, err = repo.Attempt(ctx, models.ThrottleKindAccount, "a", testNow, time.Hour, policy)
+	if err != nil || allowed || throttle.Failures != 2 {
+		t.Fatalf("attempt during delay = %+v, %v, %v", throttle, allowed, err)
+	}
+
+	// Setelah jeda lewat boleh lagi sampai dikunci
+	later := testNow.Add(2 * time.Second)
+	for i := 3; i <= 4; i++ {
+		later = later.Add(2 * time.Second)
+		throttle, allowed, err = repo.Attempt(ctx, models.ThrottleKindAccount, "a", later, time.Hour, policy)
+		if err != nil || !allowed || throttle.Failures != i {
+			t.Fatalf("attempt %d = %+v, %v, %v", i, throttle, allowed, err)
+		}
+	}
+	if !throttle.IsLocked(later) {
+		t.Fatalf("throttle not locked after max failures: %+v", throttle)
+	}
+
+	_, allowed, _ = repo.Attempt(ctx, models.ThrottleKindAccount, "a", later.Add(time.Minute), time.Hour, policy)
+	if allowed {
+		t.Fatal("attempt allowed while locked")
+	}
+}
+
+func TestMemoryAttemptWindowReset(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+
+	for range 3 {
+		if _, _, err := repo.Attempt(ctx, models.ThrottleKindIP, "203.0.113.7", testNow, time.Hour, noBlock); err != nil {
+			t.Fatalf("Attempt: %v", err)
+		}
+	}
+
+	later := testNow.Add(time.Hour)
+	throttle, allowed, err := repo.Attempt(ctx, models.ThrottleKindIP, "203.0.113.7", later, time.Hour, noBlock)
+	if err != nil || !allowed {
+		t.Fatalf("Attempt after window = %v, %v", allowed, err)
+	}
+	if throttle.Failures != 1 || !throttle.WindowStartedAt.Equal(later) {
+		t.Fatalf("throttle = %+v, want failures 1 from %v", throttle, later)
+	}
+}
+
+func TestMemoryAttemptKeysAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+	policy := delayPolicy(testNow, 1, 10)
+
+	if _, _, err := repo.Attempt(ctx, models.ThrottleKindAccount, "same", testNow, time.Hour, policy); err != nil {
+		t.Fatalf("Attempt: %v", err)
+	}
+
+	_, allowed, err := repo.Attempt(ctx, models.ThrottleKindIP, "same", testNow, time.Hour, policy)
+	if err != nil || !allowed {
+		t.Fatalf("ip attempt blocked by account throttle: %v, %v", allowed, err)
+	}
+}
+
+func TestMemoryAttemptConcurrentBurst(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+	// Jeda dimulai setelah percobaan kedua, burst paralel hanya boleh meloloskan dua percobaan
+	policy := delayPolicy(testNow, 2, 100)
+
+	const workers = 50
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := repo.Attempt(ctx, models.ThrottleKindAccount, "burst", testNow, time.Hour, policy)
+			if err != nil {
+				t.Errorf("Attempt: %v", err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 2 {
+		t.Fatalf("allowed = %d, want 2", allowed)
+	}
+
+	throttle, _ := repo.Get(ctx, models.ThrottleKindAccount, "burst")
+	if throttle.Failures != 2 {
+		t.Fatalf("failures = %d, want 2", throttle.Failures)
+	}
+}
+
+func TestMemoryRelease(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+	policy := delayPolicy(testNow, 1, 2)
+
+	// Release kunci yang belum ada tidak error
+	if err := repo.Release(ctx, models.ThrottleKindIP, "missing", testNow); err != nil {
+		t.Fatalf("Release missing: %v", err)
+	}
+
+	if _, _, err := repo.Attempt(ctx, models.ThrottleKindIP, "ip", testNow, time.Hour, policy); err != nil {
+		t.Fatalf("Attempt: %v", err)
+	}
+	if err := repo.Release(ctx, models.ThrottleKindIP, "ip", testNow); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	throttle, _ := repo.Get(ctx, models.ThrottleKindIP, "ip")
+	if throttle.Failures != 0 || !throttle.BlockedUntil(testNow).IsZero() {
+		t.Fatalf("throttle after release = %+v, want no failures and no delay", throttle)
+	}
+
+	// Release tidak membuka penguncian
+	lockPolicy := delayPolicy(testNow, 10, 2)
+	for range 2 {
+		if _, _, err := repo.Attempt(ctx, models.ThrottleKindIP, "locked", testNow, time.Hour, lockPolicy); err != nil {
+			t.Fatalf("Attempt: %v", err)
+		}
+	}
+	if err := repo.Release(ctx, models.ThrottleKindIP, "locked", testNow); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	throttle, _ = repo.Get(ctx, models.ThrottleKindIP, "locked")
+	if !throttle.IsLocked(testNow) || throttle.Failures != 1 {
+		t.Fatalf("throttle after release = %+v, want locked with 1 failure", throttle)
+	}
+}
+
+func TestMemoryReset(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+
+	if _, _, err := repo.Attempt(ctx, models.ThrottleKindAccount, "a", testNow, time.Hour, delayPolicy(testNow, 1, 1)); err != nil {
+		t.Fatalf("Attempt: %v", err)
+	}
+	if err := repo.Reset(ctx, models.ThrottleKindAccount, "a"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+
+	_, allowed, err := repo.Attempt(ctx, models.ThrottleKindAccount, "a", testNow, time.Hour, noBlock)
+	if err != nil || !allowed {
+		t.Fatalf("attempt after reset = %v, %v", allowed, err)
+	}
+}
+
+func TestMemoryDeleteStale(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryLoginThrottleRepository()
+	old := testNow.Add(-2 * time.Hour)
+
+	// Catatan lama tanpa kunci dihapus
+	if _, _, err := repo.Attempt(ctx, models.ThrottleKindIP, "stale", old, time.Hour, noBlock); err != nil {
+		t.Fatalf("Attempt: %v", err)
+	}
+	// Catatan lama yang masih terkunci dipertahankan
+	lockedPolicy := func(int) (time.Time, time.Time) {
+		return time.Time{}, testNow.Add(time.Hour)
+	}
+	if _, _, err := repo.Attempt(ctx, models.ThrottleKindIP, "locked", old, time.Hour, lockedPolicy); err != nil {
+		t.Fatalf("Attempt: %v", err)
+	}
+	// Catatan baru dipertahankan
+	if _, _, err := repo.Attempt(ctx, models.ThrottleKindIP, "fresh", testNow, time.Hour, noBlock); err != nil {
+		t.Fatalf("Attempt: %v", err)
+	}
+
+	if err := repo.DeleteStale(ctx, testNow.Add(-time.Hour)); err != nil {
+		t.Fatalf("DeleteStale: %v", err)
+	}
+
+	tests := map[string]int{"stale": 0, "locked": 1, "fresh": 1}
+	for key, want := range tests {
+		throttle, _ := repo.Get(ctx, models.ThrottleKindIP, key)
+		if throttle.Failures != want {
+			t.Errorf("%s failures = %d, want %d", key, throttle.Failures, want)
+		}
+	}
+}
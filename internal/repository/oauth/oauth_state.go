@@ -0,0 +1,15 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type OAuthStateRepository interface {
+	Create(ctx context.Context, state *models.OAuthState) error
+	Consume(ctx context.Context, stateHash string, now time.Time) (*models.OAuthState, error)
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
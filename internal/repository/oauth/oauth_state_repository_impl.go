@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type oauthStateRepository struct {
+	db *sql.DB
+}
+
+func NewOAuthStateRepository(db *sql.DB) OAuthStateRepository {
+	return &oauthStateRepository{
+		db: db,
+	}
+}
+
+// Create menyimpan state login OIDC (hanya hash dari state nya)
+func (r *oauthStateRepository) Create(ctx context.Context, state *models.OAuthState) error {
+	query := "INSERT INTO oauth_states (state_hash, code_verifier, nonce, expires_at) VALUES (?, ?, ?, ?)"
+
+	result, err := r.db.ExecContext(ctx, query, state.StateHash, state.CodeVerifier, state.Nonce, state.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create oauth state: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	state.ID = int(id)
+	return nil
+}
+
+// Consume mengambil lalu menghapus state, sehingga satu state hanya bisa dipakai sekali
+func (r *oauthStateRepository) Consume(ctx context.Context, stateHash string, now time.Time) (*models.OAuthState, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, state_hash, code_verifier, nonce, expires_at
+		FROM oauth_states
+		WHERE state_hash = ?
+		FOR UPDATE
+	`
+
+	state := &models.OAuthState{}
+	err = tx.QueryRowContext(ctx, query, stateHash).Scan(
+		&state.ID,
+		&state.StateHash,
+		&state.CodeVerifier,
+		&state.Nonce,
+		&state.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid or expired state")
+		}
+		return nil, fmt.Errorf("failed to get oauth state: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM oauth_states WHERE id = ?", state.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete oauth state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if !state.ExpiresAt.After(now) {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	return state, nil
+}
+
+// DeleteExpired membersihkan state login yang tidak pernah diselesaikan
+func (r *oauthStateRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM oauth_states WHERE expires_at <= ?", now.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired oauth states: %w", err)
+	}
+
+	return nil
+}
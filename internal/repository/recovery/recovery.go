@@ -0,0 +1,14 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID int, codeHashes []string) error
+	Consume(ctx context.Context, userID int, codeHash string, now time.Time) error
+	CountUnused(ctx context.Context, userID int) (int, error)
+	DeleteByUser(ctx context.Context, userID int) error
+}
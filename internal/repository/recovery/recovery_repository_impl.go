@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type recoveryCodeRepository struct {
+	db *sql.DB
+}
+
+func NewRecoveryCodeRepository(db *sql.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{
+		db: db,
+	}
+}
+
+// Replace mengganti semua recovery code user dengan yang baru dalam satu transaksi
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		_, err := tx.ExecContext(ctx, "INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, codeHash)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Consume memakai satu recovery code, setiap code hanya bisa dipakai sekali
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID int, codeHash string, now time.Time) error {
+	query := "UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, now.UTC(), userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("invalid two factor code")
+	}
+
+	return nil
+}
+
+// CountUnused menghitung recovery code yang belum dipakai
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID int) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL", userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// DeleteByUser menghapus semua recovery code user
+func (r *recoveryCodeRepository) DeleteByUser(ctx context.Context, userID int) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	return nil
+}
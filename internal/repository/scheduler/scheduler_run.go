@@ -0,0 +1,17 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type SchedulerRunRepository interface {
+	Create(ctx context.Context, run *models.SchedulerRun) error
+	Finish(ctx context.Context, run *models.SchedulerRun) error
+	List(ctx context.Context, limit int) ([]models.SchedulerRun, error)
+	GetLatest(ctx context.Context) (*models.SchedulerRun, error)
+	DeleteOlderThan(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type schedulerRunRepository struct {
+	db *sql.DB
+}
+
+func NewSchedulerRunRepository(db *sql.DB) SchedulerRunRepository {
+	return &schedulerRunRepository{
+		db: db,
+	}
+}
+
+const schedulerRunColumns = "id, trigger_source, instance_id, status, total, sent, retried, failed, deferred, overdue, error, started_at, finished_at"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSchedulerRun(row rowScanner) (*models.SchedulerRun, error) {
+	run := &models.SchedulerRun{}
+
+	err := row.Scan(
+		&run.ID,
+		&run.TriggerSource,
+		&run.InstanceID,
+		&run.Status,
+		&run.Total,
+		&run.Sent,
+		&run.Retried,
+		&run.Failed,
+		&run.Deferred,
+		&run.Overdue,
+		&run.Error,
+		&run.StartedAt,
+		&run.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// Create menyimpan run baru dengan status running
+func (r *schedulerRunRepository) Create(ctx context.Context, run *models.SchedulerRun) error {
+	query := "INSERT INTO scheduler_runs (trigger_source, instance_id, status, started_at) VALUES (?, ?, ?, ?)"
+
+	result, err := r.db.ExecContext(ctx, query, run.TriggerSource, run.InstanceID, run.Status, run.StartedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create scheduler run: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	run.ID = int(id)
+	return nil
+}
+
+// Finish menyimpan hasil akhir run
+func (r *schedulerRunRepository) Finish(ctx context.Context, run *models.SchedulerRun) error {
+	query := `UPDATE scheduler_runs
+		SET status = ?, total = ?, sent = ?, retried = ?, failed = ?, deferred = ?, overdue = ?, error = ?, finished_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		run.Status,
+		run.Total,
+		run.Sent,
+		run.Retried,
+		run.Failed,
+		run.Deferred,
+		run.Overdue,
+		run.Error,
+		run.FinishedAt,
+		run.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to finish scheduler run: %w", err)
+	}
+
+	return nil
+}
+
+// List mengambil run terbaru
+func (r *schedulerRunRepository) List(ctx context.Context, limit int) ([]models.SchedulerRun, error) {
+	query := "SELECT " + schedulerRunColumns + " FROM scheduler_runs ORDER BY started_at DESC, id DESC LIMIT ?"
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler runs: %w", err)
+	}
+
+	defer rows.Close()
+
+	runs := []models.SchedulerRun{}
+	for rows.Next() {
+		run, err := scanSchedulerRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetLatest mengambil run terakhir
+func (r *schedulerRunRepository) GetLatest(ctx context.Context) (*models.SchedulerRun, error) {
+	query := "SELECT " + schedulerRunColumns + " FROM scheduler_runs ORDER BY started_at DESC, id DESC LIMIT 1"
+
+	run, err := scanSchedulerRun(r.db.QueryRowContext(ctx, query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("scheduler run not found")
+		}
+		return nil, fmt.Errorf("failed to get latest scheduler run: %w", err)
+	}
+
+	return run, nil
+}
+
+// DeleteOlderThan menghapus riwayat run yang lebih lama dari batas retensi
+func (r *schedulerRunRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM scheduler_runs WHERE started_at < ?", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old scheduler runs: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
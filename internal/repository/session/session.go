@@ -0,0 +1,19 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session, refreshToken *models.RefreshToken) error
+	GetByID(ctx context.Context, id int) (*models.Session, error)
+	ListActiveByUser(ctx context.Context, userID int, now time.Time) ([]models.Session, error)
+	Touch(ctx context.Context, id int, now time.Time, minInterval time.Duration) error
+	Rotate(ctx context.Context, tokenHash string, next *models.RefreshToken, client models.ClientInfo, now time.Time) (*models.Session, error)
+	Revoke(ctx context.Context, id, userID int, reason string, now time.Time) error
+	RevokeAllByUser(ctx context.Context, userID int, reason string, now time.Time) (int, error)
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{
+		db: db,
+	}
+}
+
+const sessionColumns = "id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at, revoked_reason"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+		&session.RevokedReason,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Create menyimpan sesi baru beserta refresh token pertamanya dalam satu transaksi
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session, refreshToken *models.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO sessions (user_id, user_agent, ip_address, created_at, last_seen_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := tx.ExecContext(ctx, query,
+		session.UserID,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt.UTC(),
+		session.LastSeenAt.UTC(),
+		session.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	session.ID = int(id)
+	refreshToken.SessionID = session.ID
+
+	if err := insertRefreshToken(ctx, tx, refreshToken); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertRefreshToken(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error {
+	query := "INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES (?, ?, ?)"
+
+	result, err := tx.ExecContext(ctx, query, token.SessionID, token.TokenHash, token.ExpiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	token.ID = int(id)
+	return nil
+}
+
+// GetByID mengambil sesi berdasarkan ID
+func (r *sessionRepository) GetByID(ctx context.Context, id int) (*models.Session, error) {
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE id = ?"
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("session not found")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return session, nil
+}
+
+// ListActiveByUser mengambil sesi user yang belum dicabut dan belum expired
+func (r *sessionRepository) ListActiveByUser(ctx context.Context, userID int, now time.Time) ([]models.Session, error) {
+	query := "SELECT " + sessionColumns + ` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_seen_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Touch memperbarui last_seen_at, paling sering sekali setiap minInterval agar tidak menulis di setiap request
+func (r *sessionRepository) Touch(ctx context.Context, id int, now time.Time, minInterval time.Duration) error {
+	query := "UPDATE sessions SET last_seen_at = ? WHERE id = ? AND last_seen_at < ?"
+
+	_, err := r.db.ExecContext(ctx, query, now.UTC(), id, now.Add(-minInterval).UTC())
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	return nil
+}
+
+// Rotate menukar refresh token lama dengan yang baru secara atomik.
+// Refresh token yang sudah pernah dipakai dianggap dicuri, sesi nya langsung dicabut
+func (r *sessionRepository) Rotate(ctx context.Context, tokenHash string, next *models.RefreshToken, client models.ClientInfo, now time.Time) (*models.Session, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current := &models.RefreshToken{}
+	query := "SELECT id, session_id, token_hash, expires_at, used_at FROM refresh_tokens WHERE token_hash = ? FOR UPDATE"
+
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(
+		&current.ID,
+		&current.SessionID,
+		&current.TokenHash,
+		&current.ExpiresAt,
+		&current.UsedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid refresh token")
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	session, err := scanSession(tx.QueryRowContext(ctx, "SELECT "+sessionColumns+" FROM sessions WHERE id = ? FOR UPDATE", current.SessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Reuse detection: token lama dipakai lagi, cabut sesi agar pencuri dan pemilik sama-sama harus login ulang
+	if current.UsedAt.Valid {
+		if !session.RevokedAt.Valid {
+			_, err := tx.ExecContext(ctx,
+				"UPDATE sessions SET revoked_at = ?, revoked_reason = ? WHERE id = ?",
+				now.UTC(), models.SessionRevokedTokenReuse, session.ID,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to revoke session: %w", err)
+			}
+
+			if err := tx.Commit(); err != nil {
+				return nil, fmt.Errorf("failed to commit transaction: %w", err)
+			}
+		}
+		return nil, errors.New("refresh token reused")
+	}
+
+	if !current.ExpiresAt.After(now) || !session.IsActive(now) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = ? WHERE id = ?", now.UTC(), current.ID); err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	next.SessionID = session.ID
+	if err := insertRefreshToken(ctx, tx, next); err != nil {
+		return nil, err
+	}
+
+	// Sesi diperpanjang mengikuti masa berlaku refresh token yang baru
+	session.LastSeenAt = now.UTC()
+	session.ExpiresAt = next.ExpiresAt.UTC()
+	if client.UserAgent != "" {
+		session.UserAgent = sql.NullString{String: client.UserAgent, Valid: true}
+	}
+	if client.IPAddress != "" {
+		session.IPAddress = sql.NullString{String: client.IPAddress, Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE sessions SET last_seen_at = ?, expires_at = ?, user_agent = ?, ip_address = ? WHERE id = ?",
+		session.LastSeenAt, session.ExpiresAt, session.UserAgent, session.IPAddress, session.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return session, nil
+}
+
+// Revoke mencabut satu sesi milik user
+func (r *sessionRepository) Revoke(ctx context.Context, id, userID int, reason string, now time.Time) error {
+	query := "UPDATE sessions SET revoked_at = ?, revoked_reason = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, now.UTC(), reason, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("session not found")
+	}
+
+	return nil
+}
+
+// RevokeAllByUser mencabut semua sesi aktif milik user
+func (r *sessionRepository) RevokeAllByUser(ctx context.Context, userID int, reason string, now time.Time) (int, error) {
+	query := "UPDATE sessions SET revoked_at = ?, revoked_reason = ? WHERE user_id = ? AND revoked_at IS NULL"
+
+	result, err := r.db.ExecContext(ctx, query, now.UTC(), reason, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
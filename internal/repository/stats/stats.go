@@ -0,0 +1,13 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type StatsRepository interface {
+	GetSystemStats(ctx context.Context, now time.Time) (*models.SystemStats, error)
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type statsRepository struct {
+	db *sql.DB
+}
+
+func NewStatsRepository(db *sql.DB) StatsRepository {
+	return &statsRepository{
+		db: db,
+	}
+}
+
+// GetSystemStats menghitung ringkasan user, capsule dan pengiriman untuk admin
+func (r *statsRepository) GetSystemStats(ctx context.Context, now time.Time) (*models.SystemStats, error) {
+	dayAgo := now.Add(-24 * time.Hour).UTC()
+	dayAhead := now.Add(24 * time.Hour).UTC()
+
+	stats := &models.SystemStats{
+		Users:       models.UserStats{ByRole: map[string]int{}},
+		Capsules:    models.CapsuleStats{ByStatus: map[string]int{}},
+		GeneratedAt: now.UTC(),
+	}
+
+	userQuery := `SELECT
+			COUNT(*),
+			COALESCE(SUM(email_verified_at IS NOT NULL), 0),
+			COALESCE(SUM(disabled_at IS NOT NULL), 0),
+			COALESCE(SUM(totp_enabled_at IS NOT NULL), 0),
+			COALESCE(SUM(created_at >= ?), 0)
+		FROM users
+	`
+	err := r.db.QueryRowContext(ctx, userQuery, dayAgo).Scan(
+		&stats.Users.Total,
+		&stats.Users.Verified,
+		&stats.Users.Disabled,
+		&stats.Users.TwoFactor,
+		&stats.Users.NewLast24h,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	if err := r.countGrouped(ctx, "SELECT role, COUNT(*) FROM users GROUP BY role", stats.Users.ByRole); err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+
+	if err := r.countGrouped(ctx, "SELECT status, COUNT(*) FROM capsules GROUP BY status", stats.Capsules.ByStatus); err != nil {
+		return nil, fmt.Errorf("failed to count capsules by status: %w", err)
+	}
+	for _, count := range stats.Capsules.ByStatus {
+		stats.Capsules.Total += count
+	}
+
+	dueQuery := "SELECT COUNT(*) FROM capsules WHERE status = ? AND due_date <= ?"
+	if err := r.db.QueryRowContext(ctx, dueQuery, models.CapsuleStatusPending, dayAhead).Scan(&stats.Capsules.DueNext24h); err != nil {
+		return nil, fmt.Errorf("failed to count due capsules: %w", err)
+	}
+
+	deliveryQuery := `SELECT
+			COALESCE(SUM(status = 'success'), 0),
+			COALESCE(SUM(status = 'failed'), 0)
+		FROM delivery_attempts
+		WHERE attempted_at >= ?
+	`
+	err = r.db.QueryRowContext(ctx, deliveryQuery, dayAgo).Scan(
+		&stats.Deliveries.SuccessLast24h,
+		&stats.Deliveries.FailedLast24h,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count delivery attempts: %w", err)
+	}
+
+	return stats, nil
+}
+
+// countGrouped menjalankan query "SELECT key, COUNT(*) ... GROUP BY key" ke dalam map
+func (r *statsRepository) countGrouped(ctx context.Context, query string, dest map[string]int) error {
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var count int
+		if err := rows.Scan(&key, &count); err != nil {
+			return err
+		}
+		dest[key] = count
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,17 @@
+// Package repository
+package repository
+
+import (
+	"context"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type TokenRepository interface {
+	Create(ctx context.Context, token *models.UserToken) error
+	Consume(ctx context.Context, purpose, tokenHash string, now time.Time) (*models.UserToken, error)
+	RevokeByUser(ctx context.Context, userID int, purpose string, now time.Time) error
+	CountCreatedSince(ctx context.Context, userID int, purpose string, since time.Time) (int, error)
+	RecordAttempt(ctx context.Context, purpose, tokenHash string, now time.Time, maxAttempts int) (bool, error)
+}
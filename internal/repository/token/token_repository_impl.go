@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+type tokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{
+		db: db,
+	}
+}
+
+// Create menyimpan token baru (hanya hash nya)
+func (r *tokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	query := "INSERT INTO user_tokens (user_id, purpose, token_hash, new_email, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := r.db.ExecContext(ctx, query, token.UserID, token.Purpose, token.TokenHash, token.NewEmail, token.ExpiresAt.UTC(), token.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create user token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	token.ID = int(id)
+	return nil
+}
+
+// Consume memakai token sekali pakai. Token yang tidak ada, sudah dipakai
+// atau sudah expired semuanya mengembalikan error yang sama
+func (r *tokenRepository) Consume(ctx context.Context, purpose, tokenHash string, now time.Time) (*models.UserToken, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT id, user_id, purpose, token_hash, new_email, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE token_hash = ? AND purpose = ?
+		FOR UPDATE
+	`
+
+	token := &models.UserToken{}
+	err = tx.QueryRowContext(ctx, query, tokenHash, purpose).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.NewEmail,
+		&token.ExpiresAt,
+		&token.UsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid or expired token")
+		}
+		return nil, fmt.Errorf("failed to get user token: %w", err)
+	}
+
+	if token.UsedAt.Valid || !token.ExpiresAt.After(now) {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE user_tokens SET used_at = ? WHERE id = ?", now.UTC(), token.ID); err != nil {
+		return nil, fmt.Errorf("failed to consume user token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	token.UsedAt = sql.NullTime{Time: now.UTC(), Valid: true}
+	return token, nil
+}
+
+// RevokeByUser menandai semua token user dengan kegunaan yang sama sebagai sudah dipakai
+func (r *tokenRepository) RevokeByUser(ctx context.Context, userID int, purpose string, now time.Time) error {
+	query := "UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL"
+
+	_, err := r.db.ExecContext(ctx, query, now.UTC(), userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// CountCreatedSince menghitung token yang dibuat untuk user sejak waktu tertentu, dipakai untuk rate limit
+func (r *tokenRepository) CountCreatedSince(ctx context.Context, userID int, purpose string, since time.Time) (int, error) {
+	var count int
+
+	query := "SELECT COUNT(*) FROM user_tokens WHERE user_id = ? AND purpose = ? AND created_at >= ?"
+
+	err := r.db.QueryRowContext(ctx, query, userID, purpose, since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count user tokens: %w", err)
+	}
+
+	return count, nil
+}
+
+// RecordAttempt menghitung satu percobaan pada token yang masih berlaku secara atomik.
+// Mengembalikan false jika token tidak ada, sudah dipakai, expired atau sudah mencapai maxAttempts
+func (r *tokenRepository) RecordAttempt(ctx context.Context, purpose, tokenHash string, now time.Time, maxAttempts int) (bool, error) {
+	query := `UPDATE user_tokens SET attempts = attempts + 1
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ? AND attempts < ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, tokenHash, purpose, now.UTC(), maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to record user token attempt: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows == 1, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const recordAttemptQuery = `UPDATE user_tokens SET attempts = attempts \+ 1 WHERE token_hash = \? AND purpose = \? AND used_at IS NULL AND expires_at > \? AND attempts < \?`
+
+func newMockRepository(t *testing.T) (TokenRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return NewTokenRepository(db), mock
+}
+
+func TestRecordAttempt(t *testing.T) {
+	tests := []struct {
+		name string
+		// rows jumlah baris yang terupdate, 0 berarti token tidak berlaku atau percobaan habis
+		rows int64
+		want bool
+	}{
+		{name: "attempt counted", rows: 1, want: true},
+		{name: "attempts exhausted or token invalid", rows: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+			mock.ExpectExec(recordAttemptQuery).
+				WithArgs("hash", models.TokenPurposeTwoFactorChallenge, testNow, 5).
+				WillReturnResult(sqlmock.NewResult(0, tt.rows))
+
+			allowed, err := repo.RecordAttempt(context.Background(), models.TokenPurposeTwoFactorChallenge, "hash", testNow, 5)
+			if err != nil {
+				t.Fatalf("RecordAttempt: %v", err)
+			}
+			if allowed != tt.want {
+				t.Fatalf("allowed = %v, want %v", allowed, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRecordAttemptError(t *testing.T) {
+	repo, mock := newMockRepository(t)
+	mock.ExpectExec(recordAttemptQuery).WillReturnError(errors.New("connection lost"))
+
+	allowed, err := repo.RecordAttempt(context.Background(), models.TokenPurposeTwoFactorChallenge, "hash", testNow, 5)
+	if err == nil || allowed {
+		t.Fatalf("RecordAttempt = %v, %v, want error", allowed, err)
+	}
+}
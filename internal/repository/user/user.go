@@ -3,6 +3,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"future-letter/internal/models"
 )
@@ -11,6 +12,21 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id int) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Search(ctx context.Context, filter models.UserFilter) ([]models.User, int, error)
 	Update(ctx context.Context, user *models.User) error
+	UpdateRole(ctx context.Context, userID int, role string) error
+	Disable(ctx context.Context, userID int, reason string, disabledAt time.Time) error
+	Enable(ctx context.Context, userID int) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	ClearPassword(ctx context.Context, userID int) error
+	UpdateEmail(ctx context.Context, userID int, email string, verifiedAt time.Time) error
+	MarkEmailVerified(ctx context.Context, userID int, verifiedAt time.Time) error
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	EnableTOTP(ctx context.Context, userID int, step int64, enabledAt time.Time) error
+	DisableTOTP(ctx context.Context, userID int) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) error
+	ScheduleDeletion(ctx context.Context, userID int, requestedAt, scheduledAt time.Time) error
+	CancelDeletion(ctx context.Context, userID int) error
+	DeleteScheduled(ctx context.Context, before time.Time, limit int) (int, error)
 	Delete(ctx context.Context, id int) error
 }
@@ -6,10 +6,17 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"future-letter/internal/models"
+
+	"github.com/go-sql-driver/mysql"
 )
 
+// mysqlDuplicateEntry kode error MySQL saat nilai melanggar unique index (uq_users_email)
+const mysqlDuplicateEntry = 1062
+
 type userRepositoryImpl struct {
 	db *sql.DB
 }
@@ -20,17 +27,67 @@ func NewUserRepository(db *sql.DB) UserRepository {
 	}
 }
 
-// Create untuk input user ke database
+// userColumns kolom yang diambil setiap kali membaca user, urutannya harus sama dengan scanUser
+const userColumns = "id, name, email, email_verified_at, password, timezone, phone_number, telegram_chat_id, role, disabled_at, disabled_reason, deletion_requested_at, deletion_scheduled_at, token_version, totp_secret, totp_enabled_at, totp_last_step, created_at, updated_at"
+
+// rowScanner bisa berupa *sql.Row atau *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	user := &models.User{}
+
+	// password NULL untuk akun yang hanya login lewat OIDC
+	var password sql.NullString
+
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.EmailVerifiedAt,
+		&password,
+		&user.Timezone,
+		&user.PhoneNumber,
+		&user.TelegramChatID,
+		&user.Role,
+		&user.DisabledAt,
+		&user.DisabledReason,
+		&user.DeletionRequestedAt,
+		&user.DeletionScheduledAt,
+		&user.TokenVersion,
+		&user.TOTPSecret,
+		&user.TOTPEnabledAt,
+		&user.TOTPLastStep,
+		&user.CreatedAt,
+		&user.UpdateAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = password.String
+
+	return user, nil
+}
+
+// Create untuk input user ke database. Password kosong disimpan sebagai NULL
 func (u *userRepositoryImpl) Create(ctx context.Context, user *models.User) error {
-	query := "INSERT INTO users (name, email, password, timezone) VALUES (?, ?, ?, ?)"
+	query := "INSERT INTO users (name, email, email_verified_at, password, timezone) VALUES (?, ?, ?, ?, ?)"
+
+	password := sql.NullString{String: user.Password, Valid: user.Password != ""}
 
 	result, err := u.db.ExecContext(ctx, query,
 		user.Name,
 		user.Email,
-		user.Password,
+		user.EmailVerifiedAt,
+		password,
 		user.Timezone,
 	)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			return errors.New("email already registered")
+		}
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
 
@@ -46,11 +103,9 @@ func (u *userRepositoryImpl) Create(ctx context.Context, user *models.User) erro
 
 // GetByID untuk mendapatkan user berdasarkan ID
 func (u *userRepositoryImpl) GetByID(ctx context.Context, id int) (*models.User, error) {
-	user := &models.User{}
-
-	query := "SELECT id, name, email, password, timezone, created_at, updated_at FROM users WHERE id = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
 
-	err := u.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Timezone, &user.CreatedAt, &user.UpdateAt)
+	user, err := scanUser(u.db.QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("user not found")
@@ -63,11 +118,9 @@ func (u *userRepositoryImpl) GetByID(ctx context.Context, id int) (*models.User,
 
 // GetByEmail untuk mendapatkan user berdasarkan email
 func (u *userRepositoryImpl) GetByEmail(ctx context.Context, email string) (*models.User, error) {
-	user := &models.User{}
-
-	query := "SELECT id, name, email, password, timezone, created_at, updated_at FROM users WHERE email = ?"
+	query := "SELECT " + userColumns + " FROM users WHERE email = ?"
 
-	err := u.db.QueryRowContext(ctx, query, email).Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Timezone, &user.CreatedAt, &user.UpdateAt)
+	user, err := scanUser(u.db.QueryRowContext(ctx, query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("user not found")
@@ -78,11 +131,72 @@ func (u *userRepositoryImpl) GetByEmail(ctx context.Context, email string) (*mod
 	return user, nil
 }
 
-// Update untuk mengubah nama dan timezone
+// Search mencari user untuk admin API, mengembalikan satu halaman hasil beserta total nya
+func (u *userRepositoryImpl) Search(ctx context.Context, filter models.UserFilter) ([]models.User, int, error) {
+	conditions := []string{}
+	args := []any{}
+
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		conditions = append(conditions, "(name LIKE ? OR email LIKE ?)")
+		args = append(args, pattern, pattern)
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, filter.Role)
+	}
+	switch filter.Status {
+	case models.UserStatusActive:
+		conditions = append(conditions, "disabled_at IS NULL")
+	case models.UserStatusDisabled:
+		conditions = append(conditions, "disabled_at IS NOT NULL")
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := u.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + where + " ORDER BY id DESC LIMIT ? OFFSET ?"
+
+	rows, err := u.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, total, nil
+}
+
+// escapeLike meng-escape karakter wildcard LIKE agar dicari apa adanya
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(value)
+}
+
+// Update untuk mengubah nama, timezone dan kontak notifikasi
 func (u *userRepositoryImpl) Update(ctx context.Context, user *models.User) error {
-	query := "UPDATE users SET name = ?, timezone = ? WHERE id = ?"
+	query := "UPDATE users SET name = ?, timezone = ?, phone_number = ?, telegram_chat_id = ? WHERE id = ?"
 
-	_, err := u.db.ExecContext(ctx, query, user.Name, user.Timezone, user.ID)
+	_, err := u.db.ExecContext(ctx, query, user.Name, user.Timezone, user.PhoneNumber, user.TelegramChatID, user.ID)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -90,6 +204,266 @@ func (u *userRepositoryImpl) Update(ctx context.Context, user *models.User) erro
 	return nil
 }
 
+// UpdatePassword mengganti password dan menaikkan token_version
+// agar semua sesi (JWT) yang sudah ada tidak berlaku lagi
+func (u *userRepositoryImpl) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := "UPDATE users SET password = ?, token_version = token_version + 1 WHERE id = ?"
+
+	result, err := u.db.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// UpdateRole mengganti role user
+func (u *userRepositoryImpl) UpdateRole(ctx context.Context, userID int, role string) error {
+	result, err := u.db.ExecContext(ctx, "UPDATE users SET role = ? WHERE id = ?", role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// Disable menonaktifkan akun user beserta alasannya
+func (u *userRepositoryImpl) Disable(ctx context.Context, userID int, reason string, disabledAt time.Time) error {
+	query := "UPDATE users SET disabled_at = ?, disabled_reason = ? WHERE id = ? AND disabled_at IS NULL"
+
+	result, err := u.db.ExecContext(ctx, query, disabledAt.UTC(), reason, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found or already disabled")
+	}
+
+	return nil
+}
+
+// Enable mengaktifkan kembali akun yang dinonaktifkan
+func (u *userRepositoryImpl) Enable(ctx context.Context, userID int) error {
+	query := "UPDATE users SET disabled_at = NULL, disabled_reason = NULL WHERE id = ? AND disabled_at IS NOT NULL"
+
+	result, err := u.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found or not disabled")
+	}
+
+	return nil
+}
+
+// UpdateEmail mengganti email user dengan alamat yang sudah diverifikasi
+func (u *userRepositoryImpl) UpdateEmail(ctx context.Context, userID int, email string, verifiedAt time.Time) error {
+	query := "UPDATE users SET email = ?, email_verified_at = ? WHERE id = ?"
+
+	result, err := u.db.ExecContext(ctx, query, email, verifiedAt.UTC(), userID)
+	if err != nil {
+		// Email sudah dipakai user lain yang mendaftar atau mengganti email bersamaan
+		if isDuplicateEntry(err) {
+			return errors.New("email already registered")
+		}
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// ClearPassword menghapus password user dan menaikkan token_version,
+// dipakai saat akun lokal yang belum terverifikasi diambil alih oleh pemilik email lewat OIDC
+func (u *userRepositoryImpl) ClearPassword(ctx context.Context, userID int) error {
+	query := "UPDATE users SET password = NULL, token_version = token_version + 1 WHERE id = ?"
+
+	_, err := u.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear password: %w", err)
+	}
+
+	return nil
+}
+
+// MarkEmailVerified menandai email user sudah diverifikasi
+func (u *userRepositoryImpl) MarkEmailVerified(ctx context.Context, userID int, verifiedAt time.Time) error {
+	query := "UPDATE users SET email_verified_at = COALESCE(email_verified_at, ?) WHERE id = ?"
+
+	_, err := u.db.ExecContext(ctx, query, verifiedAt.UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email as verified: %w", err)
+	}
+
+	return nil
+}
+
+// SetTOTPSecret menyimpan secret 2FA yang belum dikonfirmasi, hanya jika 2FA belum aktif
+func (u *userRepositoryImpl) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := "UPDATE users SET totp_secret = ?, totp_last_step = NULL WHERE id = ? AND totp_enabled_at IS NULL"
+
+	result, err := u.db.ExecContext(ctx, query, secret, userID)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("two factor already enabled")
+	}
+
+	return nil
+}
+
+// EnableTOTP mengaktifkan 2FA setelah kode pertama berhasil dikonfirmasi
+func (u *userRepositoryImpl) EnableTOTP(ctx context.Context, userID int, step int64, enabledAt time.Time) error {
+	query := "UPDATE users SET totp_enabled_at = ?, totp_last_step = ? WHERE id = ? AND totp_secret IS NOT NULL"
+
+	_, err := u.db.ExecContext(ctx, query, enabledAt.UTC(), step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTOTP menonaktifkan 2FA dan menghapus secret nya
+func (u *userRepositoryImpl) DisableTOTP(ctx context.Context, userID int) error {
+	query := "UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL WHERE id = ?"
+
+	_, err := u.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep mencatat langkah waktu kode TOTP yang dipakai.
+// Gagal jika kode dari langkah yang sama atau lebih lama sudah pernah dipakai (replay)
+func (u *userRepositoryImpl) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	query := "UPDATE users SET totp_last_step = ? WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)"
+
+	result, err := u.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to use totp code: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("invalid two factor code")
+	}
+
+	return nil
+}
+
+// ScheduleDeletion menandai akun untuk dihapus permanen pada scheduledAt
+func (u *userRepositoryImpl) ScheduleDeletion(ctx context.Context, userID int, requestedAt, scheduledAt time.Time) error {
+	query := "UPDATE users SET deletion_requested_at = ?, deletion_scheduled_at = ? WHERE id = ? AND deletion_scheduled_at IS NULL"
+
+	result, err := u.db.ExecContext(ctx, query, requestedAt.UTC(), scheduledAt.UTC(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to schedule account deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("account deletion already scheduled")
+	}
+
+	return nil
+}
+
+// CancelDeletion membatalkan penghapusan akun yang masih dalam masa tenggang
+func (u *userRepositoryImpl) CancelDeletion(ctx context.Context, userID int) error {
+	query := "UPDATE users SET deletion_requested_at = NULL, deletion_scheduled_at = NULL WHERE id = ? AND deletion_scheduled_at IS NOT NULL"
+
+	result, err := u.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New("account deletion not scheduled")
+	}
+
+	return nil
+}
+
+// DeleteScheduled menghapus permanen akun yang masa tenggangnya sudah habis.
+// Capsule, inbox, sesi dan token ikut terhapus lewat ON DELETE CASCADE
+func (u *userRepositoryImpl) DeleteScheduled(ctx context.Context, before time.Time, limit int) (int, error) {
+	query := "DELETE FROM users WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ? ORDER BY deletion_scheduled_at ASC LIMIT ?"
+
+	result, err := u.db.ExecContext(ctx, query, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete scheduled users: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
 // Delete untuk menghapus user yang ada di database
 func (u *userRepositoryImpl) Delete(ctx context.Context, id int) error {
 	query := "DELETE FROM users WHERE id = ?"
@@ -110,3 +484,9 @@ func (u *userRepositoryImpl) Delete(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// isDuplicateEntry mengecek apakah error berasal dari pelanggaran unique index
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+var testNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newMockRepository(t *testing.T) (UserRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return NewUserRepository(db), mock
+}
+
+func TestUpdateEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  error
+		rows    int64
+		wantErr string
+	}{
+		{name: "updated", rows: 1},
+		{name: "user missing", rows: 0, wantErr: "user not found"},
+		{name: "email taken concurrently", result: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'new@example.com' for key 'users.uq_users_email'"}, wantErr: "email already registered"},
+		{name: "other database error", result: errors.New("connection lost"), wantErr: "failed to update email: connection lost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, mock := newMockRepository(t)
+
+			exec := mock.ExpectExec(`UPDATE users SET email = \?, email_verified_at = \? WHERE id = \?`).
+				WithArgs("new@example.com", testNow, 7)
+			if tt.result != nil {
+				exec.WillReturnError(tt.result)
+			} else {
+				exec.WillReturnResult(sqlmock.NewResult(0, tt.rows))
+			}
+
+			err := repo.UpdateEmail(context.Background(), 7, "new@example.com", testNow)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("UpdateEmail: %v", err)
+				}
+			} else if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCreateDuplicateEmail(t *testing.T) {
+	repo, mock := newMockRepository(t)
+
+	mock.ExpectExec(`INSERT INTO users`).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'user@example.com' for key 'users.uq_users_email'"})
+
+	err := repo.Create(context.Background(), &models.User{Name: "User", Email: "user@example.com", Password: "hash", Timezone: "Asia/Jakarta"})
+	if err == nil || err.Error() != "email already registered" {
+		t.Fatalf("error = %v, want email already registered", err)
+	}
+}
@@ -2,19 +2,30 @@
 package routes
 
 import (
+	"future-letter/internal/clock"
 	"future-letter/internal/config"
 	"future-letter/internal/database"
+	adminHandler "future-letter/internal/handler/admin"
 	capsuleHandler "future-letter/internal/handler/capsule"
+	debugHandler "future-letter/internal/handler/debug"
+	exportHandler "future-letter/internal/handler/export"
+	inboxHandler "future-letter/internal/handler/inbox"
 	userHandler "future-letter/internal/handler/user"
 	"future-letter/internal/middleware"
+	"future-letter/internal/models"
+	adminService "future-letter/internal/service/admin"
 	capsuleService "future-letter/internal/service/capsule"
+	exportService "future-letter/internal/service/export"
+	inboxService "future-letter/internal/service/inbox"
+	oidcService "future-letter/internal/service/oidc"
+	schedulerService "future-letter/internal/service/scheduler"
 	userService "future-letter/internal/service/user"
 	"future-letter/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, cfg *config.Config, userService userService.UserService, capsuleService capsuleService.CapsuleService) {
+func SetupRoutes(router *gin.Engine, cfg *config.Config, userService userService.UserService, adminService adminService.AdminService, capsuleService capsuleService.CapsuleService, inboxService inboxService.InboxService, exportService exportService.ExportService, schedulerService schedulerService.SchedulerService, oidcService oidcService.OIDCService, debugClock *clock.OffsetClock) {
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		allowedOrigin := "http://localhost:8000"
@@ -47,34 +58,137 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, userService userService
 		})
 	})
 
+	// authRequired memvalidasi JWT sekaligus memastikan sesi belum dicabut,
+	// personal access token ditolak di route ini
+	authRequired := middleware.AuthRequired(userService.ValidateSession, userService.AuthenticateAccessToken)
+
+	// scopeRequired menerima JWT maupun personal access token yang memiliki scope tersebut
+	scopeRequired := func(scopes ...string) gin.HandlerFunc {
+		return middleware.AuthRequired(userService.ValidateSession, userService.AuthenticateAccessToken, scopes...)
+	}
+
 	api := router.Group("/api/v1")
 	{
 		// Auth routes
 		// Initialize auth handler dengan dependency injection
-		authHandler := userHandler.NewUserHandler(userService, cfg)
+		authHandler := userHandler.NewUserHandler(userService, oidcService, cfg)
 
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", authHandler.Register)
 			auth.POST("/login", authHandler.Login)
+			auth.POST("/login/2fa", authHandler.LoginTwoFactor)
+			auth.POST("/magic-link", authHandler.RequestMagicLink)
+			auth.GET("/magic-link/consume", authHandler.MagicLinkRedirect)
+			auth.POST("/magic-link/consume", authHandler.ConsumeMagicLink)
+			auth.POST("/forgot-password", authHandler.ForgotPassword)
+			auth.POST("/reset-password", authHandler.ResetPassword)
+			auth.GET("/verify", authHandler.VerifyEmail)
+			auth.GET("/email/confirm", authHandler.ConfirmEmailChange)
+			auth.GET("/account/cancel-deletion", authHandler.CancelAccountDeletion)
+
+			// Login OIDC hanya tersedia jika provider dikonfigurasi
+			if oidcService != nil {
+				auth.GET("/oidc/login", authHandler.OIDCLogin)
+				auth.GET("/oidc/callback", authHandler.OIDCCallback)
+			}
 
 			// Protected endpoints
-			auth.GET("/profile", middleware.AuthRequired(), authHandler.GetProfile)
-			auth.PUT("/update", middleware.AuthRequired(), authHandler.UpdateProfile)
-			auth.POST("/refresh", middleware.AuthRequired(), authHandler.RefreshToken)
+			auth.GET("/profile", authRequired, authHandler.GetProfile)
+			auth.PUT("/update", authRequired, authHandler.UpdateProfile)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/logout", authRequired, authHandler.Logout)
+			auth.POST("/logout-all", authRequired, authHandler.LogoutAll)
+			auth.GET("/sessions", authRequired, authHandler.GetSessions)
+			auth.POST("/2fa/setup", authRequired, authHandler.SetupTwoFactor)
+			auth.POST("/2fa/confirm", authRequired, authHandler.ConfirmTwoFactor)
+			auth.POST("/2fa/disable", authRequired, authHandler.DisableTwoFactor)
+			auth.POST("/verify/resend", authRequired, authHandler.ResendVerification)
+			auth.PUT("/password", authRequired, authHandler.ChangePassword)
+			auth.POST("/email", authRequired, authHandler.ChangeEmail)
+			auth.POST("/tokens", authRequired, authHandler.CreateAccessToken)
+			auth.GET("/tokens", authRequired, authHandler.GetAccessTokens)
+			auth.DELETE("/tokens/:tokenID", authRequired, authHandler.RevokeAccessToken)
+			auth.GET("/account/export", authRequired, authHandler.ExportAccount)
+			auth.DELETE("/account", authRequired, authHandler.DeleteAccount)
 		}
 
 		// Initialize capsule hadnler dengan dependency injection
 		capsuleHandler := capsuleHandler.NewCapsuleHandler(capsuleService)
 
 		capsules := api.Group("/capsules")
-		capsules.Use(middleware.AuthRequired())
+		capsulesRead := scopeRequired(models.ScopeCapsulesRead)
+		capsulesWrite := scopeRequired(models.ScopeCapsulesWrite)
 		{
-			capsules.GET("", capsuleHandler.GetAllCapsules)
-			capsules.POST("", capsuleHandler.CreateCapsule)
-			capsules.GET("/:capsuleID", capsuleHandler.GetCapsuleByID)
-			capsules.PUT("/:capsuleID", capsuleHandler.UpdateCapsule)
-			capsules.DELETE("/:capsuleID", capsuleHandler.DeleteCapsule)
+			capsules.GET("", capsulesRead, capsuleHandler.GetAllCapsules)
+			capsules.POST("", capsulesWrite, capsuleHandler.CreateCapsule)
+			capsules.POST("/import", capsulesWrite, capsuleHandler.ImportCapsules)
+			capsules.GET("/search", capsulesRead, capsuleHandler.SearchCapsules)
+			capsules.GET("/:capsuleID", capsulesRead, capsuleHandler.GetCapsuleByID)
+			capsules.PUT("/:capsuleID", capsulesWrite, capsuleHandler.UpdateCapsule)
+			capsules.DELETE("/:capsuleID", capsulesWrite, capsuleHandler.DeleteCapsule)
+			capsules.GET("/:capsuleID/deliveries", capsulesRead, capsuleHandler.GetDeliveryHistory)
+		}
+
+		// Initialize inbox handler dengan dependency injection
+		inboxHandler := inboxHandler.NewInboxHandler(inboxService)
+
+		inbox := api.Group("/inbox")
+		inboxRead := scopeRequired(models.ScopeInboxRead)
+		inboxWrite := scopeRequired(models.ScopeInboxWrite)
+		{
+			inbox.GET("", inboxRead, inboxHandler.GetInbox)
+			inbox.GET("/unread-count", inboxRead, inboxHandler.GetUnreadCount)
+			inbox.PUT("/read-all", inboxWrite, inboxHandler.MarkAllAsRead)
+			inbox.PUT("/:messageID/read", inboxWrite, inboxHandler.MarkAsRead)
+		}
+
+		// Initialize export handler dengan dependency injection
+		exportHandler := exportHandler.NewExportHandler(exportService)
+
+		exports := api.Group("/exports")
+		{
+			exports.POST("", authRequired, exportHandler.CreateExport)
+			exports.GET("", authRequired, exportHandler.GetExports)
+			exports.GET("/:exportID", authRequired, exportHandler.GetExport)
+			// Link download dari email, diamankan dengan tanda tangan dan masa berlaku bukan login
+			exports.GET("/:exportID/download", exportHandler.DownloadExport)
+		}
+
+		// Initialize admin handler dengan dependency injection
+		schedulerHandler := adminHandler.NewSchedulerHandler(schedulerService)
+		adminUserHandler := adminHandler.NewUserHandler(adminService)
+
+		// Support hanya bisa membaca, perubahan akun dan scheduler khusus admin
+		adminOnly := middleware.RequireRole(models.RoleAdmin)
+
+		admin := api.Group("/admin")
+		admin.Use(authRequired, middleware.RequireRole(models.RoleAdmin, models.RoleSupport))
+		{
+			admin.GET("/users", adminUserHandler.ListUsers)
+			admin.GET("/users/:userID", adminUserHandler.GetUser)
+			admin.GET("/users/:userID/capsules", adminUserHandler.GetUserCapsules)
+			admin.POST("/users/:userID/disable", adminOnly, adminUserHandler.DisableUser)
+			admin.POST("/users/:userID/enable", adminOnly, adminUserHandler.EnableUser)
+			admin.PUT("/users/:userID/role", adminOnly, adminUserHandler.UpdateRole)
+			admin.GET("/stats", adminUserHandler.GetStats)
+
+			admin.GET("/scheduler/runs", schedulerHandler.GetRuns)
+			admin.GET("/scheduler/status", schedulerHandler.GetStatus)
+			admin.POST("/scheduler/run", adminOnly, schedulerHandler.RunNow)
+		}
+
+		// Debug routes untuk simulasi waktu, debugClock nil kecuali DEBUG_CLOCK_ENABLED=true
+		if debugClock != nil {
+			clockHandler := debugHandler.NewClockHandler(debugClock)
+
+			debug := api.Group("/debug")
+			debug.Use(authRequired, middleware.RequireRole(models.RoleAdmin))
+			{
+				debug.GET("/clock", clockHandler.GetClock)
+				debug.PUT("/clock", clockHandler.SetClock)
+				debug.DELETE("/clock", clockHandler.ResetClock)
+			}
 		}
 	}
 }
@@ -0,0 +1,20 @@
+// Package service
+package service
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+// AdminService operasi admin dan support terhadap akun user
+type AdminService interface {
+	ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error)
+	GetUser(ctx context.Context, userID int) (*models.User, error)
+	ListUserCapsules(ctx context.Context, userID int) ([]models.Capsule, error)
+	DisableUser(ctx context.Context, actorID, userID int, reason string) error
+	EnableUser(ctx context.Context, actorID, userID int) error
+	UpdateRole(ctx context.Context, actorID, userID int, role string) error
+	GetStats(ctx context.Context) (*models.SystemStats, error)
+	BootstrapAdmins(ctx context.Context, emails []string) error
+}
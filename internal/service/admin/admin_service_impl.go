@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/models"
+	accessTokenRepository "future-letter/internal/repository/accesstoken"
+	capsuleRepository "future-letter/internal/repository/capsule"
+	sessionRepository "future-letter/internal/repository/session"
+	statsRepository "future-letter/internal/repository/stats"
+	repository "future-letter/internal/repository/user"
+)
+
+type adminService struct {
+	userRepo        repository.UserRepository
+	capsuleRepo     capsuleRepository.CapsuleRepository
+	sessionRepo     sessionRepository.SessionRepository
+	accessTokenRepo accessTokenRepository.AccessTokenRepository
+	statsRepo       statsRepository.StatsRepository
+	clock           clock.Clock
+}
+
+func NewAdminService(
+	userRepo repository.UserRepository,
+	capsuleRepo capsuleRepository.CapsuleRepository,
+	sessionRepo sessionRepository.SessionRepository,
+	accessTokenRepo accessTokenRepository.AccessTokenRepository,
+	statsRepo statsRepository.StatsRepository,
+	clock clock.Clock,
+) AdminService {
+	return &adminService{
+		userRepo:        userRepo,
+		capsuleRepo:     capsuleRepo,
+		sessionRepo:     sessionRepo,
+		accessTokenRepo: accessTokenRepo,
+		statsRepo:       statsRepo,
+		clock:           clock,
+	}
+}
+
+// ListUsers mencari user berdasarkan nama/email, role dan status akun
+func (s *adminService) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error) {
+	if filter.Role != "" && !models.IsValidRole(filter.Role) {
+		return nil, 0, errors.New("invalid role")
+	}
+
+	if filter.Status != "" && filter.Status != models.UserStatusActive && filter.Status != models.UserStatusDisabled {
+		return nil, 0, errors.New("invalid status")
+	}
+
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	return s.userRepo.Search(ctx, filter)
+}
+
+func (s *adminService) GetUser(ctx context.Context, userID int) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, userID)
+}
+
+// ListUserCapsules mengambil capsule milik user, handler yang menentukan apakah isi nya ditampilkan
+func (s *adminService) ListUserCapsules(ctx context.Context, userID int) ([]models.Capsule, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return s.capsuleRepo.GetByUserID(ctx, userID)
+}
+
+// DisableUser menonaktifkan akun lalu mencabut semua sesi dan access token nya
+func (s *adminService) DisableUser(ctx context.Context, actorID, userID int, reason string) error {
+	if actorID == userID {
+		return errors.New("cannot disable your own account")
+	}
+
+	now := s.clock.Now()
+
+	if err := s.userRepo.Disable(ctx, userID, strings.TrimSpace(reason), now); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionRepo.RevokeAllByUser(ctx, userID, models.SessionRevokedAccountDisabled, now); err != nil {
+		log.Printf("Failed to revoke sessions for disabled user %d: %v", userID, err)
+	}
+
+	if err := s.accessTokenRepo.RevokeAllByUser(ctx, userID, now); err != nil {
+		log.Printf("Failed to revoke access tokens for disabled user %d: %v", userID, err)
+	}
+
+	log.Printf("User %d disabled by admin %d: %s", userID, actorID, reason)
+	return nil
+}
+
+// EnableUser mengaktifkan kembali akun, user harus login ulang karena sesi lama sudah dicabut
+func (s *adminService) EnableUser(ctx context.Context, actorID, userID int) error {
+	if err := s.userRepo.Enable(ctx, userID); err != nil {
+		return err
+	}
+
+	log.Printf("User %d enabled by admin %d", userID, actorID)
+	return nil
+}
+
+// UpdateRole mengganti role user. Admin tidak bisa mengganti role nya sendiri
+// agar sistem tidak kehilangan admin terakhir secara tidak sengaja
+func (s *adminService) UpdateRole(ctx context.Context, actorID, userID int, role string) error {
+	if !models.IsValidRole(role) {
+		return errors.New("invalid role")
+	}
+
+	if actorID == userID {
+		return errors.New("cannot change your own role")
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, userID, role); err != nil {
+		return err
+	}
+
+	log.Printf("User %d role changed to %s by admin %d", userID, role, actorID)
+	return nil
+}
+
+func (s *adminService) GetStats(ctx context.Context) (*models.SystemStats, error) {
+	return s.statsRepo.GetSystemStats(ctx, s.clock.Now())
+}
+
+// BootstrapAdmins menjadikan email di ADMIN_EMAILS sebagai admin, dijalankan saat aplikasi start
+// agar admin pertama bisa dibuat tanpa menjalankan SQL manual.
+// Hanya akun dengan email terverifikasi yang dipromosikan, siapa pun bisa mendaftar memakai
+// alamat admin yang belum terdaftar tanpa membuktikan kepemilikannya
+func (s *adminService) BootstrapAdmins(ctx context.Context, emails []string) error {
+	for _, email := range emails {
+		user, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil {
+			if err.Error() == "user not found" {
+				continue
+			}
+			return err
+		}
+
+		if user.Role == models.RoleAdmin {
+			continue
+		}
+
+		if !user.IsEmailVerified() {
+			log.Printf("User %d from ADMIN_EMAILS not promoted to admin: email is not verified, verify it and restart", user.ID)
+			continue
+		}
+
+		if err := s.userRepo.UpdateRole(ctx, user.ID, models.RoleAdmin); err != nil {
+			return err
+		}
+
+		log.Printf("User %d promoted to admin from ADMIN_EMAILS", user.ID)
+	}
+
+	return nil
+}
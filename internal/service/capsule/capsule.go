@@ -3,16 +3,26 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"future-letter/internal/models"
 )
 
 type CapsuleService interface {
 	CreateCapsule(ctx context.Context, userID int, input *models.CreateCapsuleInput) (*models.Capsule, error)
+	ImportCapsules(ctx context.Context, userID int, files []models.CapsuleImportFile, dryRun bool) (*models.CapsuleImportResult, error)
 	GetCapsule(ctx context.Context, capsuleID, userID int) (*models.Capsule, error)
 	GetUserCapsule(ctx context.Context, userID int) ([]models.Capsule, error)
+	ListCapsules(ctx context.Context, userID int, params *models.CapsuleListParams) (*models.CapsulePage, error)
+	SearchCapsules(ctx context.Context, userID int, query string, limit, offset int) (*models.CapsuleSearchResponse, error)
 	UpdateCapsule(ctx context.Context, capsuleID, userID int, input *models.UpdateCapsuleInput) (*models.Capsule, error)
 	DeleteCapsule(ctx context.Context, capsuleID, userID int) error
-	GetPendingCapsulesForToday(ctx context.Context) ([]models.Capsule, error)
-	MarkCapsulesAsSent(ctx context.Context, capsuleID int) error
+	GetDueCapsules(ctx context.Context, until time.Time) ([]models.Capsule, error)
+	ClaimDueCapsules(ctx context.Context, now time.Time, limit int, claimToken string, leaseUntil time.Time) ([]models.Capsule, error)
+	MarkCapsulesAsSent(ctx context.Context, capsuleID int, claimToken string, overdue bool, sentAt time.Time) error
+	ScheduleCapsuleRetry(ctx context.Context, capsuleID int, claimToken string, nextAttemptAt time.Time) error
+	DeferCapsule(ctx context.Context, capsuleID int, claimToken string, nextAttemptAt time.Time) error
+	MarkCapsuleAsFailed(ctx context.Context, capsuleID int, claimToken string) error
+	RecoverStaleClaims(ctx context.Context, now time.Time, maxAttempts int) (finalized, requeued, failed int, err error)
+	GetDeliveryHistory(ctx context.Context, capsuleID, userID int) ([]models.DeliveryAttempt, error)
 }
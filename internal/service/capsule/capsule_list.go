@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+const (
+	defaultCapsuleListLimit = 20
+	maxCapsuleListLimit     = 100
+)
+
+// capsuleStatuses status yang bisa dipakai sebagai filter
+var capsuleStatuses = []string{
+	models.CapsuleStatusPending,
+	models.CapsuleStatusSending,
+	models.CapsuleStatusSent,
+	models.CapsuleStatusCancelled,
+	models.CapsuleStatusFailed,
+}
+
+// ListCapsules mengambil satu halaman capsule milik user dengan filter dan urutan tertentu.
+// Halaman berikutnya diambil dengan next_cursor dari halaman sebelumnya
+func (s *capsuleService) ListCapsules(ctx context.Context, userID int, params *models.CapsuleListParams) (*models.CapsulePage, error) {
+	query, err := s.buildCapsuleQuery(ctx, userID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ambil satu capsule lebih untuk mengetahui apakah masih ada halaman berikutnya
+	limit := query.Limit
+	query.Limit = limit + 1
+
+	capsules, err := s.capsuleRepo.Find(ctx, *query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.CapsulePage{
+		Capsules: capsules,
+		Pagination: models.CapsulePagination{
+			Limit: limit,
+			Sort:  query.Sort,
+			Order: query.Order,
+		},
+	}
+
+	if len(capsules) > limit {
+		page.Capsules = capsules[:limit]
+		page.Pagination.HasMore = true
+
+		cursor, err := encodeCapsuleCursor(query.Sort, query.Order, &page.Capsules[limit-1])
+		if err != nil {
+			return nil, err
+		}
+		page.Pagination.NextCursor = &cursor
+	}
+
+	return page, nil
+}
+
+// buildCapsuleQuery memvalidasi parameter daftar capsule dan mengubahnya menjadi CapsuleQuery
+func (s *capsuleService) buildCapsuleQuery(ctx context.Context, userID int, params *models.CapsuleListParams) (*models.CapsuleQuery, error) {
+	query := &models.CapsuleQuery{
+		UserID:         userID,
+		Status:         params.Status,
+		Category:       params.Category,
+		Mood:           params.Mood,
+		DeliveryMethod: params.DeliveryMethod,
+		Sort:           params.Sort,
+		Order:          strings.ToLower(params.Order),
+		Limit:          params.Limit,
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultCapsuleListLimit
+	}
+	if query.Limit < 1 || query.Limit > maxCapsuleListLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxCapsuleListLimit)
+	}
+
+	if query.Status != "" && !containsString(capsuleStatuses, query.Status) {
+		return nil, fmt.Errorf("invalid status, use one of: %s", strings.Join(capsuleStatuses, ", "))
+	}
+
+	if query.Sort == "" {
+		query.Sort = models.CapsuleSortDueDate
+	}
+	switch query.Sort {
+	case models.CapsuleSortDueDate, models.CapsuleSortCreatedAt, models.CapsuleSortTitle:
+	default:
+		return nil, errors.New("invalid sort, use one of: due_date, created_at, title")
+	}
+
+	if query.Order == "" {
+		query.Order = models.SortOrderAsc
+	}
+	if query.Order != models.SortOrderAsc && query.Order != models.SortOrderDesc {
+		return nil, errors.New("invalid order, use asc or desc")
+	}
+
+	// Rentang tanggal tanpa jam dibaca di timezone user
+	if params.DueFrom != "" || params.DueTo != "" {
+		location, err := s.userLocation(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.DueFrom != "" {
+			from, err := parseDueBound(params.DueFrom, location, false)
+			if err != nil {
+				return nil, errors.New("invalid due_from, use YYYY-MM-DD or RFC3339")
+			}
+			query.DueFrom = &from
+		}
+
+		if params.DueTo != "" {
+			before, err := parseDueBound(params.DueTo, location, true)
+			if err != nil {
+				return nil, errors.New("invalid due_to, use YYYY-MM-DD or RFC3339")
+			}
+			query.DueBefore = &before
+		}
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodeCapsuleCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		// Cursor hanya berlaku untuk urutan yang sama dengan halaman sebelumnya
+		if cursor.Sort != query.Sort || cursor.Order != query.Order {
+			return nil, errors.New("invalid cursor")
+		}
+		query.After = cursor
+	}
+
+	return query, nil
+}
+
+// parseDueBound membaca batas rentang due date.
+// Tanggal saja berarti awal hari itu, untuk batas atas berarti awal hari berikutnya agar tanggalnya ikut terhitung
+func parseDueBound(value string, location *time.Location, upper bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		if upper {
+			// Batas atas RFC3339 inklusif, repository memakai batas eksklusif
+			return t.Add(time.Second), nil
+		}
+		return t, nil
+	}
+
+	date, err := time.ParseInLocation(dueDateLayout, value, location)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if upper {
+		return date.AddDate(0, 0, 1), nil
+	}
+	return date, nil
+}
+
+// encodeCapsuleCursor membuat cursor dari capsule terakhir pada halaman
+func encodeCapsuleCursor(sort, order string, capsule *models.Capsule) (string, error) {
+	cursor := models.CapsuleCursor{
+		Sort:  sort,
+		Order: order,
+		ID:    capsule.ID,
+	}
+
+	switch sort {
+	case models.CapsuleSortTitle:
+		cursor.Value = capsule.Title
+	case models.CapsuleSortCreatedAt:
+		cursor.Value = capsule.CreatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		cursor.Value = capsule.DueDate.UTC().Format(time.RFC3339Nano)
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCapsuleCursor membaca cursor dari query string
+func decodeCapsuleCursor(value string) (*models.CapsuleCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var cursor models.CapsuleCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &cursor, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
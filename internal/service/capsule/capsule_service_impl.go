@@ -5,19 +5,41 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"future-letter/internal/clock"
 	"future-letter/internal/models"
 	repository "future-letter/internal/repository/capsule"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	userRepository "future-letter/internal/repository/user"
+	notifier "future-letter/internal/service/notifier"
 )
 
 type capsuleService struct {
-	capsuleRepo repository.CapsuleRepository
+	capsuleRepo  repository.CapsuleRepository
+	searchRepo   repository.CapsuleSearchRepository
+	userRepo     userRepository.UserRepository
+	deliveryRepo deliveryRepository.DeliveryRepository
+	notifiers    *notifier.Registry
+	clock        clock.Clock
 }
 
-func NewCapsuleService(capsuleRepo repository.CapsuleRepository) CapsuleService {
+func NewCapsuleService(
+	capsuleRepo repository.CapsuleRepository,
+	searchRepo repository.CapsuleSearchRepository,
+	userRepo userRepository.UserRepository,
+	deliveryRepo deliveryRepository.DeliveryRepository,
+	notifiers *notifier.Registry,
+	clock clock.Clock,
+) CapsuleService {
 	return &capsuleService{
-		capsuleRepo: capsuleRepo,
+		capsuleRepo:  capsuleRepo,
+		searchRepo:   searchRepo,
+		userRepo:     userRepo,
+		deliveryRepo: deliveryRepo,
+		notifiers:    notifiers,
+		clock:        clock,
 	}
 }
 
@@ -25,21 +47,43 @@ var defaultStatus = "pending"
 
 // CreateCapsule method untuk membuat capsule
 func (s *capsuleService) CreateCapsule(ctx context.Context, userID int, input *models.CreateCapsuleInput) (*models.Capsule, error) {
-	// Parse due date
-	dueDate, err := time.Parse("2006-01-02", input.DueDate)
+	// Due date dihitung dari timezone user lalu disimpan dalam UTC
+	location, err := s.userLocation(ctx, userID)
 	if err != nil {
-		return nil, errors.New("invalid format date, use YYYY-MM-DD")
+		return nil, err
 	}
 
-	now := time.Now()
+	capsule, err := s.buildCapsule(userID, input, location, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
 
-	// Jika due date == hari ini, set jadi beberapa menit ke depan agar valid
-	if dueDate.Format("2006-01-02") == now.Format("2006-01-02") {
-		dueDate = now.Add(10 * time.Minute)
+	// Save to database
+	err = s.capsuleRepo.Create(ctx, capsule)
+	if err != nil {
+		return nil, err
 	}
-	// Memastika due date di masa depan
-	if dueDate.Before(now) {
-		return nil, errors.New("due date must be in the future")
+
+	// Fetch full capsule
+	fullCapsule, err := s.capsuleRepo.GetByID(ctx, capsule.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return fullCapsule, nil
+}
+
+// buildCapsule memvalidasi input lalu menyusun capsule baru dengan status pending.
+// Dipakai oleh CreateCapsule dan import agar aturan validasinya sama
+func (s *capsuleService) buildCapsule(userID int, input *models.CreateCapsuleInput, location *time.Location, now time.Time) (*models.Capsule, error) {
+	// Delivery method harus salah satu channel yang terdaftar
+	if err := s.validateDeliveryMethod(input.DeliveryMethod); err != nil {
+		return nil, err
+	}
+
+	dueDate, err := resolveDueDate(input.DueDate, input.DueTime, location, now)
+	if err != nil {
+		return nil, err
 	}
 
 	// buat object capsule
@@ -63,19 +107,7 @@ func (s *capsuleService) CreateCapsule(ctx context.Context, userID int, input *m
 		capsule.ImageURL = sql.NullString{String: input.ImageURL, Valid: true}
 	}
 
-	// Save to database
-	err = s.capsuleRepo.Create(ctx, capsule)
-	if err != nil {
-		return nil, err
-	}
-
-	// Fetch full capsule
-	fullCapsule, err := s.capsuleRepo.GetByID(ctx, capsule.ID, userID)
-	if err != nil {
-		return nil, err
-	}
-
-	return fullCapsule, nil
+	return capsule, nil
 }
 
 // GetCapsule mengambil kapsule berdasarkan id
@@ -119,18 +151,28 @@ func (s *capsuleService) UpdateCapsule(ctx context.Context, capsuleID, userID in
 	}
 
 	if input.DeliveryMethod != "" {
+		if err := s.validateDeliveryMethod(input.DeliveryMethod); err != nil {
+			return nil, err
+		}
 		capsule.DeliveryMethod = input.DeliveryMethod
 	}
 
 	// Update due date jika di isi
-	if input.DueDate != "" {
-		dueDate, err := time.Parse("2006-01-02", input.DueDate)
+	if input.DueDate != "" || input.DueTime != "" {
+		location, err := s.userLocation(ctx, userID)
 		if err != nil {
-			return nil, errors.New("invalid date format, use YYYY-MM-DD")
+			return nil, err
 		}
 
-		if dueDate.Before(time.Now()) {
-			return nil, errors.New("due date must be in the future")
+		// Jika hanya jam yang diubah, gunakan tanggal lama (waktu lokal user)
+		date := input.DueDate
+		if date == "" {
+			date = capsule.DueDate.In(location).Format(dueDateLayout)
+		}
+
+		dueDate, err := resolveDueDate(date, input.DueTime, location, s.clock.Now())
+		if err != nil {
+			return nil, err
 		}
 		capsule.DueDate = dueDate
 	}
@@ -174,12 +216,73 @@ func (s *capsuleService) DeleteCapsule(ctx context.Context, capsuleID, userID in
 	return s.capsuleRepo.Delete(ctx, capsuleID, userID)
 }
 
+// validateDeliveryMethod mengecek delivery method terhadap channel yang terdaftar
+func (s *capsuleService) validateDeliveryMethod(method string) error {
+	if !s.notifiers.Has(method) {
+		return fmt.Errorf("unsupported delivery method, use one of: %s", strings.Join(s.notifiers.Channels(), ", "))
+	}
+
+	return nil
+}
+
+// userLocation mengambil timezone user yang membuat capsule
+func (s *capsuleService) userLocation(ctx context.Context, userID int) (*time.Location, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	return loadUserLocation(user.Timezone), nil
+}
+
 // Method ini akan digunakan oleh schedular
-func (s *capsuleService) GetPendingCapsulesForToday(ctx context.Context) ([]models.Capsule, error) {
-	return s.capsuleRepo.GetPendingForToday(ctx)
+// mengambil semua capsule pending yang sudah jatuh tempo sampai until
+func (s *capsuleService) GetDueCapsules(ctx context.Context, until time.Time) ([]models.Capsule, error) {
+	return s.capsuleRepo.GetDuePending(ctx, until)
+}
+
+// ClaimDueCapsules dipanggil scheduler untuk mengklaim capsule yang akan dikirim
+func (s *capsuleService) ClaimDueCapsules(ctx context.Context, now time.Time, limit int, claimToken string, leaseUntil time.Time) ([]models.Capsule, error) {
+	return s.capsuleRepo.ClaimDue(ctx, now, limit, claimToken, leaseUntil)
 }
 
-// Method ini dipanggil setelah email berhasil dikirim
-func (s *capsuleService) MarkCapsulesAsSent(ctx context.Context, capsuleID int) error {
-	return s.capsuleRepo.MarkAsSent(ctx, capsuleID)
+// Method ini dipanggil setelah capsule berhasil dikirim
+func (s *capsuleService) MarkCapsulesAsSent(ctx context.Context, capsuleID int, claimToken string, overdue bool, sentAt time.Time) error {
+	return s.capsuleRepo.MarkAsSent(ctx, capsuleID, claimToken, overdue, sentAt)
+}
+
+// ScheduleCapsuleRetry dipanggil scheduler saat pengiriman gagal dan masih bisa dicoba lagi
+func (s *capsuleService) ScheduleCapsuleRetry(ctx context.Context, capsuleID int, claimToken string, nextAttemptAt time.Time) error {
+	return s.capsuleRepo.ScheduleRetry(ctx, capsuleID, claimToken, nextAttemptAt)
+}
+
+// DeferCapsule dipanggil scheduler untuk menunda capsule yang belum boleh dikirim
+func (s *capsuleService) DeferCapsule(ctx context.Context, capsuleID int, claimToken string, nextAttemptAt time.Time) error {
+	return s.capsuleRepo.Defer(ctx, capsuleID, claimToken, nextAttemptAt)
+}
+
+// MarkCapsuleAsFailed dipanggil scheduler saat semua percobaan pengiriman habis
+func (s *capsuleService) MarkCapsuleAsFailed(ctx context.Context, capsuleID int, claimToken string) error {
+	return s.capsuleRepo.MarkAsFailed(ctx, capsuleID, claimToken)
+}
+
+// RecoverStaleClaims dipanggil scheduler untuk memulihkan klaim yang lease nya habis
+func (s *capsuleService) RecoverStaleClaims(ctx context.Context, now time.Time, maxAttempts int) (int, int, int, error) {
+	return s.capsuleRepo.RecoverStaleClaims(ctx, now, maxAttempts)
+}
+
+// GetDeliveryHistory mengambil riwayat pengiriman capsule milik user
+func (s *capsuleService) GetDeliveryHistory(ctx context.Context, capsuleID, userID int) ([]models.DeliveryAttempt, error) {
+	// Pastikan capsule milik user
+	_, err := s.capsuleRepo.GetByID(ctx, capsuleID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.deliveryRepo.GetByCapsuleID(ctx, capsuleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery history: %v", err)
+	}
+
+	return attempts, nil
 }
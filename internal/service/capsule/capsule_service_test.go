@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"future-letter/internal/models"
+	repository "future-letter/internal/repository/capsule"
+	userRepository "future-letter/internal/repository/user"
+	notifier "future-letter/internal/service/notifier"
+)
+
+// testNow 2030-01-15 17:00 WIB
+var testNow = time.Date(2030, 1, 15, 10, 0, 0, 0, time.UTC)
+
+const testUserID = 7
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// fakeCapsuleRepository menyimpan capsule di slice, method yang tidak dipakai test akan panic
+type fakeCapsuleRepository struct {
+	repository.CapsuleRepository
+
+	capsules []models.Capsule
+	batches  int
+	batchErr error
+}
+
+func (r *fakeCapsuleRepository) GetByUserID(ctx context.Context, userID int) ([]models.Capsule, error) {
+	capsules := []models.Capsule{}
+	for _, capsule := range r.capsules {
+		if capsule.UserID == userID {
+			capsules = append(capsules, capsule)
+		}
+	}
+	return capsules, nil
+}
+
+func (r *fakeCapsuleRepository) CreateBatch(ctx context.Context, capsules []*models.Capsule) error {
+	r.batches++
+	if r.batchErr != nil {
+		return r.batchErr
+	}
+
+	for _, capsule := range capsules {
+		capsule.ID = len(r.capsules) + 1
+		r.capsules = append(r.capsules, *capsule)
+	}
+	return nil
+}
+
+type fakeUserRepository struct {
+	userRepository.UserRepository
+
+	user *models.User
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, errors.New("user not found")
+	}
+	return r.user, nil
+}
+
+type stubNotifier struct {
+	channel string
+}
+
+func (n stubNotifier) Channel() string {
+	return n.channel
+}
+
+func (n stubNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	return nil
+}
+
+// newTestCapsuleService service dengan user Asia/Jakarta, channel email, dan pencarian memory
+func newTestCapsuleService(capsuleRepo *fakeCapsuleRepository) *capsuleService {
+	return &capsuleService{
+		capsuleRepo: capsuleRepo,
+		searchRepo:  repository.NewMemoryCapsuleSearchRepository(capsuleRepo),
+		userRepo:    &fakeUserRepository{user: &models.User{ID: testUserID, Timezone: "Asia/Jakarta"}},
+		notifiers:   notifier.NewRegistry(stubNotifier{channel: "email"}),
+		clock:       fixedClock{now: testNow},
+	}
+}
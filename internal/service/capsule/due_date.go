@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	dueDateLayout     = "2006-01-02"
+	dueDateTimeLayout = "2006-01-02 15:04"
+	dueTimeLayout     = "15:04"
+
+	// defaultDeliveryTime jam pengiriman (waktu lokal user) jika user tidak mengisi jam
+	defaultDeliveryTime = "08:00"
+)
+
+// resolveDueDate menghitung waktu pengiriman capsule dalam UTC
+// dueDate bisa "YYYY-MM-DD" atau "YYYY-MM-DD HH:MM", dueTime opsional "HH:MM"
+// keduanya dibaca sebagai waktu lokal di timezone user
+func resolveDueDate(dueDate, dueTime string, location *time.Location, now time.Time) (time.Time, error) {
+	// Format lengkap tanggal + jam
+	if due, err := time.ParseInLocation(dueDateTimeLayout, dueDate, location); err == nil {
+		return validateDueDate(due, now)
+	}
+
+	date, err := time.ParseInLocation(dueDateLayout, dueDate, location)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date format, use YYYY-MM-DD or YYYY-MM-DD HH:MM")
+	}
+
+	explicitTime := dueTime != ""
+	if !explicitTime {
+		dueTime = defaultDeliveryTime
+	}
+
+	clock, err := time.Parse(dueTimeLayout, dueTime)
+	if err != nil {
+		return time.Time{}, errors.New("invalid time format, use HH:MM")
+	}
+
+	due := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+
+	// Jika hanya tanggal hari ini yang di isi dan jam default sudah lewat,
+	// set jadi beberapa menit ke depan agar tetap terkirim hari ini
+	localNow := now.In(location)
+	if !explicitTime && date.Format(dueDateLayout) == localNow.Format(dueDateLayout) && !due.After(localNow) {
+		due = localNow.Add(10 * time.Minute)
+	}
+
+	return validateDueDate(due, now)
+}
+
+// validateDueDate memastikan due date di masa depan dan mengembalikannya dalam UTC
+func validateDueDate(due, now time.Time) (time.Time, error) {
+	if !due.After(now) {
+		return time.Time{}, errors.New("due date must be in the future")
+	}
+
+	return due.UTC().Truncate(time.Second), nil
+}
+
+// loadUserLocation membaca timezone user, fallback ke UTC jika tidak valid
+func loadUserLocation(timezone string) *time.Location {
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		return time.UTC
+	}
+
+	return location
+}
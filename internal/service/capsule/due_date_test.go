@@ -0,0 +1,138 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s not available: %v", name, err)
+	}
+	return location
+}
+
+func TestResolveDueDate(t *testing.T) {
+	jakarta := mustLoadLocation(t, "Asia/Jakarta")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	// 2030-03-10 10:00 WIB
+	now := time.Date(2030, 3, 10, 3, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		dueDate  string
+		dueTime  string
+		location *time.Location
+		now      time.Time
+		want     time.Time
+		wantErr  string
+	}{
+		{
+			name:     "date only uses 08:00 local time",
+			dueDate:  "2030-04-01",
+			location: jakarta,
+			now:      now,
+			want:     time.Date(2030, 4, 1, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "date with separate time",
+			dueDate:  "2030-04-01",
+			dueTime:  "21:15",
+			location: jakarta,
+			now:      now,
+			want:     time.Date(2030, 4, 1, 14, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "datetime format",
+			dueDate:  "2030-04-01 06:30",
+			location: jakarta,
+			now:      now,
+			want:     time.Date(2030, 3, 31, 23, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "today after default hour is moved a few minutes ahead",
+			dueDate:  "2030-03-10",
+			location: jakarta,
+			now:      now,
+			want:     now.Add(10 * time.Minute),
+		},
+		{
+			name:     "day before DST starts uses standard offset",
+			dueDate:  "2030-03-09",
+			location: newYork,
+			now:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2030, 3, 9, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "DST start day uses daylight offset",
+			dueDate:  "2030-03-10",
+			location: newYork,
+			now:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2030, 3, 10, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "DST end day uses standard offset",
+			dueDate:  "2030-11-03 09:00",
+			location: newYork,
+			now:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			want:     time.Date(2030, 11, 3, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "past date",
+			dueDate:  "2030-03-01",
+			location: jakarta,
+			now:      now,
+			wantErr:  "due date must be in the future",
+		},
+		{
+			name:     "today with explicit past time",
+			dueDate:  "2030-03-10",
+			dueTime:  "08:00",
+			location: jakarta,
+			now:      now,
+			wantErr:  "due date must be in the future",
+		},
+		{
+			name:     "invalid date",
+			dueDate:  "01/04/2030",
+			location: jakarta,
+			now:      now,
+			wantErr:  "invalid date format, use YYYY-MM-DD or YYYY-MM-DD HH:MM",
+		},
+		{
+			name:     "invalid time",
+			dueDate:  "2030-04-01",
+			dueTime:  "9pm",
+			location: jakarta,
+			now:      now,
+			wantErr:  "invalid time format, use HH:MM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDueDate(tt.dueDate, tt.dueTime, tt.location, tt.now)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("resolveDueDate() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("resolveDueDate() unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("resolveDueDate() = %s, want %s", got, tt.want)
+			}
+			if got.Location() != time.UTC {
+				t.Fatalf("resolveDueDate() location = %s, want UTC", got.Location())
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// maxImportRows jumlah maksimal capsule dalam satu kali import (semua file)
+const maxImportRows = 1000
+
+// ImportCapsules memvalidasi semua baris dari file yang diupload dengan aturan yang sama seperti CreateCapsule,
+// lalu menyimpan semuanya dalam satu transaksi. Jika ada satu baris tidak valid, tidak ada yang disimpan.
+// Dry run hanya memvalidasi dan mengembalikan error per baris
+func (s *capsuleService) ImportCapsules(ctx context.Context, userID int, files []models.CapsuleImportFile, dryRun bool) (*models.CapsuleImportResult, error) {
+	location, err := s.userLocation(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+
+	result := &models.CapsuleImportResult{
+		DryRun: dryRun,
+		Errors: []models.CapsuleImportRowError{},
+	}
+	capsules := []*models.Capsule{}
+
+	for _, file := range files {
+		rows, err := parseCapsuleFile(file)
+		if err != nil {
+			// File yang tidak bisa dibaca dicatat sebagai error baris 0
+			result.Errors = append(result.Errors, models.CapsuleImportRowError{
+				File:   file.Name,
+				Errors: []string{err.Error()},
+			})
+			continue
+		}
+
+		result.Total += len(rows)
+		if result.Total > maxImportRows {
+			return nil, fmt.Errorf("too many capsules, maximum is %d per import", maxImportRows)
+		}
+
+		for i := range rows {
+			row := &rows[i]
+
+			if errs := s.validateImportRow(row, location, now); len(errs) > 0 {
+				result.Errors = append(result.Errors, models.CapsuleImportRowError{
+					File:   file.Name,
+					Row:    i + 1,
+					Title:  row.Title,
+					Errors: errs,
+				})
+				continue
+			}
+
+			capsule, err := s.buildCapsule(userID, row, location, now)
+			if err != nil {
+				return nil, err
+			}
+			capsules = append(capsules, capsule)
+		}
+	}
+
+	result.Valid = len(capsules)
+
+	if result.Total == 0 && len(result.Errors) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+
+	if len(result.Errors) > 0 {
+		if dryRun {
+			return result, nil
+		}
+		return result, errors.New("import has invalid rows")
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	if err := s.capsuleRepo.CreateBatch(ctx, capsules); err != nil {
+		return nil, err
+	}
+
+	result.Imported = len(capsules)
+	result.CapsuleIDs = make([]int, 0, len(capsules))
+	for _, capsule := range capsules {
+		result.CapsuleIDs = append(result.CapsuleIDs, capsule.ID)
+	}
+
+	return result, nil
+}
+
+// parseCapsuleFile menentukan format lalu membaca semua baris dari satu file
+func parseCapsuleFile(file models.CapsuleImportFile) ([]models.CreateCapsuleInput, error) {
+	format, err := detectImportFormat(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseImportFile(format, file.Content)
+}
+
+// validateImportRow mengumpulkan semua error validasi satu baris.
+// Field wajib sama dengan tag binding pada CreateCapsuleInput, sisanya sama dengan buildCapsule
+func (s *capsuleService) validateImportRow(input *models.CreateCapsuleInput, location *time.Location, now time.Time) []string {
+	errs := []string{}
+
+	required := []struct {
+		field string
+		value string
+	}{
+		{"title", input.Title},
+		{"message", input.Message},
+		{"due_date", input.DueDate},
+		{"delivery_method", input.DeliveryMethod},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			errs = append(errs, r.field+" is required")
+		}
+	}
+
+	if input.DeliveryMethod != "" {
+		if err := s.validateDeliveryMethod(input.DeliveryMethod); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if input.DueDate != "" {
+		if _, err := resolveDueDate(input.DueDate, input.DueTime, location, now); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"future-letter/internal/models"
+)
+
+// utf8BOM ditambahkan sebagian aplikasi spreadsheet di awal file CSV
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectImportFormat menentukan format file dari input user atau ekstensi file
+func detectImportFormat(file models.CapsuleImportFile) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(file.Format))
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file.Name)) {
+		case ".json":
+			format = models.ImportFormatJSON
+		case ".csv":
+			format = models.ImportFormatCSV
+		case ".md", ".markdown":
+			format = models.ImportFormatMarkdown
+		}
+	}
+
+	switch format {
+	case models.ImportFormatJSON, models.ImportFormatCSV, models.ImportFormatMarkdown:
+		return format, nil
+	case "md":
+		return models.ImportFormatMarkdown, nil
+	}
+
+	return "", errors.New("unsupported import format, use json, csv or markdown")
+}
+
+// parseImportFile membaca semua baris capsule dari satu file
+func parseImportFile(format string, content []byte) ([]models.CreateCapsuleInput, error) {
+	content = bytes.TrimPrefix(content, utf8BOM)
+
+	switch format {
+	case models.ImportFormatJSON:
+		return parseJSONImport(content)
+	case models.ImportFormatCSV:
+		return parseCSVImport(content)
+	default:
+		return parseMarkdownImport(content)
+	}
+}
+
+// parseJSONImport membaca array JSON dengan field yang sama seperti body POST /capsules
+func parseJSONImport(content []byte) ([]models.CreateCapsuleInput, error) {
+	var rows []models.CreateCapsuleInput
+	if err := json.Unmarshal(content, &rows); err != nil {
+		return nil, fmt.Errorf("invalid json, expected an array of capsules: %v", err)
+	}
+
+	return rows, nil
+}
+
+// parseCSVImport membaca CSV dengan baris header, nama kolom sama dengan field JSON (title, message, due_date, ...)
+func parseCSVImport(content []byte) ([]models.CreateCapsuleInput, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("invalid csv, header row is required")
+		}
+		return nil, fmt.Errorf("invalid csv: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("invalid csv, header row must contain at least title, message, due_date and delivery_method")
+	}
+
+	rows := []models.CreateCapsuleInput{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		rows = append(rows, models.CreateCapsuleInput{
+			Title:          value("title"),
+			Message:        value("message"),
+			DueDate:        value("due_date"),
+			DueTime:        value("due_time"),
+			DeliveryMethod: value("delivery_method"),
+			Category:       value("category"),
+			Mood:           value("mood"),
+			ImageURL:       value("image_url"),
+		})
+	}
+
+	return rows, nil
+}
+
+// frontMatterLine baris "key: value" di dalam YAML front matter
+var frontMatterLine = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*:\s*(.*)$`)
+
+// parseMarkdownImport membaca satu atau lebih capsule Markdown.
+// Setiap capsule diawali YAML front matter (di antara dua baris "---"), isi setelahnya menjadi message
+func parseMarkdownImport(content []byte) ([]models.CreateCapsuleInput, error) {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	rows := []models.CreateCapsuleInput{}
+	var current *models.CreateCapsuleInput
+	var body []string
+
+	flush := func() {
+		if current != nil {
+			current.Message = strings.TrimSpace(strings.Join(body, "\n"))
+			rows = append(rows, *current)
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i]) == "---" {
+			if end, ok := frontMatterEnd(lines, i); ok {
+				flush()
+
+				current = &models.CreateCapsuleInput{}
+				if err := applyFrontMatter(current, lines[i+1:end]); err != nil {
+					return nil, err
+				}
+				body = nil
+
+				i = end + 1
+				continue
+			}
+		}
+
+		if current == nil {
+			if strings.TrimSpace(lines[i]) != "" {
+				return nil, errors.New("invalid markdown, each capsule must start with a front matter block")
+			}
+		} else {
+			body = append(body, lines[i])
+		}
+		i++
+	}
+
+	flush()
+
+	return rows, nil
+}
+
+// frontMatterEnd mencari baris "---" penutup front matter yang dimulai di baris start.
+// Garis "---" biasa di dalam isi Markdown tidak dianggap front matter karena isinya bukan "key: value"
+func frontMatterEnd(lines []string, start int) (int, bool) {
+	keys := 0
+
+	for j := start + 1; j < len(lines); j++ {
+		line := strings.TrimSpace(lines[j])
+
+		switch {
+		case line == "---":
+			return j, keys > 0
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case frontMatterLine.MatchString(line):
+			keys++
+		default:
+			return 0, false
+		}
+	}
+
+	return 0, false
+}
+
+// applyFrontMatter mengisi input dari baris front matter, key yang tidak dikenal diabaikan
+func applyFrontMatter(input *models.CreateCapsuleInput, lines []string) error {
+	for _, line := range lines {
+		match := frontMatterLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		value, err := frontMatterValue(match[2])
+		if err != nil {
+			return fmt.Errorf("invalid markdown front matter %q: %v", match[1], err)
+		}
+
+		switch strings.ToLower(match[1]) {
+		case "title":
+			input.Title = value
+		case "due_date":
+			input.DueDate = value
+		case "due_time":
+			input.DueTime = value
+		case "delivery_method":
+			input.DeliveryMethod = value
+		case "category":
+			input.Category = value
+		case "mood":
+			input.Mood = value
+		case "image_url":
+			input.ImageURL = value
+		}
+	}
+
+	return nil
+}
+
+// frontMatterValue membaca nilai skalar YAML: tanpa kutip, kutip dua atau kutip satu
+func frontMatterValue(raw string) (string, error) {
+	value := strings.TrimSpace(raw)
+
+	switch {
+	case strings.HasPrefix(value, `"`):
+		return strconv.Unquote(value)
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", errors.New("unterminated quoted value")
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+
+	// Komentar di akhir baris untuk nilai tanpa kutip
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+
+	return value, nil
+}
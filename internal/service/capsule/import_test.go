@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		file    models.CapsuleImportFile
+		want    string
+		wantErr bool
+	}{
+		{file: models.CapsuleImportFile{Name: "letters.json"}, want: models.ImportFormatJSON},
+		{file: models.CapsuleImportFile{Name: "letters.CSV"}, want: models.ImportFormatCSV},
+		{file: models.CapsuleImportFile{Name: "letter.markdown"}, want: models.ImportFormatMarkdown},
+		{file: models.CapsuleImportFile{Name: "letters.txt", Format: "md"}, want: models.ImportFormatMarkdown},
+		{file: models.CapsuleImportFile{Name: "letters.json", Format: "csv"}, want: models.ImportFormatCSV},
+		{file: models.CapsuleImportFile{Name: "letters.txt"}, wantErr: true},
+		{file: models.CapsuleImportFile{Name: "letters.json", Format: "xml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file.Name+"/"+tt.file.Format, func(t *testing.T) {
+			got, err := detectImportFormat(tt.file)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("detectImportFormat = %q, want error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("detectImportFormat = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []models.CreateCapsuleInput
+		wantErr string
+	}{
+		{
+			name:    "json array",
+			format:  models.ImportFormatJSON,
+			content: `[{"title":"Halo","message":"Isi","due_date":"2030-06-01","delivery_method":"email","mood":"happy"}]`,
+			want: []models.CreateCapsuleInput{
+				{Title: "Halo", Message: "Isi", DueDate: "2030-06-01", DeliveryMethod: "email", Mood: "happy"},
+			},
+		},
+		{
+			name:    "json object is rejected",
+			format:  models.ImportFormatJSON,
+			content: `{"title":"Halo"}`,
+			wantErr: "invalid json, expected an array of capsules",
+		},
+		{
+			name:   "csv quoted fields",
+			format: models.ImportFormatCSV,
+			content: "title,message,due_date,delivery_method\n" +
+				"\"Halo, aku\",\"Baris satu\nBaris \"\"dua\"\"\",2030-06-01,email\n",
+			want: []models.CreateCapsuleInput{
+				{Title: "Halo, aku", Message: "Baris satu\nBaris \"dua\"", DueDate: "2030-06-01", DeliveryMethod: "email"},
+			},
+		},
+		{
+			name:    "csv with bom and reordered columns",
+			format:  models.ImportFormatCSV,
+			content: "\xEF\xBB\xBFdelivery_method, Title ,message,due_date,due_time\nemail,Halo,Isi,2030-06-01,09:30\n",
+			want: []models.CreateCapsuleInput{
+				{Title: "Halo", Message: "Isi", DueDate: "2030-06-01", DueTime: "09:30", DeliveryMethod: "email"},
+			},
+		},
+		{
+			name:    "csv short row",
+			format:  models.ImportFormatCSV,
+			content: "title,message,due_date,delivery_method\nHalo,Isi\n",
+			wantErr: "invalid csv",
+		},
+		{
+			name:    "csv without title column",
+			format:  models.ImportFormatCSV,
+			content: "message,due_date\nIsi,2030-06-01\n",
+			wantErr: "header row must contain",
+		},
+		{
+			name:    "csv empty",
+			format:  models.ImportFormatCSV,
+			content: "",
+			wantErr: "header row is required",
+		},
+		{
+			name:   "markdown two capsules",
+			format: models.ImportFormatMarkdown,
+			content: "---\ntitle: \"Surat: pertama\"\ndue_date: 2030-06-01 # komentar\ndelivery_method: email\n---\nIsi pertama\n\n" +
+				"---\ntitle: 'It''s me'\ndue_date: 2030-07-01\ndelivery_method: email\n---\nIsi kedua\n---\nmasih isi kedua\n",
+			want: []models.CreateCapsuleInput{
+				{Title: "Surat: pertama", Message: "Isi pertama", DueDate: "2030-06-01", DeliveryMethod: "email"},
+				{Title: "It's me", Message: "Isi kedua\n---\nmasih isi kedua", DueDate: "2030-07-01", DeliveryMethod: "email"},
+			},
+		},
+		{
+			name:    "markdown crlf and bom",
+			format:  models.ImportFormatMarkdown,
+			content: "\xEF\xBB\xBF---\r\ntitle: Halo\r\ndue_date: 2030-06-01\r\ndelivery_method: email\r\n---\r\nIsi\r\n",
+			want: []models.CreateCapsuleInput{
+				{Title: "Halo", Message: "Isi", DueDate: "2030-06-01", DeliveryMethod: "email"},
+			},
+		},
+		{
+			name:    "markdown missing closing delimiter",
+			format:  models.ImportFormatMarkdown,
+			content: "---\ntitle: Halo\ndue_date: 2030-06-01\nIsi tanpa penutup\n",
+			wantErr: "each capsule must start with a front matter block",
+		},
+		{
+			name:    "markdown without front matter",
+			format:  models.ImportFormatMarkdown,
+			content: "# Judul\nIsi saja\n",
+			wantErr: "each capsule must start with a front matter block",
+		},
+		{
+			name:    "markdown unterminated quote",
+			format:  models.ImportFormatMarkdown,
+			content: "---\ntitle: 'Halo\n---\nIsi\n",
+			wantErr: "unterminated quoted value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportFile(tt.format, []byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseImportFile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImportFile: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseImportFile =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// csvImport membuat file CSV dengan n baris valid
+func csvImport(name string, n int) models.CapsuleImportFile {
+	var b strings.Builder
+	b.WriteString("title,message,due_date,delivery_method\n")
+	for i := range n {
+		fmt.Fprintf(&b, "Surat %d,Isi %d,2030-06-01,email\n", i+1, i+1)
+	}
+	return models.CapsuleImportFile{Name: name, Content: []byte(b.String())}
+}
+
+func TestImportCapsulesRowLimit(t *testing.T) {
+	repo := &fakeCapsuleRepository{}
+	s := newTestCapsuleService(repo)
+
+	result, err := s.ImportCapsules(context.Background(), testUserID, []models.CapsuleImportFile{csvImport("a.csv", maxImportRows)}, true)
+	if err != nil || result.Valid != maxImportRows {
+		t.Fatalf("import of %d rows = %+v, %v", maxImportRows, result, err)
+	}
+
+	// Batas dihitung dari semua file, bukan per file
+	files := []models.CapsuleImportFile{csvImport("a.csv", maxImportRows-1), csvImport("b.csv", 2)}
+	_, err = s.ImportCapsules(context.Background(), testUserID, files, false)
+	if err == nil || !strings.Contains(err.Error(), "too many capsules") {
+		t.Fatalf("import over limit error = %v, want too many capsules", err)
+	}
+	if repo.batches != 0 {
+		t.Fatalf("CreateBatch called %d times, want 0", repo.batches)
+	}
+}
+
+func TestImportCapsulesDryRun(t *testing.T) {
+	repo := &fakeCapsuleRepository{}
+	s := newTestCapsuleService(repo)
+
+	result, err := s.ImportCapsules(context.Background(), testUserID, []models.CapsuleImportFile{csvImport("a.csv", 3)}, true)
+	if err != nil {
+		t.Fatalf("ImportCapsules: %v", err)
+	}
+
+	if !result.DryRun || result.Total != 3 || result.Valid != 3 || result.Imported != 0 || len(result.CapsuleIDs) != 0 {
+		t.Fatalf("result = %+v, want 3 valid and nothing imported", result)
+	}
+	if repo.batches != 0 || len(repo.capsules) != 0 {
+		t.Fatalf("dry run touched the repository: %d batches, %d capsules", repo.batches, len(repo.capsules))
+	}
+}
+
+func TestImportCapsulesInvalidRows(t *testing.T) {
+	content := "title,message,due_date,delivery_method\n" +
+		"Valid,Isi,2030-06-01,email\n" +
+		",Isi,2030-06-01,pigeon\n" +
+		"Lampau,Isi,2020-01-01,email\n"
+	files := []models.CapsuleImportFile{
+		{Name: "a.csv", Content: []byte(content)},
+		{Name: "b.txt", Content: []byte("x")},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		t.Run(fmt.Sprintf("dry_run=%v", dryRun), func(t *testing.T) {
+			repo := &fakeCapsuleRepository{}
+			s := newTestCapsuleService(repo)
+
+			result, err := s.ImportCapsules(context.Background(), testUserID, files, dryRun)
+			if dryRun && err != nil {
+				t.Fatalf("dry run error = %v, want nil", err)
+			}
+			if !dryRun && (err == nil || err.Error() != "import has invalid rows") {
+				t.Fatalf("error = %v, want import has invalid rows", err)
+			}
+
+			if result.Total != 3 || result.Valid != 1 || len(result.Errors) != 3 {
+				t.Fatalf("result = %+v, want 3 total, 1 valid, 3 errors", result)
+			}
+
+			rows := []int{}
+			for _, rowErr := range result.Errors {
+				rows = append(rows, rowErr.Row)
+			}
+			// Baris 2 dan 3 dari a.csv, lalu b.txt yang formatnya tidak dikenal sebagai baris 0
+			if !reflect.DeepEqual(rows, []int{2, 3, 0}) {
+				t.Fatalf("error rows = %v, want [2 3 0]", rows)
+			}
+
+			// Semua atau tidak sama sekali
+			if repo.batches != 0 {
+				t.Fatalf("CreateBatch called %d times, want 0", repo.batches)
+			}
+		})
+	}
+}
+
+func TestImportCapsulesSavesAllRows(t *testing.T) {
+	repo := &fakeCapsuleRepository{}
+	s := newTestCapsuleService(repo)
+
+	files := []models.CapsuleImportFile{
+		csvImport("a.csv", 2),
+		{Name: "b.md", Content: []byte("---\ntitle: Halo\ndue_date: 2030-06-01\ndue_time: \"21:00\"\ndelivery_method: email\n---\nIsi\n")},
+	}
+
+	result, err := s.ImportCapsules(context.Background(), testUserID, files, false)
+	if err != nil {
+		t.Fatalf("ImportCapsules: %v", err)
+	}
+
+	if result.Imported != 3 || !reflect.DeepEqual(result.CapsuleIDs, []int{1, 2, 3}) {
+		t.Fatalf("result = %+v, want 3 imported", result)
+	}
+	if repo.batches != 1 {
+		t.Fatalf("CreateBatch called %d times, want 1", repo.batches)
+	}
+
+	// Jam default 08:00 dan jam eksplisit dihitung dari timezone user (WIB, UTC+7)
+	if want := time.Date(2030, 6, 1, 1, 0, 0, 0, time.UTC); !repo.capsules[0].DueDate.Equal(want) {
+		t.Errorf("due date = %v, want %v", repo.capsules[0].DueDate, want)
+	}
+	if want := time.Date(2030, 6, 1, 14, 0, 0, 0, time.UTC); !repo.capsules[2].DueDate.Equal(want) {
+		t.Errorf("due date = %v, want %v", repo.capsules[2].DueDate, want)
+	}
+}
+
+func TestImportCapsulesEmpty(t *testing.T) {
+	s := newTestCapsuleService(&fakeCapsuleRepository{})
+
+	files := []models.CapsuleImportFile{{Name: "a.json", Content: []byte("[]")}}
+	if _, err := s.ImportCapsules(context.Background(), testUserID, files, false); err == nil || err.Error() != "import file is empty" {
+		t.Fatalf("error = %v, want import file is empty", err)
+	}
+}
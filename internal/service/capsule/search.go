@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"future-letter/internal/models"
+)
+
+const (
+	// minSearchTermLength sama dengan innodb_ft_min_token_size default, kata lebih pendek tidak diindex MySQL
+	minSearchTermLength = 3
+	maxSearchTerms      = 10
+	maxSearchQueryLen   = 200
+)
+
+// SearchCapsules mencari capsule user berdasarkan judul dan isi pesan.
+// Hasil diurutkan dari yang paling relevan, kata yang cocok ditandai di judul dan potongan pesan
+func (s *capsuleService) SearchCapsules(ctx context.Context, userID int, query string, limit, offset int) (*models.CapsuleSearchResponse, error) {
+	query = strings.TrimSpace(query)
+
+	terms, err := searchTerms(query)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, total, err := s.searchRepo.Search(ctx, models.CapsuleSearchQuery{
+		UserID: userID,
+		Terms:  terms,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.CapsuleSearchResponse{
+		Query:   query,
+		Results: make([]models.CapsuleSearchResult, 0, len(hits)),
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}
+
+	for i := range hits {
+		capsule := &hits[i].Capsule
+
+		response.Results = append(response.Results, models.CapsuleSearchResult{
+			CapsuleResponse: capsule.ToResponse(),
+			Score:           hits[i].Score,
+			Highlights: models.CapsuleSearchHighlights{
+				Title:   highlightText(capsule.Title, terms),
+				Snippet: snippetText(capsule.Message, terms),
+			},
+		})
+	}
+
+	return response, nil
+}
+
+// searchTerms mengambil kata yang bisa dicari dari query, tanpa duplikat
+func searchTerms(query string) ([]string, error) {
+	if query == "" {
+		return nil, errors.New("search query is required")
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLen {
+		return nil, errors.New("search query is too long")
+	}
+
+	terms := []string{}
+	seen := make(map[string]bool)
+	for _, token := range models.SearchTokens(query) {
+		if utf8.RuneCountInString(token) < minSearchTermLength || seen[token] {
+			continue
+		}
+		seen[token] = true
+		terms = append(terms, token)
+
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil, errors.New("search query must contain a word of at least 3 characters")
+	}
+
+	return terms, nil
+}
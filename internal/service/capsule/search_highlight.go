@@ -0,0 +1,132 @@
+package service
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetContext jumlah karakter sebelum kata pertama yang cocok
+	snippetContext = 60
+	// snippetLength panjang maksimal potongan pesan
+	snippetLength   = 200
+	snippetEllipsis = "…"
+)
+
+// wordSpan posisi satu kata dalam slice rune [start, end)
+type wordSpan struct {
+	start, end int
+}
+
+// wordSpans mencari posisi semua kata, aturannya sama dengan models.SearchTokens
+func wordSpans(text []rune) []wordSpan {
+	spans := []wordSpan{}
+	start := -1
+
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, wordSpan{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start: start, end: len(text)})
+	}
+
+	return spans
+}
+
+func isSearchTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if word == term {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightText membungkus kata yang cocok dengan <mark> dan meng-escape teks sisanya
+func highlightText(text string, terms []string) string {
+	runes := []rune(text)
+	return markRange(runes, wordSpans(runes), 0, len(runes), terms)
+}
+
+// snippetText mengambil potongan pesan di sekitar kata pertama yang cocok.
+// Jika kata hanya cocok di judul, potongan diambil dari awal pesan
+func snippetText(message string, terms []string) string {
+	runes := []rune(message)
+	spans := wordSpans(runes)
+
+	from := 0
+	for _, span := range spans {
+		if isSearchTerm(string(runes[span.start:span.end]), terms) {
+			from = max(0, span.start-snippetContext)
+			break
+		}
+	}
+	to := min(len(runes), from+snippetLength)
+
+	// Jangan memotong di tengah kata
+	if from > 0 {
+		for _, span := range spans {
+			if span.start >= from {
+				from = span.start
+				break
+			}
+		}
+	}
+	if to < len(runes) {
+		for i := len(spans) - 1; i >= 0; i-- {
+			if spans[i].end <= to && spans[i].start >= from {
+				to = spans[i].end
+				break
+			}
+		}
+	}
+
+	snippet := markRange(runes, spans, from, to, terms)
+
+	// Baris baru dan spasi berlebih dirapikan agar potongan tampil dalam satu baris
+	snippet = strings.Join(strings.Fields(snippet), " ")
+
+	if from > 0 {
+		snippet = snippetEllipsis + snippet
+	}
+	if to < len(runes) {
+		snippet += snippetEllipsis
+	}
+
+	return snippet
+}
+
+// markRange menulis runes[from:to] dengan kata yang cocok dibungkus <mark>
+func markRange(runes []rune, spans []wordSpan, from, to int, terms []string) string {
+	var b strings.Builder
+
+	last := from
+	for _, span := range spans {
+		if span.start < from || span.end > to {
+			continue
+		}
+
+		word := string(runes[span.start:span.end])
+		if !isSearchTerm(word, terms) {
+			continue
+		}
+
+		b.WriteString(html.EscapeString(string(runes[last:span.start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(word))
+		b.WriteString("</mark>")
+		last = span.end
+	}
+	b.WriteString(html.EscapeString(string(runes[last:to])))
+
+	return b.String()
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"future-letter/internal/models"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", query: "", wantErr: "search query is required"},
+		{name: "only short words", query: "ke di ya", wantErr: "search query must contain a word of at least 3 characters"},
+		{name: "only punctuation", query: "!!! ---", wantErr: "search query must contain a word of at least 3 characters"},
+		{name: "too long", query: strings.Repeat("a", maxSearchQueryLen+1), wantErr: "search query is too long"},
+		{name: "short words dropped", query: "liburan ke Bali", want: []string{"liburan", "bali"}},
+		{name: "duplicates removed", query: "Surat surat SURAT untuk", want: []string{"surat", "untuk"}},
+		{name: "punctuation splits words", query: "liburan!!! (bali)/lombok", want: []string{"liburan", "bali", "lombok"}},
+		{name: "multibyte length counted in runes", query: strings.Repeat("é", maxSearchQueryLen), want: []string{strings.Repeat("é", maxSearchQueryLen)}},
+		{name: "multibyte short word", query: "日本 東京都", want: []string{"東京都"}},
+		{
+			name:  "at most maxSearchTerms",
+			query: "satu dua tiga empat lima enam tujuh delapan sembilan sepuluh sebelas",
+			want:  []string{"satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := searchTerms(tt.query)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("searchTerms error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("searchTerms: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("searchTerms = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newSearchService service dengan capsule yang skornya berbeda untuk query "liburan bali"
+func newSearchService() *capsuleService {
+	repo := &fakeCapsuleRepository{capsules: []models.Capsule{
+		// skor 4: dua kata di judul
+		{ID: 1, UserID: testUserID, Title: "Liburan ke Bali", Message: "Jangan lupa sunscreen"},
+		// skor 2: dua kata di pesan
+		{ID: 2, UserID: testUserID, Title: "Catatan", Message: "Liburan ke Bali tahun depan"},
+		// tidak cocok
+		{ID: 3, UserID: testUserID, Title: "Kerja", Message: "Rapat setiap senin"},
+		// skor 3: judul dan pesan
+		{ID: 4, UserID: testUserID, Title: "Liburan", Message: "liburan keluarga"},
+		// skor 1
+		{ID: 5, UserID: testUserID, Title: "Rumah", Message: "Pindah ke Bali"},
+		// skor 2, sama dengan ID 2 sehingga yang lebih baru (ID lebih besar) di depan
+		{ID: 6, UserID: testUserID, Title: "Rencana", Message: "LIBURAN, liburan!"},
+		// capsule user lain tidak boleh ikut
+		{ID: 7, UserID: testUserID + 1, Title: "Liburan Bali", Message: "liburan bali"},
+	}}
+
+	return newTestCapsuleService(repo)
+}
+
+func searchIDs(response *models.CapsuleSearchResponse) []int {
+	ids := []int{}
+	for _, result := range response.Results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+func TestSearchCapsulesRanking(t *testing.T) {
+	s := newSearchService()
+
+	response, err := s.SearchCapsules(context.Background(), testUserID, "  liburan BALI  ", 20, 0)
+	if err != nil {
+		t.Fatalf("SearchCapsules: %v", err)
+	}
+
+	if got, want := searchIDs(response), []int{1, 4, 6, 2, 5}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("result order = %v, want %v", got, want)
+	}
+	if response.Total != 5 || response.Query != "liburan BALI" {
+		t.Fatalf("response = %+v, want total 5 and trimmed query", response)
+	}
+
+	scores := []float64{}
+	for _, result := range response.Results {
+		scores = append(scores, result.Score)
+	}
+	if want := []float64{4, 3, 2, 2, 1}; !reflect.DeepEqual(scores, want) {
+		t.Fatalf("scores = %v, want %v", scores, want)
+	}
+
+	first := response.Results[0].Highlights
+	if first.Title != "<mark>Liburan</mark> ke <mark>Bali</mark>" || first.Snippet != "Jangan lupa sunscreen" {
+		t.Fatalf("highlights = %+v", first)
+	}
+}
+
+func TestSearchCapsulesPagination(t *testing.T) {
+	s := newSearchService()
+
+	tests := []struct {
+		name   string
+		limit  int
+		offset int
+		want   []int
+	}{
+		{name: "first page", limit: 2, offset: 0, want: []int{1, 4}},
+		{name: "middle page", limit: 2, offset: 2, want: []int{6, 2}},
+		{name: "last partial page", limit: 2, offset: 4, want: []int{5}},
+		{name: "offset equals total", limit: 2, offset: 5, want: []int{}},
+		{name: "offset beyond total", limit: 10, offset: 100, want: []int{}},
+		{name: "limit larger than total", limit: 50, offset: 1, want: []int{4, 6, 2, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.SearchCapsules(context.Background(), testUserID, "liburan bali", tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("SearchCapsules: %v", err)
+			}
+
+			if got := searchIDs(response); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+			// Total selalu jumlah semua hasil, bukan jumlah di halaman ini
+			if response.Total != 5 || response.Limit != tt.limit || response.Offset != tt.offset {
+				t.Fatalf("response total/limit/offset = %d/%d/%d", response.Total, response.Limit, response.Offset)
+			}
+		})
+	}
+}
+
+func TestSearchCapsulesRejectsInvalidQuery(t *testing.T) {
+	s := newSearchService()
+
+	if _, err := s.SearchCapsules(context.Background(), testUserID, "   ", 20, 0); err == nil || err.Error() != "search query is required" {
+		t.Fatalf("error = %v, want search query is required", err)
+	}
+}
+
+func TestHighlightText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{name: "case insensitive", text: "Surat untuk SURAT", terms: []string{"surat"}, want: "<mark>Surat</mark> untuk <mark>SURAT</mark>"},
+		{name: "whole words only", text: "suratku surat", terms: []string{"surat"}, want: "suratku <mark>surat</mark>"},
+		{name: "accented", text: "Café naïve café", terms: []string{"café", "naïve"}, want: "<mark>Café</mark> <mark>naïve</mark> <mark>café</mark>"},
+		{name: "cjk", text: "東京 と 大阪", terms: []string{"大阪"}, want: "東京 と <mark>大阪</mark>"},
+		{name: "emoji between words", text: "liburan🎉bali", terms: []string{"bali"}, want: "liburan🎉<mark>bali</mark>"},
+		{name: "html escaped", text: "<b>liburan</b> & teman", terms: []string{"liburan"}, want: "&lt;b&gt;<mark>liburan</mark>&lt;/b&gt; &amp; teman"},
+		{name: "no match", text: "Halo dunia", terms: []string{"bali"}, want: "Halo dunia"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightText(tt.text, tt.terms); got != tt.want {
+				t.Fatalf("highlightText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippetText(t *testing.T) {
+	// 150 rune sebelum kata yang dicari, 300 rune setelahnya
+	message := strings.Repeat("日本 ", 50) + "liburan" + strings.Repeat(" über", 60)
+
+	snippet := snippetText(message, []string{"liburan"})
+
+	if !utf8.ValidString(snippet) {
+		t.Fatalf("snippet is not valid UTF-8: %q", snippet)
+	}
+	if !strings.HasPrefix(snippet, snippetEllipsis) || !strings.HasSuffix(snippet, snippetEllipsis) {
+		t.Fatalf("snippet = %q, want ellipsis on both sides", snippet)
+	}
+	if !strings.Contains(snippet, "<mark>liburan</mark>") {
+		t.Fatalf("snippet = %q, want highlighted term", snippet)
+	}
+
+	// Potongan tidak memotong kata dan panjang teksnya tidak melebihi snippetLength
+	plain := strings.NewReplacer("<mark>", "", "</mark>", "", snippetEllipsis, "").Replace(snippet)
+	if n := utf8.RuneCountInString(plain); n > snippetLength {
+		t.Fatalf("snippet has %d runes, want at most %d", n, snippetLength)
+	}
+	for _, word := range strings.Fields(plain) {
+		if word != "日本" && word != "liburan" && word != "über" {
+			t.Fatalf("snippet contains a cut word %q: %q", word, snippet)
+		}
+	}
+
+	// Konteks sebelum kata pertama yang cocok kira-kira snippetContext rune
+	before := strings.Split(plain, "liburan")[0]
+	if n := utf8.RuneCountInString(before); n > snippetContext {
+		t.Fatalf("snippet has %d runes before the match, want at most %d", n, snippetContext)
+	}
+}
+
+func TestSnippetTextShortMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		terms   []string
+		want    string
+	}{
+		{name: "match", message: "Selamat\n\nliburan  ke Bali", terms: []string{"bali"}, want: "Selamat liburan ke <mark>Bali</mark>"},
+		{name: "title only match", message: "Isi pesan tanpa kata", terms: []string{"bali"}, want: "Isi pesan tanpa kata"},
+		{name: "empty", message: "", terms: []string{"bali"}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snippetText(tt.message, tt.terms); got != tt.want {
+				t.Fatalf("snippetText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
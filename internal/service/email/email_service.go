@@ -2,9 +2,11 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"net/smtp"
 	"strings"
+	"time"
 
 	"future-letter/internal/config"
 	"future-letter/internal/models"
@@ -27,6 +29,11 @@ func NewEmailService(cfg *config.Config) *EmailService {
 //   - user : data user yang akan menerima email
 //   - capsule : data capsule yang akan dikirim
 func (s *EmailService) SendCapsuleEmail(user *models.User, capsule *models.Capsule) error {
+	return s.SendCapsuleEmailContext(context.Background(), user, capsule)
+}
+
+// SendCapsuleEmailContext sama dengan SendCapsuleEmail, koneksi SMTP dibatasi deadline dari ctx
+func (s *EmailService) SendCapsuleEmailContext(ctx context.Context, user *models.User, capsule *models.Capsule) error {
 	// Judul email
 	subject := fmt.Sprintf("Time Capsule: %s", capsule.Title)
 
@@ -40,11 +47,15 @@ func (s *EmailService) SendCapsuleEmail(user *models.User, capsule *models.Capsu
 		s.cfg.Email.SMTPHost,
 	)
 
+	// Message-ID tetap untuk capsule yang sama agar pengiriman ulang bisa dikenali
+	messageID := fmt.Sprintf("<%s@%s>", capsule.IdempotencyKey(), s.cfg.Email.SMTPHost)
+
 	// Mmembuat message
 	message := []byte(
 		"From: " + s.cfg.Email.SMTPFrom + "\r\n" +
 			"To: " + user.Email + "\r\n" +
 			"Subject: " + subject + "\r\n" +
+			"Message-ID: " + messageID + "\r\n" +
 			"MIME-Version: 1.0\r\n" +
 			"Content-Type: text/html; charset=UTF-8\r\n" +
 			"\r\n" +
@@ -52,7 +63,8 @@ func (s *EmailService) SendCapsuleEmail(user *models.User, capsule *models.Capsu
 	)
 
 	addr := fmt.Sprintf("%s:%d", s.cfg.Email.SMTPHost, s.cfg.Email.SMTPPort)
-	err := smtp.SendMail(
+	err := sendMailContext(
+		ctx,
 		addr,
 		auth,
 		s.cfg.Email.SMTPUsername,
@@ -295,3 +307,288 @@ func (s *EmailService) SendWelcomeEmail(user *models.User) error {
 
 	return nil
 }
+
+// sendHTML mengirim email HTML sederhana ke satu penerima
+func (s *EmailService) sendHTML(toEmail, subject, html string) error {
+	auth := smtp.PlainAuth("", s.cfg.Email.SMTPUsername, s.cfg.Email.SMTPPassword, s.cfg.Email.SMTPHost)
+
+	message := []byte(
+		"From: " + s.cfg.Email.SMTPFrom + "\r\n" +
+			"To: " + toEmail + "\r\n" +
+			"Subject: " + subject + "\r\n" +
+			"MIME-Version: 1.0\r\n" +
+			"Content-Type: text/html; charset=UTF-8\r\n" +
+			"\r\n" +
+			html + "\r\n",
+	)
+
+	addr := fmt.Sprintf("%s:%d", s.cfg.Email.SMTPHost, s.cfg.Email.SMTPPort)
+	if err := smtp.SendMail(addr, auth, s.cfg.Email.SMTPUsername, []string{toEmail}, message); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// SendPasswordResetEmail mengirim link reset password yang berlaku selama expiresIn
+func (s *EmailService) SendPasswordResetEmail(user *models.User, resetURL string, expiresIn time.Duration) error {
+	subject := "Reset your Future Self Reminders password"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">🔑 Reset your password</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>We received a request to reset your password. Click the button below to choose a new one:</p>
+    <p style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #667eea; color: white; padding: 12px 24px; border-radius: 5px; text-decoration: none;">Reset Password</a>
+    </p>
+    <p>This link expires in %d minutes and can only be used once.</p>
+    <p style="font-size: 14px; color: #666;">If you didn't request this, you can safely ignore this email. Your password will not change.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), escapeHTML(resetURL), int(expiresIn.Minutes()))
+
+	return s.sendHTML(user.Email, subject, html)
+}
+
+// SendVerificationEmail mengirim link verifikasi email yang berlaku selama expiresIn
+func (s *EmailService) SendVerificationEmail(user *models.User, verifyURL string, expiresIn time.Duration) error {
+	subject := "Verify your Future Self Reminders email"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">📬 Verify your email address</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>Please confirm that this is your email address so your time capsules can reach you:</p>
+    <p style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #667eea; color: white; padding: 12px 24px; border-radius: 5px; text-decoration: none;">Verify Email</a>
+    </p>
+    <p>This link expires in %d hours.</p>
+    <p style="font-size: 14px; color: #666;">Capsules scheduled for email delivery will wait until your address is verified.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), escapeHTML(verifyURL), int(expiresIn.Hours()))
+
+	return s.sendHTML(user.Email, subject, html)
+}
+
+// SendMagicLinkEmail mengirim link login tanpa password yang berlaku selama expiresIn
+func (s *EmailService) SendMagicLinkEmail(user *models.User, loginURL string, expiresIn time.Duration) error {
+	subject := "Your Future Self Reminders login link"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">✨ Log in to Future Self Reminders</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>Click the button below to log in without a password:</p>
+    <p style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #667eea; color: white; padding: 12px 24px; border-radius: 5px; text-decoration: none;">Log In</a>
+    </p>
+    <p>This link expires in %d minutes and can only be used once.</p>
+    <p style="font-size: 14px; color: #666;">If you didn't request this, you can safely ignore this email.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), escapeHTML(loginURL), int(expiresIn.Minutes()))
+
+	return s.sendHTML(user.Email, subject, html)
+}
+
+// SendAccountLockedEmail memberi tahu pemilik akun bahwa akun dikunci sementara karena banyak login gagal
+func (s *EmailService) SendAccountLockedEmail(user *models.User, lockedUntil time.Time, ipAddress string) error {
+	subject := "Your Future Self Reminders account was temporarily locked"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">🔒 Account temporarily locked</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>We noticed several failed login attempts on your account, so we locked it until <strong>%s</strong>.</p>
+    <p>Last attempt came from IP address: <strong>%s</strong></p>
+    <p>If this was you, just wait and try again. If it wasn't, we recommend resetting your password and enabling two factor authentication.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	if ipAddress == "" {
+		ipAddress = "unknown"
+	}
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), lockedUntil.UTC().Format("January 2, 2006 15:04 MST"), escapeHTML(ipAddress))
+
+	return s.sendHTML(user.Email, subject, html)
+}
+
+// SendPasswordChangedEmail memberi tahu user bahwa password akun baru saja diganti
+func (s *EmailService) SendPasswordChangedEmail(user *models.User) error {
+	subject := "Your Future Self Reminders password was changed"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">🔑 Password changed</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>The password for your account was just changed and all other devices were logged out.</p>
+    <p style="font-size: 14px; color: #666;">If you didn't do this, reset your password immediately using the forgot password link.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name))
+
+	return s.sendHTML(user.Email, subject, html)
+}
+
+// SendEmailChangeConfirmation mengirim link konfirmasi ke alamat email baru
+func (s *EmailService) SendEmailChangeConfirmation(user *models.User, newEmail, confirmURL string, expiresIn time.Duration) error {
+	subject := "Confirm your new Future Self Reminders email"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">📧 Confirm your new email</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>Click the button below to use this address for your Future Self Reminders account:</p>
+    <p style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #667eea; color: white; padding: 12px 24px; border-radius: 5px; text-decoration: none;">Confirm Email</a>
+    </p>
+    <p>This link expires in %d hours.</p>
+    <p style="font-size: 14px; color: #666;">If you didn't request this, you can safely ignore this email.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), escapeHTML(confirmURL), int(expiresIn.Hours()))
+
+	return s.sendHTML(newEmail, subject, html)
+}
+
+// SendEmailChangedNotice memberi tahu alamat lama bahwa email akun sudah diganti
+func (s *EmailService) SendEmailChangedNotice(user *models.User, oldEmail, newEmail string) error {
+	subject := "Your Future Self Reminders email was changed"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">📧 Email address changed</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>The email address for your account was changed to <strong>%s</strong>. Future capsules will be delivered there.</p>
+    <p style="font-size: 14px; color: #666;">If you didn't do this, please contact support right away.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), escapeHTML(newEmail))
+
+	return s.sendHTML(oldEmail, subject, html)
+}
+
+// SendAccountDeletionScheduledEmail memberi tahu user bahwa akun akan dihapus beserta link untuk membatalkannya
+func (s *EmailService) SendAccountDeletionScheduledEmail(user *models.User, scheduledAt time.Time, cancelURL string) error {
+	subject := "Your Future Self Reminders account is scheduled for deletion"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">🗑️ Account deletion scheduled</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>Your account and all of your capsules will be permanently deleted on <strong>%s</strong>.</p>
+    <p>Until then you can still log in and download a copy of your data. Changed your mind? Click the button below to keep your account:</p>
+    <p style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #667eea; color: white; padding: 12px 24px; border-radius: 5px; text-decoration: none;">Cancel Deletion</a>
+    </p>
+    <p style="font-size: 14px; color: #666;">If you didn't request this, cancel the deletion and reset your password right away.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), scheduledAt.UTC().Format("January 2, 2006 15:04 MST"), escapeHTML(cancelURL))
+
+	return s.sendHTML(user.Email, subject, html)
+}
+
+// SendDataExportReadyEmail mengirim link download export data user yang sudah selesai dibuat
+func (s *EmailService) SendDataExportReadyEmail(user *models.User, downloadURL string, expiresAt time.Time) error {
+	subject := "Your Future Self Reminders data export is ready"
+
+	html := `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; padding: 20px; max-width: 600px; margin: 0 auto;">
+    <h2 style="color: #667eea;">📦 Your data export is ready</h2>
+    <p>Hi <strong>%s</strong>,</p>
+    <p>The archive with your profile, capsules, attachments and delivery history is ready to download:</p>
+    <p style="text-align: center; margin: 30px 0;">
+        <a href="%s" style="background: #667eea; color: white; padding: 12px 24px; border-radius: 5px; text-decoration: none;">Download Export</a>
+    </p>
+    <p>This link expires on <strong>%s</strong>, after that the file is deleted.</p>
+    <p style="font-size: 14px; color: #666;">If you didn't request this export, please change your password right away.</p>
+    <hr>
+    <p style="font-size: 12px; color: #999;">Future Self Reminders - Your personal time capsule service</p>
+</body>
+</html>
+`
+
+	html = fmt.Sprintf(html, escapeHTML(user.Name), escapeHTML(downloadURL), expiresAt.UTC().Format("January 2, 2006 15:04 MST"))
+
+	return s.sendHTML(user.Email, subject, html)
+}
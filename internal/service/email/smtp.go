@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// sendMailContext sama dengan smtp.SendMail tetapi menghormati context.
+// Deadline context dipasang di koneksi dan koneksi diputus saat context dibatalkan,
+// sehingga pengiriman tidak terus berjalan di belakang setelah pemanggil menyerah
+func sendMailContext(ctx context.Context, addr string, auth smtp.Auth, from string, to []string, message []byte) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	// Context dibatalkan tanpa deadline: paksa operasi yang sedang berjalan berhenti
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return contextError(ctx, err)
+		}
+	}
+
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err := client.Auth(auth); err != nil {
+				return contextError(ctx, err)
+			}
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return contextError(ctx, err)
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return contextError(ctx, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return contextError(ctx, err)
+	}
+	if err := writer.Close(); err != nil {
+		return contextError(ctx, err)
+	}
+
+	return client.Quit()
+}
+
+// contextError mengganti error I/O karena deadline dengan error context agar penyebabnya jelas
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return err
+}
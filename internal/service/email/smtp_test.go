@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSendMailContextStopsAtDeadline(t *testing.T) {
+	// Server SMTP yang menerima koneksi tapi tidak pernah membalas greeting
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = sendMailContext(ctx, listener.Addr().String(), nil, "from@example.com", []string{"to@example.com"}, []byte("hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("sendMailContext() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("sendMailContext() returned after %s, deadline was not applied to the connection", elapsed)
+	}
+}
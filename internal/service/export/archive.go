@@ -0,0 +1,217 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// archiveManifest ringkasan isi ZIP, termasuk lampiran yang gagal diunduh
+type archiveManifest struct {
+	ExportedAt    time.Time            `json:"exported_at"`
+	UserID        int                  `json:"user_id"`
+	Capsules      int                  `json:"capsules"`
+	Deliveries    int                  `json:"deliveries"`
+	Attachments   []attachmentManifest `json:"attachments"`
+	InboxMessages int                  `json:"inbox_messages"`
+}
+
+type attachmentManifest struct {
+	CapsuleID int    `json:"capsule_id"`
+	SourceURL string `json:"source_url"`
+	File      string `json:"file,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// deliveryRecord satu baris riwayat pengiriman di deliveries.json
+type deliveryRecord struct {
+	CapsuleID int `json:"capsule_id"`
+	*models.DeliveryAttemptResponse
+}
+
+// buildArchive menulis ZIP berisi profil, capsule (JSON dan Markdown), lampiran,
+// riwayat pengiriman dan inbox user ke filePath. Mengembalikan ukuran file
+func (s *exportService) buildArchive(ctx context.Context, user *models.User, filePath string) (int64, error) {
+	capsules, err := s.capsuleRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	attempts, err := s.deliveryRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	messages, err := s.inboxRepo.GetByUserID(ctx, user.ID, false)
+	if err != nil {
+		return 0, err
+	}
+
+	data := models.NewAccountExport(user, capsules, attempts, messages, s.clock.Now())
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+
+	manifest := archiveManifest{
+		ExportedAt:    data.ExportedAt,
+		UserID:        user.ID,
+		Capsules:      len(data.Capsules),
+		Deliveries:    len(attempts),
+		Attachments:   []attachmentManifest{},
+		InboxMessages: len(data.InboxMessages),
+	}
+
+	if err := writeJSON(archive, "profile.json", data.Profile); err != nil {
+		return 0, err
+	}
+
+	deliveries := make([]deliveryRecord, 0, len(attempts))
+	for _, capsule := range data.Capsules {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		base := fmt.Sprintf("capsules/%d-%s", capsule.ID, slugify(capsule.Title))
+
+		if err := writeJSON(archive, base+".json", capsule); err != nil {
+			return 0, err
+		}
+		if err := writeFile(archive, base+".md", []byte(capsuleMarkdown(capsule.CapsuleResponse))); err != nil {
+			return 0, err
+		}
+
+		for i := range capsule.Deliveries {
+			deliveries = append(deliveries, deliveryRecord{CapsuleID: capsule.ID, DeliveryAttemptResponse: &capsule.Deliveries[i]})
+		}
+
+		if capsule.ImageURL == nil {
+			continue
+		}
+
+		// Lampiran yang gagal diunduh tidak menggagalkan export, dicatat di manifest
+		entry := attachmentManifest{CapsuleID: capsule.ID, SourceURL: *capsule.ImageURL}
+		content, name, err := s.attachments.Fetch(ctx, *capsule.ImageURL)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			entry.File = fmt.Sprintf("attachments/%d-%s", capsule.ID, name)
+			if err := writeFile(archive, entry.File, content); err != nil {
+				return 0, err
+			}
+		}
+		manifest.Attachments = append(manifest.Attachments, entry)
+	}
+
+	if err := writeJSON(archive, "deliveries.json", deliveries); err != nil {
+		return 0, err
+	}
+	if err := writeJSON(archive, "inbox.json", data.InboxMessages); err != nil {
+		return 0, err
+	}
+	if err := writeJSON(archive, "manifest.json", manifest); err != nil {
+		return 0, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finalize export file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func writeJSON(archive *zip.Writer, name string, value any) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	return writeFile(archive, name, content)
+}
+
+func writeFile(archive *zip.Writer, name string, content []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// capsuleMarkdown menulis capsule sebagai Markdown dengan YAML front matter,
+// format yang sama bisa dipakai lagi untuk import
+func capsuleMarkdown(capsule *models.CapsuleResponse) string {
+	var b strings.Builder
+
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", strconv.Quote(capsule.Title))
+	fmt.Fprintf(&b, "due_date: %s\n", capsule.DueDate)
+	fmt.Fprintf(&b, "delivery_method: %s\n", capsule.DeliveryMethod)
+	fmt.Fprintf(&b, "status: %s\n", capsule.Status)
+	if capsule.Category != nil {
+		fmt.Fprintf(&b, "category: %s\n", strconv.Quote(*capsule.Category))
+	}
+	if capsule.Mood != nil {
+		fmt.Fprintf(&b, "mood: %s\n", strconv.Quote(*capsule.Mood))
+	}
+	if capsule.ImageURL != nil {
+		fmt.Fprintf(&b, "image_url: %s\n", strconv.Quote(*capsule.ImageURL))
+	}
+	if capsule.SentAt != nil {
+		fmt.Fprintf(&b, "sent_at: %s\n", capsule.SentAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "created_at: %s\n", capsule.CreatedAt.UTC().Format(time.RFC3339))
+	b.WriteString("---\n\n")
+	b.WriteString(capsule.Message)
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// slugify membuat potongan nama file dari judul capsule
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteRune('-')
+			dash = true
+		}
+
+		if b.Len() >= 50 {
+			break
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "capsule"
+	}
+
+	return slug
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// deniedAttachmentNetworks rentang alamat yang tidak boleh dihubungi saat mengunduh lampiran.
+// Ditulis eksplisit karena helper net.IP tidak mencakup CGNAT, "this network" dan rentang khusus lain.
+// Rentang IPv6 yang membawa alamat IPv4 (NAT64, 6to4, Teredo) ditolak seluruhnya agar tidak bisa
+// dipakai untuk menjangkau alamat IPv4 internal
+var deniedAttachmentNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("10.0.0.0/8"),      // privat
+	netip.MustParsePrefix("100.64.0.0/10"),   // CGNAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, termasuk metadata cloud
+	netip.MustParsePrefix("172.16.0.0/12"),   // privat
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // dokumentasi
+	netip.MustParsePrefix("192.88.99.0/24"),  // relay anycast 6to4
+	netip.MustParsePrefix("192.168.0.0/16"),  // privat
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // dokumentasi
+	netip.MustParsePrefix("203.0.113.0/24"),  // dokumentasi
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved dan broadcast
+	netip.MustParsePrefix("::/128"),          // unspecified
+	netip.MustParsePrefix("::1/128"),         // loopback
+	netip.MustParsePrefix("::/96"),           // IPv4-compatible, menyimpan alamat IPv4 di 32 bit terakhir
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, diteruskan ke alamat IPv4 di 32 bit terakhir
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // NAT64 lokal
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/32"),       // Teredo, tunnel ke alamat IPv4
+	netip.MustParsePrefix("2001:db8::/32"),   // dokumentasi
+	netip.MustParsePrefix("2002::/16"),       // 6to4, tunnel ke alamat IPv4
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link-local
+	netip.MustParsePrefix("ff00::/8"),        // multicast
+}
+
+// attachmentFetcher mengunduh lampiran capsule (image_url) untuk dimasukkan ke ZIP.
+// URL berasal dari input user, jadi koneksi ke alamat internal (loopback, jaringan privat) ditolak
+type attachmentFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+func newAttachmentFetcher(maxBytes int64, timeout time.Duration) *attachmentFetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: rejectInternalAddress,
+	}
+
+	return &attachmentFetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 3 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+		maxBytes: maxBytes,
+	}
+}
+
+// rejectInternalAddress dipanggil setelah DNS di resolve, sehingga redirect dan DNS rebinding ikut dicek
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("invalid address %s", address)
+	}
+
+	if !isAllowedAttachmentAddress(ip) {
+		return fmt.Errorf("address %s is not allowed", ip)
+	}
+
+	return nil
+}
+
+// isAllowedAttachmentAddress alamat IPv4-mapped (::ffff:a.b.c.d) dicek sebagai IPv4
+// agar tidak bisa dipakai untuk melewati deny-list
+func isAllowedAttachmentAddress(ip netip.Addr) bool {
+	ip = ip.Unmap().WithZone("")
+
+	for _, network := range deniedAttachmentNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fetch mengunduh satu lampiran, mengembalikan isi file dan nama file yang aman dipakai di ZIP
+func (f *attachmentFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, "", errors.New("unsupported attachment url")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// Baca satu byte lebih dari batas untuk mendeteksi file yang terlalu besar
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, "", fmt.Errorf("attachment larger than %d bytes", f.maxBytes)
+	}
+
+	return data, attachmentName(u.Path), nil
+}
+
+// attachmentName mengambil nama file dari path URL, karakter selain huruf, angka, titik,
+// strip dan underscore diganti agar aman dipakai sebagai nama file di ZIP
+func attachmentName(urlPath string) string {
+	name := path.Base(urlPath)
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+
+	name = strings.Trim(name, ".")
+	if name == "" || name == "_" {
+		return "attachment"
+	}
+
+	if len(name) > 100 {
+		name = name[len(name)-100:]
+	}
+
+	return name
+}
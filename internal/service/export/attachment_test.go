@@ -0,0 +1,92 @@
+package service
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRejectInternalAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"8.8.8.8:80", true},
+		{"[2606:4700:4700::1111]:443", true},
+		{"100.63.255.255:80", true},
+		{"100.128.0.0:80", true},
+
+		{"0.0.0.0:80", false},
+		{"0.1.2.3:80", false},
+		{"10.0.0.1:80", false},
+		{"100.64.0.1:80", false},
+		{"100.127.255.254:80", false},
+		{"127.0.0.1:80", false},
+		{"127.8.8.8:80", false},
+		{"169.254.169.254:80", false},
+		{"172.16.0.1:80", false},
+		{"172.31.255.255:80", false},
+		{"192.0.0.8:80", false},
+		{"192.168.1.1:80", false},
+		{"198.18.0.1:80", false},
+		{"224.0.0.1:80", false},
+		{"255.255.255.255:80", false},
+		{"[::]:80", false},
+		{"[::1]:80", false},
+		{"[fc00::1]:80", false},
+		{"[fd12:3456::1]:80", false},
+		{"[fe80::1%eth0]:80", false},
+		{"[ff02::1]:80", false},
+		// IPv4-mapped IPv6 tidak boleh melewati deny-list IPv4
+		{"[::ffff:127.0.0.1]:80", false},
+		{"[::ffff:100.64.0.1]:80", false},
+		{"[::ffff:169.254.169.254]:80", false},
+		// Rentang IPv6 yang membawa alamat IPv4 ditolak seluruhnya
+		{"[64:ff9b::a9fe:a9fe]:80", false},
+		{"[64:ff9b::808:808]:80", false},
+		{"[64:ff9b:1::7f00:1]:80", false},
+		{"[2002:7f00:1::1]:80", false},
+		{"[2002:a9fe:a9fe::1]:80", false},
+		{"[2001:0:4136:e378:8000:63bf:3fff:fdd2]:80", false},
+		{"[::127.0.0.1]:80", false},
+		{"[::a9fe:a9fe]:80", false},
+		{"192.88.99.1:80", false},
+		// Rentang IPv6 publik di sekitar rentang tersebut tetap boleh
+		{"[2001:4860:4860::8888]:443", true},
+		{"[2003::1]:443", true},
+		{"[64:ff9b:2::1]:443", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := rejectInternalAddress("tcp", tt.address, nil)
+			if tt.allowed && err != nil {
+				t.Fatalf("rejectInternalAddress(%q) = %v, want allowed", tt.address, err)
+			}
+			if !tt.allowed && err == nil {
+				t.Fatalf("rejectInternalAddress(%q) = nil, want rejected", tt.address)
+			}
+		})
+	}
+}
+
+func TestRejectInternalAddressInvalid(t *testing.T) {
+	for _, address := range []string{"example.com:80", "127.0.0.1", ""} {
+		if err := rejectInternalAddress("tcp", address, nil); err == nil {
+			t.Errorf("rejectInternalAddress(%q) = nil, want error", address)
+		}
+	}
+}
+
+func TestAttachmentFetcherRefusesLoopback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	fetcher := newAttachmentFetcher(1024, 0)
+	if _, _, err := fetcher.Fetch(t.Context(), "http://"+listener.Addr().String()+"/image.png"); err == nil {
+		t.Fatal("Fetch to loopback succeeded, want error")
+	}
+}
@@ -0,0 +1,19 @@
+// Package service export
+package service
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+// ExportService membuat file ZIP berisi seluruh data user secara asynchronous
+type ExportService interface {
+	Start() error
+	Stop()
+	RequestExport(ctx context.Context, userID int) (*models.DataExport, error)
+	GetExport(ctx context.Context, userID, exportID int) (*models.DataExport, error)
+	ListExports(ctx context.Context, userID int) ([]models.DataExport, error)
+	DownloadURL(export *models.DataExport) string
+	ResolveDownload(ctx context.Context, exportID int, expires int64, signature string) (*models.DataExport, error)
+}
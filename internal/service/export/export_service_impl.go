@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+	capsuleRepository "future-letter/internal/repository/capsule"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	repository "future-letter/internal/repository/export"
+	inboxRepository "future-letter/internal/repository/inbox"
+	userRepository "future-letter/internal/repository/user"
+	emailService "future-letter/internal/service/email"
+	"future-letter/internal/utils"
+)
+
+const (
+	// exportQueueSize jumlah export yang bisa menunggu di antrian worker
+	exportQueueSize = 100
+	// exportSweepInterval jeda janitor mengambil ulang export pending dan menghapus file kedaluwarsa
+	exportSweepInterval = time.Minute
+	// exportListLimit jumlah export terbaru yang ditampilkan ke user
+	exportListLimit = 20
+)
+
+type exportService struct {
+	cfg          *config.Config
+	exportRepo   repository.ExportRepository
+	userRepo     userRepository.UserRepository
+	capsuleRepo  capsuleRepository.CapsuleRepository
+	deliveryRepo deliveryRepository.DeliveryRepository
+	inboxRepo    inboxRepository.InboxRepository
+	emailService *emailService.EmailService
+	clock        clock.Clock
+
+	// attachments mengunduh lampiran capsule ke dalam ZIP
+	attachments *attachmentFetcher
+	// signingKey kunci HMAC link download, terpisah dari kunci JWT
+	signingKey []byte
+
+	// jobs antrian ID export yang akan diproses worker
+	jobs chan int
+	// ctx dibatalkan saat service berhenti agar export yang sedang berjalan ikut berhenti
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewExportService(
+	cfg *config.Config,
+	exportRepo repository.ExportRepository,
+	userRepo userRepository.UserRepository,
+	capsuleRepo capsuleRepository.CapsuleRepository,
+	deliveryRepo deliveryRepository.DeliveryRepository,
+	inboxRepo inboxRepository.InboxRepository,
+	emailService *emailService.EmailService,
+	clock clock.Clock,
+) ExportService {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &exportService{
+		cfg:          cfg,
+		exportRepo:   exportRepo,
+		userRepo:     userRepo,
+		capsuleRepo:  capsuleRepo,
+		deliveryRepo: deliveryRepo,
+		inboxRepo:    inboxRepo,
+		emailService: emailService,
+		clock:        clock,
+		attachments:  newAttachmentFetcher(cfg.Export.MaxAttachmentBytes, time.Duration(cfg.Export.AttachmentTimeoutSeconds)*time.Second),
+		signingKey:   exportSigningKey(cfg),
+		jobs:         make(chan int, exportQueueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start menyiapkan folder penyimpanan lalu menjalankan worker dan janitor di background
+func (s *exportService) Start() error {
+	if err := os.MkdirAll(s.cfg.Export.StorageDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create export storage dir: %w", err)
+	}
+
+	workers := s.cfg.Export.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	s.wg.Add(1)
+	go s.janitor()
+
+	log.Printf("Export service started with %d worker(s), storage: %s", workers, s.cfg.Export.StorageDir)
+
+	return nil
+}
+
+// Stop menghentikan worker, export yang sedang dibuat dibatalkan dan ditandai gagal
+func (s *exportService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// RequestExport membuat permintaan export baru, satu user hanya boleh punya satu export yang sedang berjalan
+func (s *exportService) RequestExport(ctx context.Context, userID int) (*models.DataExport, error) {
+	active, err := s.exportRepo.HasActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, errors.New("export already in progress")
+	}
+
+	export := &models.DataExport{
+		UserID:    userID,
+		Status:    models.ExportStatusPending,
+		CreatedAt: s.clock.Now(),
+	}
+
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+
+	s.enqueue(export.ID)
+
+	return s.exportRepo.GetByIDForUser(ctx, export.ID, userID)
+}
+
+// GetExport mengambil status export milik user
+func (s *exportService) GetExport(ctx context.Context, userID, exportID int) (*models.DataExport, error) {
+	return s.exportRepo.GetByIDForUser(ctx, exportID, userID)
+}
+
+// ListExports mengambil export terbaru milik user
+func (s *exportService) ListExports(ctx context.Context, userID int) ([]models.DataExport, error) {
+	return s.exportRepo.ListByUser(ctx, userID, exportListLimit)
+}
+
+// DownloadURL membuat link download bertanda tangan, kosong jika export tidak bisa diunduh
+func (s *exportService) DownloadURL(export *models.DataExport) string {
+	if !export.IsDownloadable(s.clock.Now()) {
+		return ""
+	}
+
+	expires := export.ExpiresAt.Time.Unix()
+
+	return fmt.Sprintf("%s/api/v1/exports/%d/download?expires=%d&signature=%s",
+		s.cfg.App.APIURL, export.ID, expires, s.sign(export.ID, expires))
+}
+
+// ResolveDownload memvalidasi link download bertanda tangan dan mengembalikan export yang boleh diunduh
+func (s *exportService) ResolveDownload(ctx context.Context, exportID int, expires int64, signature string) (*models.DataExport, error) {
+	if !s.verify(exportID, expires, signature) {
+		return nil, errors.New("invalid download link")
+	}
+
+	now := s.clock.Now()
+	if now.Unix() >= expires {
+		return nil, errors.New("download link expired")
+	}
+
+	export, err := s.exportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Link harus sesuai dengan masa berlaku yang tersimpan
+	if !export.ExpiresAt.Valid || export.ExpiresAt.Time.Unix() != expires {
+		return nil, errors.New("invalid download link")
+	}
+
+	if !export.IsDownloadable(now) {
+		return nil, errors.New("download link expired")
+	}
+
+	return export, nil
+}
+
+// enqueue memasukkan export ke antrian tanpa menunggu.
+// Jika antrian penuh, export tetap pending dan diambil janitor pada sweep berikutnya
+func (s *exportService) enqueue(exportID int) {
+	select {
+	case s.jobs <- exportID:
+	default:
+		log.Printf("Export queue full, export %d will be picked up on next sweep", exportID)
+	}
+}
+
+// worker memproses export dari antrian sampai service dihentikan
+func (s *exportService) worker() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case exportID := <-s.jobs:
+			s.process(exportID)
+		}
+	}
+}
+
+// janitor secara berkala mengambil ulang export pending (misalnya dari sebelum restart),
+// menandai gagal export yang macet dan menghapus file yang link nya sudah kedaluwarsa
+func (s *exportService) janitor() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(exportSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *exportService) sweep() {
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	now := s.clock.Now()
+
+	// Export yang lebih lama dari dua kali batas waktu pasti sudah ditinggal worker nya
+	staleBefore := now.Add(-2 * s.exportTimeout())
+	if failed, err := s.exportRepo.FailStale(ctx, staleBefore, now); err != nil {
+		log.Printf("Failed to fail stale exports: %v", err)
+	} else if failed > 0 {
+		log.Printf("Marked %d stale export(s) as failed", failed)
+	}
+
+	pending, err := s.exportRepo.ListPending(ctx, exportQueueSize)
+	if err != nil {
+		log.Printf("Failed to list pending exports: %v", err)
+	}
+	for _, export := range pending {
+		s.enqueue(export.ID)
+	}
+
+	expired, err := s.exportRepo.ListExpired(ctx, now, exportQueueSize)
+	if err != nil {
+		log.Printf("Failed to list expired exports: %v", err)
+	}
+	for _, export := range expired {
+		if export.FilePath.Valid {
+			if err := os.Remove(export.FilePath.String); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove export file %s: %v", export.FilePath.String, err)
+				continue
+			}
+		}
+
+		if err := s.exportRepo.MarkExpired(ctx, export.ID); err != nil {
+			log.Printf("Failed to mark export %d as expired: %v", export.ID, err)
+		}
+	}
+
+	s.removeOrphanedFiles(ctx)
+}
+
+// removeOrphanedFiles menghapus file ZIP yang tidak bisa diunduh lagi: baris export nya sudah tidak ada
+// (akun pemilik dihapus permanen dan data_exports ikut terhapus lewat ON DELETE CASCADE),
+// atau export nya sudah gagal atau kedaluwarsa (misalnya file setengah jadi dari worker yang mati)
+func (s *exportService) removeOrphanedFiles(ctx context.Context) {
+	entries, err := os.ReadDir(s.cfg.Export.StorageDir)
+	if err != nil {
+		log.Printf("Failed to read export storage dir: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		exportID, ok := parseExportFileName(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		export, err := s.exportRepo.GetByID(ctx, exportID)
+		if err != nil && err.Error() != "export not found" {
+			log.Printf("Failed to get export %d: %v", exportID, err)
+			continue
+		}
+		if err == nil && export.Status != models.ExportStatusFailed && export.Status != models.ExportStatusExpired {
+			continue
+		}
+
+		filePath := filepath.Join(s.cfg.Export.StorageDir, entry.Name())
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove orphaned export file %s: %v", filePath, err)
+			continue
+		}
+		log.Printf("Removed orphaned export file %s", filePath)
+	}
+}
+
+// exportFileName nama file ZIP sebuah export, suffix acak agar tidak bisa ditebak dari ID export
+func exportFileName(exportID int, suffix string) string {
+	return fmt.Sprintf("export-%d-%s.zip", exportID, suffix)
+}
+
+// parseExportFileName mengambil ID export dari nama file buatan exportFileName
+func parseExportFileName(name string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, "export-")
+	if !ok || !strings.HasSuffix(rest, ".zip") {
+		return 0, false
+	}
+
+	idPart, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+
+	exportID, err := strconv.Atoi(idPart)
+	if err != nil || exportID <= 0 {
+		return 0, false
+	}
+
+	return exportID, true
+}
+
+// process membuat file ZIP untuk satu export lalu mengirim link download ke email user
+func (s *exportService) process(exportID int) {
+	now := s.clock.Now()
+
+	// Export yang sama bisa masuk antrian lebih dari sekali, hanya satu worker yang berhasil klaim
+	if err := s.exportRepo.Claim(s.ctx, exportID, now); err != nil {
+		return
+	}
+
+	export, err := s.exportRepo.GetByID(s.ctx, exportID)
+	if err != nil {
+		log.Printf("Failed to get export %d: %v", exportID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.exportTimeout())
+	defer cancel()
+
+	// Nama file diacak agar tidak bisa ditebak dari ID export
+	suffix, err := utils.GenerateRandomToken(8)
+	if err != nil {
+		s.fail(export, err)
+		return
+	}
+	filePath := filepath.Join(s.cfg.Export.StorageDir, exportFileName(export.ID, suffix))
+
+	user, err := s.userRepo.GetByID(ctx, export.UserID)
+	if err != nil {
+		s.fail(export, err)
+		return
+	}
+
+	size, err := s.buildArchive(ctx, user, filePath)
+	if err != nil {
+		if removeErr := os.Remove(filePath); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("Failed to remove partial export file %s: %v", filePath, removeErr)
+		}
+		s.fail(export, err)
+		return
+	}
+
+	completedAt := s.clock.Now()
+	expiresAt := completedAt.Add(time.Duration(s.cfg.Export.LinkTTLHours) * time.Hour)
+
+	if err := s.exportRepo.Complete(ctx, export.ID, filePath, size, completedAt, expiresAt); err != nil {
+		log.Printf("Failed to complete export %d: %v", export.ID, err)
+		return
+	}
+
+	completed, err := s.exportRepo.GetByID(ctx, export.ID)
+	if err != nil {
+		log.Printf("Failed to get export %d: %v", export.ID, err)
+		return
+	}
+
+	if err := s.emailService.SendDataExportReadyEmail(user, s.DownloadURL(completed), expiresAt); err != nil {
+		log.Printf("Failed to send export ready email to user %d: %v", user.ID, err)
+	}
+
+	log.Printf("Export %d for user %d completed (%d bytes)", export.ID, user.ID, size)
+}
+
+// fail menandai export gagal, memakai context baru karena context export bisa sudah dibatalkan
+func (s *exportService) fail(export *models.DataExport, cause error) {
+	log.Printf("Export %d for user %d failed: %v", export.ID, export.UserID, cause)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.exportRepo.Fail(ctx, export.ID, cause.Error(), s.clock.Now()); err != nil {
+		log.Printf("Failed to mark export %d as failed: %v", export.ID, err)
+	}
+}
+
+func (s *exportService) exportTimeout() time.Duration {
+	return time.Duration(s.cfg.Export.TimeoutMinutes) * time.Minute
+}
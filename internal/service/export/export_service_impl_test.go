@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+	repository "future-letter/internal/repository/export"
+)
+
+var testNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// fakeExportRepository menyimpan export di memory, method yang tidak dipakai test akan panic
+type fakeExportRepository struct {
+	repository.ExportRepository
+
+	exports map[int]*models.DataExport
+	expired []int
+}
+
+func (r *fakeExportRepository) GetByID(ctx context.Context, id int) (*models.DataExport, error) {
+	export, ok := r.exports[id]
+	if !ok {
+		return nil, errors.New("export not found")
+	}
+	copied := *export
+	return &copied, nil
+}
+
+func (r *fakeExportRepository) FailStale(ctx context.Context, startedBefore, now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeExportRepository) ListPending(ctx context.Context, limit int) ([]models.DataExport, error) {
+	return nil, nil
+}
+
+func (r *fakeExportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	var expired []models.DataExport
+	for _, export := range r.exports {
+		if export.Status == models.ExportStatusCompleted && export.ExpiresAt.Valid && !export.ExpiresAt.Time.After(now) {
+			expired = append(expired, *export)
+		}
+	}
+	return expired, nil
+}
+
+func (r *fakeExportRepository) MarkExpired(ctx context.Context, id int) error {
+	r.exports[id].Status = models.ExportStatusExpired
+	r.exports[id].FilePath = sql.NullString{}
+	r.expired = append(r.expired, id)
+	return nil
+}
+
+func newJanitorTestService(t *testing.T, exports map[int]*models.DataExport) (*exportService, *fakeExportRepository, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo := &fakeExportRepository{exports: exports}
+
+	return &exportService{
+		cfg:        &config.Config{Export: config.ExportConfig{StorageDir: dir, TimeoutMinutes: 10}},
+		exportRepo: repo,
+		clock:      fixedClock{now: testNow},
+		jobs:       make(chan int, exportQueueSize),
+		ctx:        context.Background(),
+	}, repo, dir
+}
+
+func writeExportFile(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("zip"), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func storedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestSweepRemovesOrphanedFiles(t *testing.T) {
+	exports := map[int]*models.DataExport{
+		// Masih bisa diunduh
+		1: {ID: 1, UserID: 1, Status: models.ExportStatusCompleted},
+		// Sedang dibuat, file nya belum tercatat
+		2: {ID: 2, UserID: 1, Status: models.ExportStatusProcessing},
+		// Link sudah kedaluwarsa
+		3: {ID: 3, UserID: 1, Status: models.ExportStatusCompleted, ExpiresAt: sql.NullTime{Time: testNow.Add(-time.Hour), Valid: true}},
+		// Gagal dengan file setengah jadi yang tertinggal
+		4: {ID: 4, UserID: 1, Status: models.ExportStatusFailed},
+	}
+	s, repo, dir := newJanitorTestService(t, exports)
+
+	exports[1].FilePath = sql.NullString{String: writeExportFile(t, dir, exportFileName(1, "aaaa")), Valid: true}
+	writeExportFile(t, dir, exportFileName(2, "bbbb"))
+	exports[3].FilePath = sql.NullString{String: writeExportFile(t, dir, exportFileName(3, "cccc")), Valid: true}
+	writeExportFile(t, dir, exportFileName(4, "dddd"))
+	// Akun pemilik sudah dihapus permanen, baris export ikut terhapus lewat ON DELETE CASCADE
+	writeExportFile(t, dir, exportFileName(5, "eeee"))
+	// File lain di folder penyimpanan tidak disentuh
+	writeExportFile(t, dir, "README.txt")
+
+	s.sweep()
+
+	want := []string{"README.txt", exportFileName(1, "aaaa"), exportFileName(2, "bbbb")}
+	if got := storedFiles(t, dir); !slices.Equal(got, want) {
+		t.Fatalf("files after sweep = %v, want %v", got, want)
+	}
+	if len(repo.expired) != 1 || repo.expired[0] != 3 {
+		t.Fatalf("expired = %v, want [3]", repo.expired)
+	}
+}
+
+func TestParseExportFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		wantID int
+		wantOK bool
+	}{
+		{name: exportFileName(42, "0123abcd"), wantID: 42, wantOK: true},
+		{name: "export-42.zip"},
+		{name: "export-x-0123.zip"},
+		{name: "export-0-0123.zip"},
+		{name: "export-42-0123.tmp"},
+		{name: "backup-42-0123.zip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := parseExportFileName(tt.name)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Fatalf("parseExportFileName(%q) = %d, %v, want %d, %v", tt.name, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
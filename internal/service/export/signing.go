@@ -0,0 +1,36 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"future-letter/internal/config"
+)
+
+// exportSigningKey memakai EXPORT_SIGNING_SECRET jika di isi. Jika tidak, kunci diturunkan
+// dari JWT secret dengan HMAC sehingga kunci JWT tidak dipakai langsung untuk keperluan lain
+func exportSigningKey(cfg *config.Config) []byte {
+	if cfg.Export.SigningSecret != "" {
+		return []byte(cfg.Export.SigningSecret)
+	}
+
+	mac := hmac.New(sha256.New, []byte(cfg.JWT.Secret))
+	mac.Write([]byte("data-export"))
+	return mac.Sum(nil)
+}
+
+// sign membuat tanda tangan HMAC-SHA256 untuk link download export.
+// Tanda tangan mengikat ID export dan waktu kedaluwarsa, sehingga link tidak bisa diubah ke export lain
+func (s *exportService) sign(exportID int, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "data-export:%d:%d", exportID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify membandingkan tanda tangan dengan waktu konstan
+func (s *exportService) verify(exportID int, expires int64, signature string) bool {
+	expected := s.sign(exportID, expires)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"future-letter/internal/config"
+)
+
+func newSigningService(jwtSecret, signingSecret string) *exportService {
+	cfg := &config.Config{
+		JWT:    config.JWTConfig{Secret: jwtSecret},
+		Export: config.ExportConfig{SigningSecret: signingSecret},
+	}
+	return &exportService{cfg: cfg, signingKey: exportSigningKey(cfg)}
+}
+
+func TestSignVerify(t *testing.T) {
+	s := newSigningService("jwt-secret", "")
+	signature := s.sign(42, 1900000000)
+
+	if !s.verify(42, 1900000000, signature) {
+		t.Fatal("verify rejected a valid signature")
+	}
+
+	tests := []struct {
+		name      string
+		exportID  int
+		expires   int64
+		signature string
+	}{
+		{"other export", 43, 1900000000, signature},
+		{"other expiry", 42, 1900000001, signature},
+		{"tampered signature", 42, 1900000000, signature[:len(signature)-1] + "x"},
+		{"empty signature", 42, 1900000000, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if s.verify(tt.exportID, tt.expires, tt.signature) {
+				t.Fatal("verify accepted an invalid signature")
+			}
+		})
+	}
+}
+
+func TestSignDoesNotUseJWTSecretDirectly(t *testing.T) {
+	s := newSigningService("jwt-secret", "")
+
+	mac := hmac.New(sha256.New, []byte("jwt-secret"))
+	fmt.Fprintf(mac, "data-export:%d:%d", 42, 1900000000)
+	withJWTKey := hex.EncodeToString(mac.Sum(nil))
+
+	if s.sign(42, 1900000000) == withJWTKey {
+		t.Fatal("export link signed with the JWT secret itself")
+	}
+}
+
+func TestExportSigningKey(t *testing.T) {
+	derived := newSigningService("jwt-secret", "")
+	explicit := newSigningService("jwt-secret", "export-secret")
+	rotatedJWT := newSigningService("rotated-jwt-secret", "export-secret")
+
+	signature := explicit.sign(1, 1900000000)
+
+	if derived.verify(1, 1900000000, signature) {
+		t.Fatal("derived key accepted a signature made with EXPORT_SIGNING_SECRET")
+	}
+	// Dengan EXPORT_SIGNING_SECRET, rotasi JWT secret tidak membatalkan link
+	if !rotatedJWT.verify(1, 1900000000, signature) {
+		t.Fatal("rotating JWT_SECRET invalidated export links signed with EXPORT_SIGNING_SECRET")
+	}
+}
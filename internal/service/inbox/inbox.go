@@ -0,0 +1,16 @@
+// Package service
+package service
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+type InboxService interface {
+	DeliverCapsule(ctx context.Context, capsule *models.Capsule) error
+	GetInbox(ctx context.Context, userID int, unreadOnly bool) ([]models.InboxMessage, error)
+	MarkAsRead(ctx context.Context, messageID, userID int) error
+	MarkAllAsRead(ctx context.Context, userID int) (int, error)
+	CountUnread(ctx context.Context, userID int) (int, error)
+}
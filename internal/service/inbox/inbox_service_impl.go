@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/models"
+	repository "future-letter/internal/repository/inbox"
+)
+
+type inboxService struct {
+	inboxRepo repository.InboxRepository
+	clock     clock.Clock
+}
+
+func NewInboxService(inboxRepo repository.InboxRepository, clock clock.Clock) InboxService {
+	return &inboxService{
+		inboxRepo: inboxRepo,
+		clock:     clock,
+	}
+}
+
+// DeliverCapsule memasukkan capsule ke inbox pemiliknya
+// Method ini dipanggil oleh scheduler untuk capsule dengan delivery method in_app
+func (s *inboxService) DeliverCapsule(ctx context.Context, capsule *models.Capsule) error {
+	err := s.inboxRepo.Create(ctx, capsule.UserID, capsule.ID, s.clock.Now())
+	if err != nil {
+		return fmt.Errorf("failed to deliver capsule %d to inbox: %w", capsule.ID, err)
+	}
+
+	return nil
+}
+
+// GetInbox mengambil isi inbox user
+func (s *inboxService) GetInbox(ctx context.Context, userID int, unreadOnly bool) ([]models.InboxMessage, error) {
+	messages, err := s.inboxRepo.GetByUserID(ctx, userID, unreadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inbox: %v", err)
+	}
+
+	return messages, nil
+}
+
+// MarkAsRead menandai pesan inbox sudah dibaca
+func (s *inboxService) MarkAsRead(ctx context.Context, messageID, userID int) error {
+	return s.inboxRepo.MarkAsRead(ctx, messageID, userID, s.clock.Now())
+}
+
+// MarkAllAsRead menandai semua pesan inbox sudah dibaca
+func (s *inboxService) MarkAllAsRead(ctx context.Context, userID int) (int, error) {
+	return s.inboxRepo.MarkAllAsRead(ctx, userID, s.clock.Now())
+}
+
+// CountUnread menghitung jumlah pesan yang belum dibaca
+func (s *inboxService) CountUnread(ctx context.Context, userID int) (int, error) {
+	return s.inboxRepo.CountUnread(ctx, userID)
+}
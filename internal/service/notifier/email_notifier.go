@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+	email "future-letter/internal/service/email"
+)
+
+// emailNotifier mengirim capsule lewat EmailService (SMTP)
+type emailNotifier struct {
+	emailService *email.EmailService
+}
+
+func NewEmailNotifier(emailService *email.EmailService) Notifier {
+	return &emailNotifier{
+		emailService: emailService,
+	}
+}
+
+func (n *emailNotifier) Channel() string {
+	return models.DeliveryMethodEmail
+}
+
+// Send mengirim email capsule. Deadline ctx dipasang langsung di koneksi SMTP,
+// jadi pengiriman berhenti saat timeout dan tidak terkirim dua kali ketika scheduler mencoba ulang
+func (n *emailNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	return n.emailService.SendCapsuleEmailContext(ctx, user, capsule)
+}
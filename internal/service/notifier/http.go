@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// maxErrorBodySize batas body response yang dimasukkan ke pesan error
+const maxErrorBodySize = 512
+
+func newHTTPClient(timeoutSeconds int) *http.Client {
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 10
+	}
+
+	return &http.Client{
+		Timeout: time.Duration(timeoutSeconds) * time.Second,
+	}
+}
+
+// postJSON mengirim payload JSON dan mengembalikan error jika status bukan 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+// plainTextMessage format capsule untuk channel berbasis teks (telegram, sms)
+func plainTextMessage(capsule *models.Capsule) string {
+	return fmt.Sprintf("Your Time Capsule Has Arrived!\n\n%s\n\n%s\n\nWritten on %s",
+		capsule.Title,
+		capsule.Message,
+		capsule.CreatedAt.Format("January 2, 2006"),
+	)
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+	inbox "future-letter/internal/service/inbox"
+)
+
+// inboxNotifier mengirim capsule ke inbox di aplikasi
+type inboxNotifier struct {
+	inboxService inbox.InboxService
+}
+
+func NewInboxNotifier(inboxService inbox.InboxService) Notifier {
+	return &inboxNotifier{
+		inboxService: inboxService,
+	}
+}
+
+func (n *inboxNotifier) Channel() string {
+	return models.DeliveryMethodInApp
+}
+
+func (n *inboxNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	return n.inboxService.DeliverCapsule(ctx, capsule)
+}
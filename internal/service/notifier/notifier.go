@@ -0,0 +1,73 @@
+// Package service notifier berisi channel pengiriman capsule
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"future-letter/internal/models"
+)
+
+// Notifier channel yang bisa mengirim capsule ke user
+// Channel() harus sama dengan nilai delivery_method di capsule
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, user *models.User, capsule *models.Capsule) error
+}
+
+// Registry menyimpan notifier berdasarkan delivery method
+type Registry struct {
+	mu        sync.RWMutex
+	notifiers map[string]Notifier
+}
+
+// NewRegistry membuat registry baru dan mendaftarkan notifier yang diberikan
+func NewRegistry(notifiers ...Notifier) *Registry {
+	registry := &Registry{
+		notifiers: make(map[string]Notifier),
+	}
+
+	for _, notifier := range notifiers {
+		registry.Register(notifier)
+	}
+
+	return registry
+}
+
+// Register mendaftarkan notifier, notifier dengan channel yang sama akan ditimpa
+func (r *Registry) Register(notifier Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifiers[notifier.Channel()] = notifier
+}
+
+// Get mengambil notifier berdasarkan delivery method
+func (r *Registry) Get(channel string) (Notifier, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifier, ok := r.notifiers[channel]
+	return notifier, ok
+}
+
+// Has mengecek apakah delivery method sudah terdaftar
+func (r *Registry) Has(channel string) bool {
+	_, ok := r.Get(channel)
+	return ok
+}
+
+// Channels mengembalikan semua delivery method yang terdaftar (urut abjad)
+func (r *Registry) Channels() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	channels := make([]string, 0, len(r.notifiers))
+	for channel := range r.notifiers {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	return channels
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"future-letter/internal/models"
+)
+
+type fakeNotifier struct {
+	channel string
+}
+
+func (n *fakeNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *fakeNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	return nil
+}
+
+func TestRegistryGet(t *testing.T) {
+	registry := NewRegistry(&fakeNotifier{channel: models.DeliveryMethodEmail})
+
+	tests := []struct {
+		name    string
+		channel string
+		found   bool
+	}{
+		{name: "registered channel", channel: models.DeliveryMethodEmail, found: true},
+		{name: "unknown channel", channel: "carrier_pigeon", found: false},
+		{name: "empty channel", channel: "", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier, ok := registry.Get(tt.channel)
+			if ok != tt.found {
+				t.Fatalf("Get(%q) found = %v, want %v", tt.channel, ok, tt.found)
+			}
+			if !tt.found && notifier != nil {
+				t.Fatalf("Get(%q) returned notifier for unknown channel", tt.channel)
+			}
+			if registry.Has(tt.channel) != tt.found {
+				t.Fatalf("Has(%q) = %v, want %v", tt.channel, !tt.found, tt.found)
+			}
+		})
+	}
+}
+
+func TestRegistryRegisterReplacesChannel(t *testing.T) {
+	first := &fakeNotifier{channel: "webhook"}
+	second := &fakeNotifier{channel: "webhook"}
+
+	registry := NewRegistry(first)
+	registry.Register(second)
+
+	notifier, ok := registry.Get("webhook")
+	if !ok || notifier != second {
+		t.Fatalf("Register did not replace notifier for existing channel")
+	}
+	if channels := registry.Channels(); len(channels) != 1 {
+		t.Fatalf("Channels() = %v, want one channel", channels)
+	}
+}
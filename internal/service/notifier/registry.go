@@ -0,0 +1,35 @@
+package service
+
+import (
+	"log"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/config"
+	email "future-letter/internal/service/email"
+	inbox "future-letter/internal/service/inbox"
+)
+
+// NewDefaultRegistry membuat registry dengan semua channel bawaan
+// email dan in_app selalu aktif, channel HTTP hanya aktif jika konfigurasinya di isi
+func NewDefaultRegistry(cfg *config.Config, emailService *email.EmailService, inboxService inbox.InboxService, clock clock.Clock) *Registry {
+	registry := NewRegistry(
+		NewEmailNotifier(emailService),
+		NewInboxNotifier(inboxService),
+	)
+
+	if cfg.Webhook.URL != "" {
+		registry.Register(NewWebhookNotifier(cfg.Webhook, clock))
+	}
+
+	if cfg.Telegram.BotToken != "" {
+		registry.Register(NewTelegramNotifier(cfg.Telegram))
+	}
+
+	if cfg.SMS.APIURL != "" {
+		registry.Register(NewSMSNotifier(cfg.SMS))
+	}
+
+	log.Printf("Delivery channels enabled: %v", registry.Channels())
+
+	return registry
+}
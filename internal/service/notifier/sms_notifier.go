@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+)
+
+const DeliveryMethodSMS = "sms"
+
+// smsNotifier mengirim capsule lewat HTTP API SMS gateway
+type smsNotifier struct {
+	cfg    config.SMSConfig
+	client *http.Client
+}
+
+func NewSMSNotifier(cfg config.SMSConfig) Notifier {
+	return &smsNotifier{
+		cfg:    cfg,
+		client: newHTTPClient(cfg.TimeoutSeconds),
+	}
+}
+
+func (n *smsNotifier) Channel() string {
+	return DeliveryMethodSMS
+}
+
+func (n *smsNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	if !user.PhoneNumber.Valid || user.PhoneNumber.String == "" {
+		return errors.New("user has no phone number")
+	}
+
+	headers := map[string]string{
+		"Idempotency-Key": capsule.IdempotencyKey(),
+	}
+	if n.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + n.cfg.APIKey
+	}
+
+	payload := map[string]any{
+		"from":    n.cfg.From,
+		"to":      user.PhoneNumber.String,
+		"message": plainTextMessage(capsule),
+	}
+
+	err := postJSON(ctx, n.client, n.cfg.APIURL, headers, payload)
+	if err != nil {
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+
+	return nil
+}
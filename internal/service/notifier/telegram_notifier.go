@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+)
+
+const DeliveryMethodTelegram = "telegram"
+
+// telegramNotifier mengirim capsule lewat Telegram Bot API (sendMessage)
+type telegramNotifier struct {
+	cfg    config.TelegramConfig
+	client *http.Client
+}
+
+func NewTelegramNotifier(cfg config.TelegramConfig) Notifier {
+	return &telegramNotifier{
+		cfg:    cfg,
+		client: newHTTPClient(cfg.TimeoutSeconds),
+	}
+}
+
+func (n *telegramNotifier) Channel() string {
+	return DeliveryMethodTelegram
+}
+
+func (n *telegramNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	if !user.TelegramChatID.Valid || user.TelegramChatID.String == "" {
+		return errors.New("user has no telegram chat id")
+	}
+
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(n.cfg.APIURL, "/"), n.cfg.BotToken)
+
+	payload := map[string]any{
+		"chat_id": user.TelegramChatID.String,
+		"text":    plainTextMessage(capsule),
+	}
+
+	err := postJSON(ctx, n.client, url, nil, payload)
+	if err != nil {
+		// Jangan tampilkan URL karena berisi bot token
+		return fmt.Errorf("failed to send telegram message: %s", strings.ReplaceAll(err.Error(), n.cfg.BotToken, "***"))
+	}
+
+	return nil
+}
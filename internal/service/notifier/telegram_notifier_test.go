@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"future-letter/internal/config"
+)
+
+const testBotToken = "123456:SECRET-BOT-TOKEN"
+
+func TestTelegramNotifierSend(t *testing.T) {
+	var (
+		path    string
+		payload map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	notifier := NewTelegramNotifier(config.TelegramConfig{APIURL: server.URL + "/", BotToken: testBotToken})
+
+	user := testUser()
+	user.TelegramChatID = sql.NullString{String: "999", Valid: true}
+
+	if err := notifier.Send(context.Background(), user, testCapsule()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if path != "/bot"+testBotToken+"/sendMessage" {
+		t.Errorf("path = %q", path)
+	}
+	if payload["chat_id"] != "999" {
+		t.Errorf("chat_id = %v, want 999", payload["chat_id"])
+	}
+}
+
+func TestTelegramNotifierRequiresChatID(t *testing.T) {
+	notifier := NewTelegramNotifier(config.TelegramConfig{APIURL: "http://127.0.0.1:1", BotToken: testBotToken})
+
+	if err := notifier.Send(context.Background(), testUser(), testCapsule()); err == nil {
+		t.Fatal("Send() error = nil, want error for user without chat id")
+	}
+}
+
+func TestTelegramNotifierRedactsToken(t *testing.T) {
+	// Server yang memantulkan path (berisi token) di body error
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request for "+r.URL.Path, http.StatusBadRequest)
+	}))
+	defer echo.Close()
+
+	// Server yang sudah ditutup, error transport berisi URL lengkap
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedURL := closed.URL
+	closed.Close()
+
+	tests := []struct {
+		name   string
+		apiURL string
+	}{
+		{name: "non-2xx response echoing path", apiURL: echo.URL},
+		{name: "connection error", apiURL: closedURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifier := NewTelegramNotifier(config.TelegramConfig{APIURL: tt.apiURL, BotToken: testBotToken, TimeoutSeconds: 2})
+
+			user := testUser()
+			user.TelegramChatID = sql.NullString{String: "999", Valid: true}
+
+			err := notifier.Send(context.Background(), user, testCapsule())
+			if err == nil {
+				t.Fatal("Send() error = nil, want error")
+			}
+			if strings.Contains(err.Error(), testBotToken) {
+				t.Errorf("error leaks bot token: %q", err)
+			}
+			if !strings.Contains(err.Error(), "***") {
+				t.Errorf("error %q was not redacted", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+)
+
+const DeliveryMethodWebhook = "webhook"
+
+// webhookNotifier mengirim capsule sebagai JSON ke URL webhook yang dikonfigurasi
+type webhookNotifier struct {
+	cfg    config.WebhookConfig
+	client *http.Client
+	clock  clock.Clock
+}
+
+func NewWebhookNotifier(cfg config.WebhookConfig, clock clock.Clock) Notifier {
+	return &webhookNotifier{
+		cfg:    cfg,
+		client: newHTTPClient(cfg.TimeoutSeconds),
+		clock:  clock,
+	}
+}
+
+// webhookPayload body yang dikirim ke webhook
+type webhookPayload struct {
+	Event   string                  `json:"event"`
+	SentAt  time.Time               `json:"sent_at"`
+	User    *models.UserResponse    `json:"user"`
+	Capsule *models.CapsuleResponse `json:"capsule"`
+}
+
+func (n *webhookNotifier) Channel() string {
+	return DeliveryMethodWebhook
+}
+
+func (n *webhookNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	payload := webhookPayload{
+		Event:   "capsule.delivered",
+		SentAt:  n.clock.Now().UTC(),
+		User:    user.ToResponse(),
+		Capsule: capsule.ToResponse(),
+	}
+
+	headers := map[string]string{
+		"Idempotency-Key": capsule.IdempotencyKey(),
+	}
+
+	// Jika secret di isi, sertakan signature HMAC-SHA256 agar penerima bisa memverifikasi
+	if n.cfg.Secret != "" {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode webhook payload: %w", err)
+		}
+
+		timestamp := strconv.FormatInt(payload.SentAt.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(n.cfg.Secret))
+		mac.Write([]byte(timestamp + "." + string(body)))
+
+		headers["X-Webhook-Timestamp"] = timestamp
+		headers["X-Webhook-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		return n.post(ctx, headers, json.RawMessage(body))
+	}
+
+	return n.post(ctx, headers, payload)
+}
+
+func (n *webhookNotifier) post(ctx context.Context, headers map[string]string, payload any) error {
+	err := postJSON(ctx, n.client, n.cfg.URL, headers, payload)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+)
+
+// fixedClock clock yang selalu mengembalikan waktu yang sama
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+var testNow = time.Date(2031, 6, 1, 9, 30, 0, 0, time.UTC)
+
+func testCapsule() *models.Capsule {
+	return &models.Capsule{
+		ID:        42,
+		UserID:    7,
+		Title:     "Hello future me",
+		Message:   "Remember this day",
+		DueDate:   time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC),
+		CreatedAt: time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+func testUser() *models.User {
+	return &models.User{ID: 7, Name: "Tester", Email: "tester@example.com"}
+}
+
+func TestWebhookNotifierSignsPayload(t *testing.T) {
+	const secret = "webhook-secret"
+
+	var (
+		body    []byte
+		headers http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(config.WebhookConfig{URL: server.URL, Secret: secret, TimeoutSeconds: 5}, fixedClock{now: testNow})
+
+	if err := notifier.Send(context.Background(), testUser(), testCapsule()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if got := headers.Get("Idempotency-Key"); got != "capsule-42" {
+		t.Errorf("Idempotency-Key = %q, want %q", got, "capsule-42")
+	}
+
+	// Timestamp berasal dari clock yang diinject, bukan waktu sistem
+	timestamp := headers.Get("X-Webhook-Timestamp")
+	if want := strconv.FormatInt(testNow.Unix(), 10); timestamp != want {
+		t.Fatalf("X-Webhook-Timestamp = %q, want %q", timestamp, want)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := headers.Get("X-Webhook-Signature"); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload is not valid JSON: %v", err)
+	}
+	if payload.Event != "capsule.delivered" || payload.Capsule.ID != 42 || !payload.SentAt.Equal(testNow) {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+}
+
+func TestWebhookNotifierWithoutSecret(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(config.WebhookConfig{URL: server.URL}, fixedClock{now: testNow})
+
+	if err := notifier.Send(context.Background(), testUser(), testCapsule()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if headers.Get("X-Webhook-Signature") != "" {
+		t.Error("signature header sent without secret")
+	}
+}
+
+func TestWebhookNotifierNon2xx(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "client error", status: http.StatusBadRequest},
+		{name: "server error", status: http.StatusInternalServerError},
+		{name: "not modified", status: http.StatusNotModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, "receiver says no")
+			}))
+			defer server.Close()
+
+			notifier := NewWebhookNotifier(config.WebhookConfig{URL: server.URL, Secret: "s"}, fixedClock{now: testNow})
+
+			err := notifier.Send(context.Background(), testUser(), testCapsule())
+			if err == nil {
+				t.Fatalf("Send() error = nil, want error for status %d", tt.status)
+			}
+			if !strings.Contains(err.Error(), "unexpected status") {
+				t.Errorf("error %q does not mention status", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResponseSize batas body response dari provider yang dibaca
+const maxResponseSize = 1 << 20
+
+// providerMetadata bagian dari dokumen discovery OpenID yang dipakai
+type providerMetadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+// supportsAuthMethod mengecek metode autentikasi client di token endpoint.
+// Sesuai spesifikasi, jika tidak diumumkan default nya client_secret_basic
+func (m *providerMetadata) supportsAuthMethod(method string) bool {
+	if len(m.TokenAuthMethods) == 0 {
+		return method == "client_secret_basic"
+	}
+
+	for _, supported := range m.TokenAuthMethods {
+		if supported == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// discover mengambil dokumen .well-known/openid-configuration milik issuer
+func discover(ctx context.Context, client *http.Client, issuer string) (*providerMetadata, error) {
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	metadata := &providerMetadata{}
+	if err := getJSON(ctx, client, discoveryURL, metadata); err != nil {
+		return nil, fmt.Errorf("failed to fetch openid configuration: %w", err)
+	}
+
+	// Issuer di dokumen harus sama persis dengan yang dikonfigurasi
+	if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("issuer mismatch: expected %s, got %s", issuer, metadata.Issuer)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("openid configuration is missing required endpoints")
+	}
+
+	return metadata, nil
+}
+
+// getJSON melakukan GET dan decode body JSON, error jika status bukan 2xx
+func getJSON(ctx context.Context, client *http.Client, url string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, dest); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
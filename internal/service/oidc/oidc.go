@@ -0,0 +1,20 @@
+// Package service
+package service
+
+import (
+	"context"
+
+	"future-letter/internal/models"
+)
+
+// OIDCService menjalankan alur authorization code + PKCE ke provider OpenID Connect
+type OIDCService interface {
+	// ProviderName nama provider yang disimpan di user_identities
+	ProviderName() string
+	// AuthCodeURL membuat state baru dan mengembalikan URL halaman login provider beserta state nya.
+	// State disimpan pemanggil di browser (cookie) agar callback terikat ke browser yang memulai login
+	AuthCodeURL(ctx context.Context) (authURL string, state string, err error)
+	// Exchange menukar code dari callback dengan ID token lalu memverifikasinya.
+	// browserState state yang disimpan di browser saat login dimulai, harus sama dengan state dari callback
+	Exchange(ctx context.Context, state, code, browserState string) (*models.ExternalIdentity, error)
+}
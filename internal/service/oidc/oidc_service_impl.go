@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+	repository "future-letter/internal/repository/oauth"
+	"future-letter/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// idTokenLeeway toleransi perbedaan jam dengan provider saat memvalidasi exp dan iat
+const idTokenLeeway = time.Minute
+
+type oidcService struct {
+	cfg       config.OIDCConfig
+	stateRepo repository.OAuthStateRepository
+	client    *http.Client
+	clock     clock.Clock
+
+	// metadata dan key set diambil saat pertama dibutuhkan,
+	// agar aplikasi tetap bisa start walau provider sedang tidak bisa dihubungi
+	mu       sync.Mutex
+	metadata *providerMetadata
+	keys     *keySet
+}
+
+func NewOIDCService(cfg config.OIDCConfig, stateRepo repository.OAuthStateRepository, clock clock.Clock) OIDCService {
+	timeout := cfg.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = 10
+	}
+
+	return &oidcService{
+		cfg:       cfg,
+		stateRepo: stateRepo,
+		client:    &http.Client{Timeout: time.Duration(timeout) * time.Second},
+		clock:     clock,
+	}
+}
+
+func (s *oidcService) ProviderName() string {
+	return s.cfg.Provider
+}
+
+// provider mengembalikan metadata dan key set provider, discovery hanya dijalankan sekali
+func (s *oidcService) provider(ctx context.Context) (*providerMetadata, *keySet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metadata != nil {
+		return s.metadata, s.keys, nil
+	}
+
+	metadata, err := discover(ctx, s.client, s.cfg.Issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.metadata = metadata
+	s.keys = newKeySet(s.client, metadata.JWKSURI)
+	return s.metadata, s.keys, nil
+}
+
+// AuthCodeURL membuat state, PKCE code verifier dan nonce baru lalu menyimpannya.
+// Yang dikirim ke provider hanya code challenge (S256) dan state asli
+func (s *oidcService) AuthCodeURL(ctx context.Context) (string, string, error) {
+	metadata, _, err := s.provider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := s.clock.Now()
+
+	// Bersihkan state lama yang tidak pernah diselesaikan, kegagalan di sini tidak menghentikan login
+	if err := s.stateRepo.DeleteExpired(ctx, now); err != nil {
+		log.Printf("Failed to delete expired oauth states: %v", err)
+	}
+
+	err = s.stateRepo.Create(ctx, &models.OAuthState{
+		StateHash:    utils.HashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    now.Add(time.Duration(s.cfg.StateTTLMinutes) * time.Minute),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", s.cfg.ClientID)
+	params.Set("redirect_uri", s.cfg.RedirectURL)
+	params.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), state, nil
+}
+
+// Exchange memakai state (sekali pakai), menukar code di token endpoint
+// lalu memverifikasi ID token yang dikembalikan provider.
+// State dari callback harus sama dengan state di browser, mencegah login CSRF
+// (penyerang mengirim URL callback milik nya ke korban)
+func (s *oidcService) Exchange(ctx context.Context, state, code, browserState string) (*models.ExternalIdentity, error) {
+	if state == "" || code == "" {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, errors.New("invalid or expired state")
+	}
+
+	savedState, err := s.stateRepo.Consume(ctx, utils.HashToken(state), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, keys, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := s.exchangeCode(ctx, metadata, code, savedState.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.verifyIDToken(ctx, metadata, keys, rawIDToken, savedState.Nonce)
+	if err != nil {
+		log.Printf("Failed to verify id token from %s: %v", s.cfg.Provider, err)
+		return nil, errors.New("invalid id token")
+	}
+
+	return &models.ExternalIdentity{
+		Provider:      s.cfg.Provider,
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// exchangeCode memanggil token endpoint dengan code dan PKCE code verifier
+func (s *oidcService) exchangeCode(ctx context.Context, metadata *providerMetadata, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	useBasicAuth := s.cfg.ClientSecret != "" && metadata.supportsAuthMethod("client_secret_basic")
+	if !useBasicAuth {
+		// Public client (tanpa secret) atau provider yang hanya menerima client_secret_post
+		form.Set("client_id", s.cfg.ClientID)
+		if s.cfg.ClientSecret != "" {
+			form.Set("client_secret", s.cfg.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if useBasicAuth {
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokenResponse struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to decode token response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || tokenResponse.Error != "" {
+		log.Printf("OIDC token exchange failed with status %d: %s %s", resp.StatusCode, tokenResponse.Error, tokenResponse.ErrorDescription)
+		return "", errors.New("failed to exchange authorization code")
+	}
+
+	if tokenResponse.IDToken == "" {
+		return "", errors.New("provider did not return an id token")
+	}
+
+	return tokenResponse.IDToken, nil
+}
+
+// idTokenClaims claim ID token yang dipakai
+type idTokenClaims struct {
+	Email         string       `json:"email"`
+	EmailVerified flexibleBool `json:"email_verified"`
+	Name          string       `json:"name"`
+	Nonce         string       `json:"nonce"`
+	AuthorizedBy  string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// verifyIDToken memvalidasi signature (JWKS provider), issuer, audience, exp dan nonce
+func (s *oidcService) verifyIDToken(ctx context.Context, metadata *providerMetadata, keys *keySet, rawIDToken, nonce string) (*idTokenClaims, error) {
+	claims := &idTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			return keys.get(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(s.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	// Token untuk beberapa audience wajib menyebut client ini di azp
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != s.cfg.ClientID {
+		return nil, errors.New("id token authorized party mismatch")
+	}
+
+	if claims.Nonce != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+// codeChallenge PKCE S256: base64url(sha256(verifier))
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// flexibleBool beberapa provider mengirim email_verified sebagai string "true"
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+
+	return nil
+}
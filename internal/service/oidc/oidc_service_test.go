@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"future-letter/internal/clock"
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "future-letter-test"
+	testKeyID    = "test-key"
+)
+
+// memoryStateRepository OAuthStateRepository di memory untuk pengujian
+type memoryStateRepository struct {
+	mu     sync.Mutex
+	states map[string]*models.OAuthState
+}
+
+func newMemoryStateRepository() *memoryStateRepository {
+	return &memoryStateRepository{states: make(map[string]*models.OAuthState)}
+}
+
+func (r *memoryStateRepository) Create(ctx context.Context, state *models.OAuthState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *memoryStateRepository) Consume(ctx context.Context, stateHash string, now time.Time) (*models.OAuthState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[stateHash]
+	if !ok || !state.ExpiresAt.After(now) {
+		return nil, errors.New("invalid or expired state")
+	}
+	delete(r.states, stateHash)
+
+	return state, nil
+}
+
+func (r *memoryStateRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+// authorization request yang diterima mock IdP dari URL login
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+// mockIdP provider OIDC palsu: discovery, JWKS dan token endpoint yang memeriksa PKCE
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]authorization
+
+	// Pengubah perilaku untuk kasus negatif
+	nonceOverride    string
+	kidOverride      string
+	audienceOverride string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idp := &mockIdP{key: key, codes: make(map[string]authorization)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/jwks",
+			"token_endpoint_auth_methods_supported": []string{"none"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": testKeyID,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+// authorize meniru user yang login di halaman provider dan mengembalikan code untuk callback
+func (idp *mockIdP) authorize(t *testing.T, authURL string) (string, string) {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL without S256 PKCE challenge: %s", authURL)
+	}
+	if query.Get("nonce") == "" || query.Get("state") == "" {
+		t.Fatalf("authorization URL without nonce or state: %s", authURL)
+	}
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	code := "code-" + query.Get("state")[:8]
+	idp.codes[code] = authorization{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+
+	return query.Get("state"), code
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idp.mu.Lock()
+	auth, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge || r.PostForm.Get("client_id") != testClientID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	nonce := auth.nonce
+	if idp.nonceOverride != "" {
+		nonce = idp.nonceOverride
+	}
+	audience := testClientID
+	if idp.audienceOverride != "" {
+		audience = idp.audienceOverride
+	}
+	kid := testKeyID
+	if idp.kidOverride != "" {
+		kid = idp.kidOverride
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            audience,
+		"sub":            "subject-123",
+		"email":          "User@Example.com",
+		"email_verified": "true",
+		"name":           "Test User",
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+func newTestOIDCService(idp *mockIdP) OIDCService {
+	return NewOIDCService(config.OIDCConfig{
+		Provider:        "mock",
+		Issuer:          idp.server.URL,
+		ClientID:        testClientID,
+		RedirectURL:     "http://localhost/api/v1/auth/oidc/callback",
+		Scopes:          []string{"openid", "email"},
+		StateTTLMinutes: 10,
+		TimeoutSeconds:  5,
+	}, newMemoryStateRepository(), clock.System())
+}
+
+func TestOIDCExchangeSuccess(t *testing.T) {
+	idp := newMockIdP(t)
+	svc := newTestOIDCService(idp)
+	ctx := context.Background()
+
+	authURL, browserState, err := svc.AuthCodeURL(ctx)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	state, code := idp.authorize(t, authURL)
+	if state != browserState {
+		t.Fatalf("state in URL %q differs from state returned for the browser %q", state, browserState)
+	}
+
+	identity, err := svc.Exchange(ctx, state, code, browserState)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	if identity.Subject != "subject-123" || identity.Email != "user@example.com" || !identity.EmailVerified {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	// State sekali pakai
+	if _, err := svc.Exchange(ctx, state, code, browserState); err == nil || err.Error() != "invalid or expired state" {
+		t.Fatalf("replayed Exchange() error = %v, want invalid or expired state", err)
+	}
+}
+
+func TestOIDCExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(idp *mockIdP)
+		browserState func(state string) string
+		tamperCode   bool
+		wantErr      string
+	}{
+		{
+			name:         "callback without browser cookie (login CSRF)",
+			browserState: func(string) string { return "" },
+			wantErr:      "invalid or expired state",
+		},
+		{
+			name:         "callback from another browser",
+			browserState: func(string) string { return "attacker-state" },
+			wantErr:      "invalid or expired state",
+		},
+		{
+			name:    "nonce mismatch",
+			setup:   func(idp *mockIdP) { idp.nonceOverride = "replayed-nonce" },
+			wantErr: "invalid id token",
+		},
+		{
+			name:    "unknown signing key",
+			setup:   func(idp *mockIdP) { idp.kidOverride = "rotated-key" },
+			wantErr: "invalid id token",
+		},
+		{
+			name:    "token for another client",
+			setup:   func(idp *mockIdP) { idp.audienceOverride = "other-client" },
+			wantErr: "invalid id token",
+		},
+		{
+			name:       "code not issued for this PKCE verifier",
+			tamperCode: true,
+			wantErr:    "failed to exchange authorization code",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			if tt.setup != nil {
+				tt.setup(idp)
+			}
+			svc := newTestOIDCService(idp)
+			ctx := context.Background()
+
+			authURL, browserState, err := svc.AuthCodeURL(ctx)
+			if err != nil {
+				t.Fatalf("AuthCodeURL() error = %v", err)
+			}
+
+			state, code := idp.authorize(t, authURL)
+			if tt.browserState != nil {
+				browserState = tt.browserState(state)
+			}
+			if tt.tamperCode {
+				// Code milik challenge lain, verifier kita tidak cocok
+				idp.mu.Lock()
+				idp.codes[code] = authorization{challenge: codeChallenge("another-verifier"), nonce: idp.codes[code].nonce}
+				idp.mu.Unlock()
+			}
+
+			_, err = svc.Exchange(ctx, state, code, browserState)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCodeChallengeRFC7636(t *testing.T) {
+	// Contoh dari RFC 7636 Appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	want := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := codeChallenge(verifier); got != want {
+		t.Fatalf("codeChallenge() = %s, want %s", got, want)
+	}
+}
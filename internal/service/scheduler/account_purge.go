@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// accountPurgeBatchSize jumlah akun yang dihapus dalam satu query agar lock tabel tidak terlalu lama
+const accountPurgeBatchSize = 100
+
+// purgeDeletedAccounts menghapus permanen akun yang masa tenggang penghapusannya sudah habis.
+// Query DELETE aman dijalankan bersamaan di beberapa replica, jadi tidak perlu lock scheduler
+func (s *schedulerService) purgeDeletedAccounts() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := s.clock.Now().UTC()
+
+	total := 0
+	for ctx.Err() == nil {
+		deleted, err := s.userRepo.DeleteScheduled(ctx, now, accountPurgeBatchSize)
+		if err != nil {
+			log.Printf("Failed to purge deleted accounts: %v", err)
+			break
+		}
+
+		total += deleted
+		if deleted < accountPurgeBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Purged %d account(s) after deletion grace period", total)
+	}
+}
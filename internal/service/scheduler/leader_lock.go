@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// deliveryLockName nama lock untuk job pengiriman capsule
+const deliveryLockName = "capsule-delivery"
+
+// minLockLease batas bawah lease agar interval perpanjangan (ttl/3) tidak nol
+const minLockLease = 3 * time.Second
+
+// defaultInstanceID identitas replica jika SCHEDULER_INSTANCE_ID tidak di isi
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// acquireRunLock mengambil lock di database agar hanya satu replica yang mengirim capsule.
+// Selama run berjalan lock diperpanjang di background, jika lock hilang context run dibatalkan.
+// Fungsi release yang dikembalikan wajib dipanggil setelah run selesai
+func (s *schedulerService) acquireRunLock(ctx context.Context) (context.Context, func(), error) {
+	ttl := time.Duration(s.cfg.Schedular.LockLeaseSeconds) * time.Second
+	if ttl < minLockLease {
+		ttl = minLockLease
+	}
+
+	acquired, err := s.lockRepo.TryAcquire(ctx, deliveryLockName, s.instanceID, time.Now(), ttl)
+	if err != nil {
+		log.Printf("Failed to acquire scheduler lock: %v", err)
+		return ctx, func() {}, err
+	}
+
+	if !acquired {
+		if lock, err := s.lockRepo.Get(ctx, deliveryLockName); err == nil {
+			log.Printf("Scheduler lock held by %s until %s, skipping this run", lock.Holder, lock.ExpiresAt.Format(time.RFC3339))
+		} else {
+			log.Println("Scheduler lock held by another instance, skipping this run")
+		}
+		return ctx, func() {}, errors.New("scheduler lock held by another instance")
+	}
+
+	log.Printf("Scheduler lock acquired by %s", s.instanceID)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	// Perpanjang lock setiap sepertiga ttl
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				renewed, err := s.lockRepo.Renew(runCtx, deliveryLockName, s.instanceID, time.Now(), ttl)
+				if err != nil {
+					log.Printf("Failed to renew scheduler lock: %v", err)
+					continue
+				}
+				if !renewed {
+					log.Printf("Scheduler lock lost by %s, stopping run", s.instanceID)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		close(done)
+		cancel()
+
+		// Gunakan context baru karena context run bisa sudah dibatalkan
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer releaseCancel()
+
+		if err := s.lockRepo.Release(releaseCtx, deliveryLockName, s.instanceID); err != nil {
+			log.Printf("Failed to release scheduler lock: %v", err)
+			return
+		}
+		log.Printf("Scheduler lock released by %s", s.instanceID)
+	}
+
+	return runCtx, release, nil
+}
+
+// LockStatus mengembalikan siapa yang memegang lock scheduler saat ini
+func (s *schedulerService) LockStatus(ctx context.Context) (*models.SchedulerLock, error) {
+	return s.lockRepo.Get(ctx, deliveryLockName)
+}
+
+// InstanceID identitas replica ini pada lock scheduler
+func (s *schedulerService) InstanceID() string {
+	return s.instanceID
+}
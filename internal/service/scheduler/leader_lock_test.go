@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// memoryLockRepository lease lock di memory dengan aturan yang sama seperti lockRepository MySQL
+type memoryLockRepository struct {
+	mu    sync.Mutex
+	locks map[string]*models.SchedulerLock
+	// renewFails membuat Renew selalu gagal, seolah lock diambil replica lain
+	renewFails bool
+}
+
+func newMemoryLockRepository() *memoryLockRepository {
+	return &memoryLockRepository{locks: make(map[string]*models.SchedulerLock)}
+}
+
+func (r *memoryLockRepository) TryAcquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lock, ok := r.locks[name]
+	if ok && lock.Holder != holder && lock.IsHeld(now) {
+		return false, nil
+	}
+
+	acquiredAt := now
+	if ok && lock.Holder == holder {
+		acquiredAt = lock.AcquiredAt
+	}
+	r.locks[name] = &models.SchedulerLock{Name: name, Holder: holder, AcquiredAt: acquiredAt, RenewedAt: now, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *memoryLockRepository) Renew(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lock, ok := r.locks[name]
+	if r.renewFails || !ok || lock.Holder != holder || lock.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	lock.RenewedAt = now
+	lock.ExpiresAt = now.Add(ttl)
+	return true, nil
+}
+
+func (r *memoryLockRepository) Release(ctx context.Context, name, holder string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if lock, ok := r.locks[name]; ok && lock.Holder == holder {
+		lock.ExpiresAt = lock.RenewedAt
+	}
+	return nil
+}
+
+func (r *memoryLockRepository) Get(ctx context.Context, name string) (*models.SchedulerLock, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lock, ok := r.locks[name]
+	if !ok {
+		return nil, errors.New("lock not found")
+	}
+	copied := *lock
+	return &copied, nil
+}
+
+// expire membuat lease habis tanpa dilepas, seperti replica yang mati di tengah run
+func (r *memoryLockRepository) expire(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locks[name].ExpiresAt = time.Now().Add(-time.Second)
+}
+
+// newLockTestReplica scheduler dengan instanceID tertentu yang berbagi lock repository
+func newLockTestReplica(instanceID string, locks *memoryLockRepository) *schedulerService {
+	cfg := testSchedulerConfig()
+	cfg.Schedular.LockLeaseSeconds = 60
+
+	return &schedulerService{cfg: cfg, lockRepo: locks, instanceID: instanceID}
+}
+
+func TestAcquireRunLock(t *testing.T) {
+	locks := newMemoryLockRepository()
+	a := newLockTestReplica("replica-a", locks)
+	b := newLockTestReplica("replica-b", locks)
+
+	// Acquire
+	ctxA, releaseA, err := a.acquireRunLock(context.Background())
+	if err != nil {
+		t.Fatalf("replica a acquire: %v", err)
+	}
+	if lock, _ := locks.Get(context.Background(), deliveryLockName); lock.Holder != "replica-a" || !lock.IsHeld(time.Now()) {
+		t.Fatalf("lock = %+v, want held by replica-a", lock)
+	}
+
+	// Ditolak selama lease replica lain masih aktif
+	if _, _, err := b.acquireRunLock(context.Background()); err == nil || err.Error() != "scheduler lock held by another instance" {
+		t.Fatalf("replica b acquire error = %v, want lock held", err)
+	}
+
+	// Diambil alih setelah lease habis
+	locks.expire(deliveryLockName)
+	_, releaseB, err := b.acquireRunLock(context.Background())
+	if err != nil {
+		t.Fatalf("replica b takeover: %v", err)
+	}
+
+	// Release replica a yang sudah kehilangan lock tidak melepas lock replica b
+	releaseA()
+	if ctxA.Err() == nil {
+		t.Fatal("run context of replica a not cancelled after release")
+	}
+	lock, _ := locks.Get(context.Background(), deliveryLockName)
+	if lock.Holder != "replica-b" || !lock.IsHeld(time.Now()) {
+		t.Fatalf("lock = %+v, want still held by replica-b", lock)
+	}
+
+	// Release oleh pemegang lock membuat lock bisa diambil lagi
+	releaseB()
+	_, releaseA, err = a.acquireRunLock(context.Background())
+	if err != nil {
+		t.Fatalf("replica a acquire after release: %v", err)
+	}
+	releaseA()
+}
+
+func TestAcquireRunLockCancelsRunWhenLockLost(t *testing.T) {
+	locks := newMemoryLockRepository()
+	a := newLockTestReplica("replica-a", locks)
+	// Lease minimum 3 detik, perpanjangan dicoba setiap 1 detik
+	a.cfg.Schedular.LockLeaseSeconds = 1
+
+	runCtx, release, err := a.acquireRunLock(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	locks.mu.Lock()
+	locks.renewFails = true
+	locks.mu.Unlock()
+
+	select {
+	case <-runCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("run context not cancelled after the lock was lost")
+	}
+}
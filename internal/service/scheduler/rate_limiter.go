@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter membatasi jumlah pengiriman per detik untuk satu channel
+// dengan memberi jarak minimal antar pengiriman
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter membuat limiter dengan rate per detik, nil berarti tanpa batas
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+// Wait menunggu giliran pengiriman berikutnya atau sampai context dibatalkan
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := time.Until(slot)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
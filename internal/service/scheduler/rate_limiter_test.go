@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterUnlimited(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		limiter := newRateLimiter(rate)
+		if limiter != nil {
+			t.Fatalf("newRateLimiter(%v) = %+v, want nil", rate, limiter)
+		}
+
+		// Limiter nil tidak pernah menunggu, bahkan dengan context yang sudah dibatalkan
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("nil limiter Wait = %v, want nil", err)
+		}
+	}
+}
+
+func TestRateLimiterWaitSpacing(t *testing.T) {
+	// 20 per detik, jarak antar pengiriman 50ms
+	limiter := newRateLimiter(20)
+	if limiter.interval != 50*time.Millisecond {
+		t.Fatalf("interval = %s, want 50ms", limiter.interval)
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait %d: %v", i, err)
+		}
+	}
+
+	// Pengiriman pertama langsung, tiga berikutnya masing-masing menunggu satu interval
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("4 waits took %s, want at least 150ms", elapsed)
+	}
+}
+
+func TestRateLimiterWaitConcurrent(t *testing.T) {
+	limiter := newRateLimiter(20)
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		slots []time.Time
+	)
+
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Errorf("Wait: %v", err)
+				return
+			}
+			mu.Lock()
+			slots = append(slots, time.Now())
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Goroutine yang menunggu bersamaan tetap mendapat slot yang berbeda
+	first, last := slots[0], slots[0]
+	for _, slot := range slots {
+		if slot.Before(first) {
+			first = slot
+		}
+		if slot.After(last) {
+			last = slot
+		}
+	}
+	if span := last.Sub(first); span < 200*time.Millisecond {
+		t.Fatalf("5 concurrent waits spread over %s, want at least 200ms", span)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	limiter := newRateLimiter(1)
+
+	// Slot pertama langsung tersedia
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := limiter.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("cancelled Wait returned after %s, want immediately after the deadline", elapsed)
+	}
+}
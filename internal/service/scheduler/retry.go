@@ -0,0 +1,42 @@
+package service
+
+import (
+	"time"
+)
+
+// retryJitterFraction bagian jeda backoff yang diacak, capsule yang gagal bersamaan
+// (misalnya saat SMTP down) tidak dicoba ulang pada detik yang sama
+const retryJitterFraction = 0.2
+
+// backoffDelay menghitung jeda sebelum percobaan berikutnya (exponential backoff)
+// attempt adalah nomor percobaan yang baru saja gagal, dimulai dari 1
+func backoffDelay(attempt, baseSeconds, maxSeconds int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	base := time.Duration(baseSeconds) * time.Second
+	maxDelay := time.Duration(maxSeconds) * time.Second
+
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		// Berhenti lebih awal agar tidak overflow
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
+// jitterDelay memperpendek delay secara acak sampai retryJitterFraction, hasilnya di antara
+// delay*(1-retryJitterFraction) dan delay sehingga batas RetryMaxSeconds tidak terlampaui.
+// random bernilai [0, 1)
+func jitterDelay(delay time.Duration, random float64) time.Duration {
+	return delay - time.Duration(float64(delay)*retryJitterFraction*random)
+}
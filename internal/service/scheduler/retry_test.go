@@ -0,0 +1,70 @@
+package service
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt int
+		base    int
+		max     int
+		want    time.Duration
+	}{
+		{name: "attempt below one", attempt: 0, base: 30, max: 3600, want: 30 * time.Second},
+		{name: "first attempt", attempt: 1, base: 30, max: 3600, want: 30 * time.Second},
+		{name: "doubles", attempt: 2, base: 30, max: 3600, want: time.Minute},
+		{name: "keeps doubling", attempt: 5, base: 30, max: 3600, want: 8 * time.Minute},
+		{name: "capped", attempt: 8, base: 30, max: 3600, want: time.Hour},
+		{name: "exactly at cap", attempt: 3, base: 15, max: 60, want: time.Minute},
+		{name: "base above cap", attempt: 1, base: 120, max: 60, want: time.Minute},
+		{name: "huge attempt does not overflow", attempt: 1000, base: 30, max: 3600, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoffDelay(tt.attempt, tt.base, tt.max); got != tt.want {
+				t.Fatalf("backoffDelay(%d, %d, %d) = %s, want %s", tt.attempt, tt.base, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJitterDelay(t *testing.T) {
+	delay := 10 * time.Minute
+
+	tests := []struct {
+		random float64
+		want   time.Duration
+	}{
+		{random: 0, want: delay},
+		{random: 0.5, want: 9 * time.Minute},
+		{random: 0.999999, want: delay - time.Duration(float64(delay)*retryJitterFraction*0.999999)},
+	}
+
+	for _, tt := range tests {
+		if got := jitterDelay(delay, tt.random); got != tt.want {
+			t.Errorf("jitterDelay(%s, %v) = %s, want %s", delay, tt.random, got, tt.want)
+		}
+	}
+}
+
+func TestJitterDelayBounds(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for attempt := 1; attempt <= 12; attempt++ {
+		delay := backoffDelay(attempt, 30, 3600)
+		minDelay := time.Duration(float64(delay) * (1 - retryJitterFraction))
+
+		for range 1000 {
+			got := jitterDelay(delay, rng.Float64())
+			// Jitter tidak pernah melewati batas atas backoff
+			if got < minDelay || got > delay {
+				t.Fatalf("attempt %d: jitterDelay = %s, want between %s and %s", attempt, got, minDelay, delay)
+			}
+		}
+	}
+}
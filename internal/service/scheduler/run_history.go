@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// startRun mencatat run baru dengan status running.
+// Jika gagal disimpan, run tetap berjalan tanpa riwayat
+func (s *schedulerService) startRun(ctx context.Context, trigger string) *models.SchedulerRun {
+	run := &models.SchedulerRun{
+		TriggerSource: trigger,
+		InstanceID:    s.instanceID,
+		Status:        models.SchedulerRunRunning,
+		StartedAt:     time.Now().UTC(),
+	}
+
+	if err := s.runRepo.Create(ctx, run); err != nil {
+		log.Printf("Failed to record scheduler run: %v", err)
+	}
+
+	return run
+}
+
+// finishRun menyimpan hasil akhir run beserta ringkasan pengiriman dan error nya
+func (s *schedulerService) finishRun(run *models.SchedulerRun, summary *runSummary, runErrors []string) *models.SchedulerRun {
+	summary.mu.Lock()
+	run.Total = summary.total
+	run.Sent = summary.sent
+	run.Retried = summary.retried
+	run.Failed = summary.failed
+	run.Deferred = summary.deferred
+	run.Overdue = summary.overdue
+	summary.mu.Unlock()
+
+	run.Status = models.SchedulerRunCompleted
+	if len(runErrors) > 0 {
+		run.Status = models.SchedulerRunFailed
+		run.Error = sql.NullString{String: strings.Join(runErrors, "; "), Valid: true}
+	}
+	run.FinishedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+
+	// Run yang gagal dicatat di awal tidak punya ID, tidak perlu disimpan
+	if run.ID == 0 {
+		return run
+	}
+
+	// Gunakan context baru karena context run bisa sudah dibatalkan
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.runRepo.Finish(ctx, run); err != nil {
+		log.Printf("Failed to save scheduler run %d: %v", run.ID, err)
+	}
+
+	s.pruneRuns(ctx)
+
+	return run
+}
+
+// pruneRuns menghapus riwayat run yang melewati batas retensi
+func (s *schedulerService) pruneRuns(ctx context.Context) {
+	retentionDays := s.cfg.Schedular.RunRetentionDays
+	if retentionDays <= 0 {
+		return
+	}
+
+	before := time.Now().UTC().AddDate(0, 0, -retentionDays)
+	deleted, err := s.runRepo.DeleteOlderThan(ctx, before)
+	if err != nil {
+		log.Printf("Failed to prune scheduler runs: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("Pruned %d scheduler run(s) older than %d days", deleted, retentionDays)
+	}
+}
+
+// ListRuns mengambil riwayat run terbaru
+func (s *schedulerService) ListRuns(ctx context.Context, limit int) ([]models.SchedulerRun, error) {
+	return s.runRepo.List(ctx, limit)
+}
+
+// Status mengembalikan jadwal run berikutnya, pemegang lock dan run terakhir
+func (s *schedulerService) Status(ctx context.Context) (*models.SchedulerStatus, error) {
+	status := &models.SchedulerStatus{
+		InstanceID:     s.instanceID,
+		CronExpression: s.cfg.Schedular.CronExpression,
+		Timezone:       s.cfg.Schedular.Timezone,
+		Running:        s.entryID != 0,
+	}
+
+	// Entry cron hanya ada setelah Start dipanggil
+	if s.entryID != 0 {
+		if next := s.cron.Entry(s.entryID).Next; !next.IsZero() {
+			nextRunAt := next.UTC()
+			status.NextRunAt = &nextRunAt
+		}
+	}
+
+	lock, err := s.lockRepo.Get(ctx, deliveryLockName)
+	if err != nil && err.Error() != "lock not found" {
+		return nil, err
+	}
+	if lock != nil {
+		status.Lock = lock
+		status.LockHeld = lock.IsHeld(time.Now())
+	}
+
+	lastRun, err := s.runRepo.GetLatest(ctx)
+	if err != nil && err.Error() != "scheduler run not found" {
+		return nil, err
+	}
+	if lastRun != nil {
+		status.LastRun = lastRun.ToResponse()
+	}
+
+	return status, nil
+}
@@ -3,14 +3,24 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
+	"sync"
 	"time"
 
+	"future-letter/internal/clock"
 	"future-letter/internal/config"
+	"future-letter/internal/models"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	lockRepository "future-letter/internal/repository/lock"
+	schedulerRepository "future-letter/internal/repository/scheduler"
 	repository "future-letter/internal/repository/user"
 	capsule "future-letter/internal/service/capsule"
-	email "future-letter/internal/service/email"
+	notifier "future-letter/internal/service/notifier"
+	"future-letter/internal/utils"
 
 	"github.com/robfig/cron/v3"
 )
@@ -19,7 +29,11 @@ import (
 type SchedulerService interface {
 	Start() error
 	Stop()
-	RunManually()
+	RunManually() (*models.SchedulerRun, error)
+	LockStatus(ctx context.Context) (*models.SchedulerLock, error)
+	InstanceID() string
+	Status(ctx context.Context) (*models.SchedulerStatus, error)
+	ListRuns(ctx context.Context, limit int) ([]models.SchedulerRun, error)
 }
 
 // schedulerService struct implementation
@@ -27,8 +41,24 @@ type schedulerService struct {
 	cfg            *config.Config
 	cron           *cron.Cron
 	userRepo       repository.UserRepository
+	deliveryRepo   deliveryRepository.DeliveryRepository
+	lockRepo       lockRepository.LockRepository
+	runRepo        schedulerRepository.SchedulerRunRepository
 	capsuleService capsule.CapsuleService
-	emailService   *email.EmailService
+	notifiers      *notifier.Registry
+	// clock sumber waktu untuk due date, lease dan riwayat pengiriman.
+	// Lock dan riwayat run tetap memakai waktu sistem karena dibagi antar replica
+	clock clock.Clock
+
+	// entryID entry cron job pengiriman, dipakai untuk status jadwal berikutnya
+	entryID cron.EntryID
+	// instanceID identitas replica ini saat memegang lock scheduler
+	instanceID string
+	// limiters rate limiter per channel pengiriman
+	limiters map[string]*rateLimiter
+
+	// mu memastikan hanya satu proses pengiriman yang berjalan dalam satu waktu
+	mu sync.Mutex
 }
 
 // NewSchedulerService instance baru SchedulerService
@@ -36,8 +66,12 @@ type schedulerService struct {
 func NewSchedulerService(
 	cfg *config.Config,
 	userRepo repository.UserRepository,
+	deliveryRepo deliveryRepository.DeliveryRepository,
+	lockRepo lockRepository.LockRepository,
+	runRepo schedulerRepository.SchedulerRunRepository,
 	capsuleService capsule.CapsuleService,
-	emailService *email.EmailService,
+	notifiers *notifier.Registry,
+	clock clock.Clock,
 ) SchedulerService {
 	// Load timezone dari config
 	location, err := time.LoadLocation(cfg.Schedular.Timezone)
@@ -52,12 +86,23 @@ func NewSchedulerService(
 		cron.WithLocation(location),
 	)
 
+	instanceID := cfg.Schedular.InstanceID
+	if instanceID == "" {
+		instanceID = defaultInstanceID()
+	}
+
 	return &schedulerService{
 		cfg:            cfg,
 		cron:           cronScheduler,
 		userRepo:       userRepo,
+		deliveryRepo:   deliveryRepo,
+		lockRepo:       lockRepo,
+		runRepo:        runRepo,
 		capsuleService: capsuleService,
-		emailService:   emailService,
+		notifiers:      notifiers,
+		clock:          clock,
+		instanceID:     instanceID,
+		limiters:       newChannelLimiters(notifiers.Channels(), cfg.Schedular.RateLimits, cfg.Schedular.DefaultRatePerSecond),
 	}
 }
 
@@ -67,17 +112,27 @@ func (s *schedulerService) Start() error {
 
 	// Register cron job
 	// addFunc untuk menambahkan job ke scheduler
-	_, err := s.cron.AddFunc(s.cfg.Schedular.CronExpression, func() {
+	entryID, err := s.cron.AddFunc(s.cfg.Schedular.CronExpression, func() {
 		// Fungsi akan dijalankan sesuai cron expression
 		log.Println("Schedular running: checking pending capsuless...")
 
 		// jalankan job untuk kirim capsules
-		s.processPendingCapsules()
+		if _, err := s.processPendingCapsules(models.SchedulerTriggerCron); err != nil {
+			log.Printf("Scheduler run skipped: %v", err)
+		}
 	})
 	if err != nil {
 		return fmt.Errorf("failed to add cron job: %w", err)
 	}
 
+	// Simpan entry cron untuk menghitung jadwal run berikutnya
+	s.entryID = entryID
+
+	// Hapus permanen akun yang masa tenggang penghapusannya sudah habis
+	if _, err := s.cron.AddFunc(s.cfg.Schedular.AccountPurgeCronExpression, s.purgeDeletedAccounts); err != nil {
+		return fmt.Errorf("failed to add account purge job: %w", err)
+	}
+
 	// Start cron scheduler menjalankan scheduler di background (goroutine)
 	s.cron.Start()
 
@@ -85,6 +140,8 @@ func (s *schedulerService) Start() error {
 
 	log.Printf("Timezone: %s", s.cfg.Schedular.Timezone)
 
+	log.Printf("Instance ID: %s", s.instanceID)
+
 	log.Println("Schedular is running in background...")
 
 	return nil
@@ -105,70 +162,254 @@ func (s *schedulerService) Stop() {
 	}
 }
 
-func (s *schedulerService) processPendingCapsules() {
+// processPendingCapsules menjalankan satu kali run pengiriman dan menyimpan riwayatnya.
+// trigger berisi sumber run (cron atau manual)
+func (s *schedulerService) processPendingCapsules(trigger string) (*models.SchedulerRun, error) {
+	// Jangan antri di belakang run yang sedang berjalan di instance ini
+	if !s.mu.TryLock() {
+		return nil, errors.New("scheduler run already in progress")
+	}
+	defer s.mu.Unlock()
+
 	// Buat context dengan timeout
 	// Agar job tidak berjalan selamanya / loop
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.Schedular.RunTimeoutMinutes)*time.Minute)
 	defer cancel()
 
-	// GetPendingForToday mendapat capsule yang pending pada hari ini
-	capsules, err := s.capsuleService.GetPendingCapsulesForToday(ctx)
+	// Hanya replica yang memegang lock yang boleh mengirim capsule
+	ctx, release, err := s.acquireRunLock(ctx)
 	if err != nil {
-		log.Printf("Failed to get pending capsules: %v", err)
-		return
+		return nil, err
 	}
+	defer release()
 
-	// Jika tidak ada capsule yang jatuh tempo
-	if len(capsules) == 0 {
-		log.Println("No pending capsule for today")
-		return
+	run := s.startRun(ctx, trigger)
+	summary := &runSummary{startedAt: run.StartedAt}
+
+	// runErrors error level run yang disimpan ke riwayat
+	var runErrors []string
+
+	now := s.clock.Now().UTC()
+
+	// Pulihkan klaim yang macet (lease habis) dari run sebelumnya yang berhenti di tengah jalan
+	finalized, requeued, failed, err := s.capsuleService.RecoverStaleClaims(ctx, now, s.cfg.Schedular.MaxAttempts)
+	if err != nil {
+		log.Printf("Failed to recover stale claims: %v", err)
+		runErrors = append(runErrors, fmt.Sprintf("recover stale claims: %v", err))
+	} else if finalized > 0 || requeued > 0 || failed > 0 {
+		log.Printf("Recovered stale claims: %d finalized as sent, %d requeued, %d failed after max attempts", finalized, requeued, failed)
 	}
 
-	log.Printf("Found %d pendiing capsule(S) to send", len(capsules))
+	// claimToken menandai capsule yang diklaim oleh run ini
+	claimToken, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		log.Printf("Failed to generate claim token: %v", err)
+		runErrors = append(runErrors, fmt.Sprintf("generate claim token: %v", err))
+		return s.finishRun(run, summary, runErrors), nil
+	}
 
-	// Proses setiap capsule
-	successCount := 0
-	failCount := 0
+	// Klaim dan kirim capsule per batch, termasuk capsule yang terlewat karena
+	// service mati atau cron melewatkan jadwal
+	batchSize := s.cfg.Schedular.BatchSize
+	for ctx.Err() == nil {
+		leaseUntil := now.Add(time.Duration(s.cfg.Schedular.LeaseSeconds) * time.Second)
 
-	for _, capsule := range capsules {
-		// Dapatkan user data untuk email
-		user, err := s.userRepo.GetByID(ctx, capsule.ID)
+		capsules, err := s.capsuleService.ClaimDueCapsules(ctx, now, batchSize, claimToken, leaseUntil)
 		if err != nil {
-			log.Printf("Failed to get user %d for capsule %d: %v", capsule.UserID, capsule.ID, err)
-			failCount++
-			continue
+			log.Printf("Failed to claim pending capsules: %v", err)
+			runErrors = append(runErrors, fmt.Sprintf("claim capsules: %v", err))
+			break
 		}
 
-		// Kirim email
-		log.Printf("Sending capsule %d to %s (%s)", capsule.ID, user.Name, user.Email)
+		if len(capsules) == 0 {
+			break
+		}
 
-		err = s.emailService.SendCapsuleEmail(user, &capsule)
-		if err != nil {
-			log.Printf("Failed to send email for capsule %d: %v", capsule.ID, err)
-			failCount++
-			continue
+		log.Printf("Claimed %d pending capsule(s) to send", len(capsules))
+
+		// Kirim capsule secara paralel dengan worker pool
+		s.processBatch(ctx, now, claimToken, capsules, summary)
+
+		if len(capsules) < batchSize {
+			break
 		}
 
-		// Tandai jika sudah dikirim
-		err = s.capsuleService.MarkCapsulesAsSent(ctx, capsule.ID)
-		if err != nil {
-			log.Printf("Email send but failed to update status for capsule %d: %v", capsule.ID, err)
-			// email sudah terkirim tetapi status di database belum terupdate
+		now = s.clock.Now().UTC()
+	}
+
+	// Run berhenti karena timeout atau lock hilang
+	if err := ctx.Err(); err != nil {
+		runErrors = append(runErrors, fmt.Sprintf("run interrupted: %v", err))
+	}
+
+	// Jika tidak ada capsule yang jatuh tempo
+	if summary.total == 0 {
+		log.Println("No pending capsule due")
+	} else {
+		// Ringkasan Log
+		summary.log()
+	}
+
+	return s.finishRun(run, summary, runErrors), nil
+}
+
+// Hasil akhir pemrosesan satu capsule
+const (
+	outcomeSent   = "sent"
+	outcomeRetry  = "retry"
+	outcomeFailed = "failed"
+	// outcomeDeferred capsule ditunda tanpa dihitung sebagai percobaan
+	outcomeDeferred = "deferred"
+)
+
+type capsuleResult struct {
+	outcome string
+	overdue bool
+}
+
+// processCapsule mengirim satu capsule, mencatat attempt dan mengatur retry jika gagal
+func (s *schedulerService) processCapsule(ctx context.Context, now time.Time, claimToken string, capsule *models.Capsule) capsuleResult {
+	attemptNumber := capsule.AttemptCount + 1
+
+	// Capsule yang terlambat melewati batas tetap dikirim, tetapi ditandai overdue
+	lateness := now.Sub(capsule.DueDate)
+	overdue := s.isOverdue(lateness)
+	if overdue {
+		log.Printf("Capsule %d is overdue by %s", capsule.ID, lateness.Round(time.Second))
+	}
+
+	result := capsuleResult{overdue: overdue}
+
+	// Idempotency: jika capsule sudah pernah berhasil dikirim (misalnya proses mati
+	// sebelum status diupdate), cukup finalisasi tanpa mengirim ulang
+	delivered, err := s.deliveryRepo.HasSuccessfulAttempt(ctx, capsule.ID)
+	if err != nil {
+		log.Printf("Failed to check delivery history for capsule %d: %v", capsule.ID, err)
+	} else if delivered {
+		log.Printf("Capsule %d was already delivered, finalizing without resending", capsule.ID)
+		if err := s.capsuleService.MarkCapsulesAsSent(ctx, capsule.ID, claimToken, overdue, s.clock.Now()); err != nil {
+			log.Printf("Failed to finalize capsule %d: %v", capsule.ID, err)
+		}
+
+		result.outcome = outcomeSent
+		return result
+	}
+
+	// Dapatkan user pemilik capsule, lalu kirim capsule sesuai delivery method
+	user, err := s.userRepo.GetByID(ctx, capsule.UserID)
+	if err != nil {
+		err = fmt.Errorf("failed to get user %d: %w", capsule.UserID, err)
+	} else if s.requiresVerifiedEmail(capsule) && !user.IsEmailVerified() {
+		// Jangan kirim ke email yang belum diverifikasi, tunda sampai user verifikasi
+		nextAttemptAt := now.Add(time.Duration(s.cfg.Schedular.UnverifiedDeferMinutes) * time.Minute)
+		if err := s.capsuleService.DeferCapsule(ctx, capsule.ID, claimToken, nextAttemptAt); err != nil {
+			log.Printf("Failed to defer capsule %d: %v", capsule.ID, err)
+		}
+		log.Printf("Capsule %d deferred until %s, email of user %d is not verified", capsule.ID, nextAttemptAt.Format(time.RFC3339), user.ID)
+
+		result.outcome = outcomeDeferred
+		return result
+	} else {
+		log.Printf("Sending capsule %d to %s via %s (attempt %d)", capsule.ID, user.Email, capsule.DeliveryMethod, attemptNumber)
+
+		// Batasi waktu setiap pengiriman agar satu channel yang lambat tidak menahan worker
+		sendCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.Schedular.SendTimeoutSeconds)*time.Second)
+		err = s.deliverCapsule(sendCtx, user, capsule)
+		cancel()
+	}
+
+	if err != nil {
+		log.Printf("Failed to deliver capsule %d: %v", capsule.ID, err)
+		s.recordAttempt(ctx, capsule, attemptNumber, overdue, err)
+
+		// Semua percobaan habis, capsule masuk dead letter dengan status failed
+		if attemptNumber >= s.cfg.Schedular.MaxAttempts {
+			if err := s.capsuleService.MarkCapsuleAsFailed(ctx, capsule.ID, claimToken); err != nil {
+				log.Printf("Failed to mark capsule %d as failed: %v", capsule.ID, err)
+			}
+			log.Printf("Capsule %d failed permanently after %d attempts", capsule.ID, attemptNumber)
+
+			result.outcome = outcomeFailed
+			return result
 		}
 
-		log.Printf("Capsule %d sent successfully to %s", capsule.ID, user.Email)
-		successCount++
+		delay := backoffDelay(attemptNumber, s.cfg.Schedular.RetryBaseSeconds, s.cfg.Schedular.RetryMaxSeconds)
+		nextAttemptAt := now.Add(jitterDelay(delay, rand.Float64()))
+		if err := s.capsuleService.ScheduleCapsuleRetry(ctx, capsule.ID, claimToken, nextAttemptAt); err != nil {
+			log.Printf("Failed to schedule retry for capsule %d: %v", capsule.ID, err)
+		}
+		log.Printf("Capsule %d will be retried at %s", capsule.ID, nextAttemptAt.Format(time.RFC3339))
+
+		result.outcome = outcomeRetry
+		return result
+	}
+
+	s.recordAttempt(ctx, capsule, attemptNumber, overdue, nil)
+
+	// Tandai jika sudah dikirim
+	err = s.capsuleService.MarkCapsulesAsSent(ctx, capsule.ID, claimToken, overdue, s.clock.Now())
+	if err != nil {
+		// Attempt sukses sudah tercatat, recovery pass akan memfinalisasi capsule ini
+		// tanpa mengirim ulang setelah lease habis
+		log.Printf("Capsule delivered but failed to update status for capsule %d: %v", capsule.ID, err)
+	}
+
+	log.Printf("Capsule %d delivered successfully to %s", capsule.ID, user.Email)
+
+	result.outcome = outcomeSent
+	return result
+}
+
+// recordAttempt menyimpan riwayat percobaan pengiriman, error disimpan jika gagal
+func (s *schedulerService) recordAttempt(ctx context.Context, capsule *models.Capsule, attemptNumber int, overdue bool, deliveryErr error) {
+	attempt := &models.DeliveryAttempt{
+		CapsuleID:      capsule.ID,
+		Channel:        capsule.DeliveryMethod,
+		IdempotencyKey: capsule.IdempotencyKey(),
+		AttemptNumber:  attemptNumber,
+		Status:         models.DeliveryStatusSuccess,
+		Overdue:        overdue,
+		AttemptedAt:    s.clock.Now(),
+	}
+
+	if deliveryErr != nil {
+		attempt.Status = models.DeliveryStatusFailed
+		attempt.Error = sql.NullString{String: deliveryErr.Error(), Valid: true}
 	}
 
-	// Ringkasan Log
-	log.Printf("Success : %d capsules", successCount)
+	if err := s.deliveryRepo.Create(ctx, attempt); err != nil {
+		log.Printf("Failed to record delivery attempt for capsule %d: %v", capsule.ID, err)
+	}
+}
 
-	log.Printf("Failed : %d capsules", failCount)
+// deliverCapsule mengirim capsule lewat notifier yang sesuai dengan delivery method
+func (s *schedulerService) deliverCapsule(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	method := capsule.DeliveryMethod
+	if method == "" {
+		method = models.DeliveryMethodEmail
+	}
+
+	channel, ok := s.notifiers.Get(method)
+	if !ok {
+		return fmt.Errorf("no notifier registered for delivery method: %s", method)
+	}
+
+	return channel.Send(ctx, user, capsule)
+}
+
+// requiresVerifiedEmail mengecek apakah capsule dikirim ke alamat email user
+func (s *schedulerService) requiresVerifiedEmail(capsule *models.Capsule) bool {
+	return capsule.DeliveryMethod == "" || capsule.DeliveryMethod == models.DeliveryMethodEmail
+}
 
-	log.Printf("Total processed : %d capsules", len(capsules))
+// isOverdue mengecek apakah keterlambatan melewati batas yang dikonfigurasi
+func (s *schedulerService) isOverdue(lateness time.Duration) bool {
+	maxLateness := time.Duration(s.cfg.Schedular.MaxLatenessMinutes) * time.Minute
+	return lateness > maxLateness
 }
 
-func (s *schedulerService) RunManually() {
-	log.Println("Running scheduler manually for testing...")
-	s.processPendingCapsules()
+// RunManually menjalankan pengiriman di luar jadwal cron dan mengembalikan hasil run
+func (s *schedulerService) RunManually() (*models.SchedulerRun, error) {
+	log.Println("Running scheduler manually...")
+	return s.processPendingCapsules(models.SchedulerTriggerManual)
 }
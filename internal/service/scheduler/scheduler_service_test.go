@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	userRepository "future-letter/internal/repository/user"
+	capsule "future-letter/internal/service/capsule"
+	notifier "future-letter/internal/service/notifier"
+)
+
+var testNow = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// fakeCapsuleService mencatat keputusan scheduler untuk setiap capsule,
+// method yang tidak dipakai test akan panic
+type fakeCapsuleService struct {
+	capsule.CapsuleService
+
+	mu       sync.Mutex
+	batches  [][]models.Capsule
+	sent     []int
+	retries  map[int]time.Time
+	failed   []int
+	deferred map[int]time.Time
+}
+
+func newFakeCapsuleService(batches ...[]models.Capsule) *fakeCapsuleService {
+	return &fakeCapsuleService{
+		batches:  batches,
+		retries:  make(map[int]time.Time),
+		deferred: make(map[int]time.Time),
+	}
+}
+
+func (f *fakeCapsuleService) RecoverStaleClaims(ctx context.Context, now time.Time, maxAttempts int) (int, int, int, error) {
+	return 0, 0, 0, nil
+}
+
+func (f *fakeCapsuleService) ClaimDueCapsules(ctx context.Context, now time.Time, limit int, claimToken string, leaseUntil time.Time) ([]models.Capsule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+	return batch, nil
+}
+
+func (f *fakeCapsuleService) MarkCapsulesAsSent(ctx context.Context, capsuleID int, claimToken string, overdue bool, sentAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, capsuleID)
+	return nil
+}
+
+func (f *fakeCapsuleService) ScheduleCapsuleRetry(ctx context.Context, capsuleID int, claimToken string, nextAttemptAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.retries[capsuleID] = nextAttemptAt
+	return nil
+}
+
+func (f *fakeCapsuleService) DeferCapsule(ctx context.Context, capsuleID int, claimToken string, nextAttemptAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.deferred[capsuleID] = nextAttemptAt
+	return nil
+}
+
+func (f *fakeCapsuleService) MarkCapsuleAsFailed(ctx context.Context, capsuleID int, claimToken string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failed = append(f.failed, capsuleID)
+	return nil
+}
+
+type fakeDeliveryRepository struct {
+	deliveryRepository.DeliveryRepository
+
+	mu        sync.Mutex
+	attempts  []models.DeliveryAttempt
+	delivered map[int]bool
+}
+
+func (r *fakeDeliveryRepository) Create(ctx context.Context, attempt *models.DeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *fakeDeliveryRepository) HasSuccessfulAttempt(ctx context.Context, capsuleID int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delivered[capsuleID], nil
+}
+
+type fakeUserRepository struct {
+	userRepository.UserRepository
+
+	users map[int]*models.User
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// scriptedNotifier mengembalikan err untuk setiap pengiriman dan menghitung jumlah pengiriman
+type scriptedNotifier struct {
+	channel string
+	err     error
+
+	mu    sync.Mutex
+	calls []int
+}
+
+func (n *scriptedNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *scriptedNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	n.mu.Lock()
+	n.calls = append(n.calls, capsule.ID)
+	n.mu.Unlock()
+
+	return n.err
+}
+
+func testSchedulerConfig() *config.Config {
+	return &config.Config{
+		Schedular: config.SchedularConfig{
+			MaxLatenessMinutes:     60,
+			MaxAttempts:            3,
+			RetryBaseSeconds:       60,
+			RetryMaxSeconds:        3600,
+			LeaseSeconds:           300,
+			BatchSize:              10,
+			LockLeaseSeconds:       30,
+			Workers:                4,
+			SendTimeoutSeconds:     5,
+			RunTimeoutMinutes:      1,
+			UnverifiedDeferMinutes: 60,
+		},
+	}
+}
+
+// newTestScheduler scheduler dengan satu user terverifikasi (ID 1) dan channel email dari n
+func newTestScheduler(cfg *config.Config, capsules *fakeCapsuleService, n *scriptedNotifier) (*schedulerService, *fakeDeliveryRepository) {
+	deliveries := &fakeDeliveryRepository{delivered: make(map[int]bool)}
+
+	users := &fakeUserRepository{users: map[int]*models.User{
+		1: {ID: 1, Email: "user@example.com", EmailVerifiedAt: sql.NullTime{Time: testNow, Valid: true}},
+	}}
+
+	notifiers := notifier.NewRegistry(n)
+
+	return &schedulerService{
+		cfg:            cfg,
+		userRepo:       users,
+		deliveryRepo:   deliveries,
+		capsuleService: capsules,
+		notifiers:      notifiers,
+		clock:          fixedClock{now: testNow},
+		instanceID:     "test-instance",
+		limiters:       newChannelLimiters(notifiers.Channels(), cfg.Schedular.RateLimits, cfg.Schedular.DefaultRatePerSecond),
+	}, deliveries
+}
+
+func testCapsule(id, attemptCount int) models.Capsule {
+	return models.Capsule{
+		ID:             id,
+		UserID:         1,
+		DueDate:        testNow.Add(-time.Minute),
+		DeliveryMethod: models.DeliveryMethodEmail,
+		Status:         "sending",
+		AttemptCount:   attemptCount,
+	}
+}
+
+func TestProcessCapsuleRetryUntilDeadLetter(t *testing.T) {
+	tests := []struct {
+		name         string
+		attemptCount int
+		wantOutcome  string
+		// wantDelay jeda backoff sebelum jitter, hanya untuk outcomeRetry
+		wantDelay time.Duration
+	}{
+		{name: "first failure", attemptCount: 0, wantOutcome: outcomeRetry, wantDelay: time.Minute},
+		{name: "second failure", attemptCount: 1, wantOutcome: outcomeRetry, wantDelay: 2 * time.Minute},
+		{name: "last attempt", attemptCount: 2, wantOutcome: outcomeFailed},
+		{name: "beyond max attempts", attemptCount: 5, wantOutcome: outcomeFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			capsules := newFakeCapsuleService()
+			n := &scriptedNotifier{channel: models.DeliveryMethodEmail, err: errors.New("smtp unavailable")}
+			s, deliveries := newTestScheduler(testSchedulerConfig(), capsules, n)
+
+			c := testCapsule(10, tt.attemptCount)
+			result := s.processCapsule(context.Background(), testNow, "claim", &c)
+
+			if result.outcome != tt.wantOutcome {
+				t.Fatalf("outcome = %q, want %q", result.outcome, tt.wantOutcome)
+			}
+
+			// Setiap percobaan gagal tercatat dengan nomor percobaan berikutnya
+			if len(deliveries.attempts) != 1 {
+				t.Fatalf("recorded %d attempts, want 1", len(deliveries.attempts))
+			}
+			attempt := deliveries.attempts[0]
+			if attempt.AttemptNumber != tt.attemptCount+1 || attempt.Status != models.DeliveryStatusFailed || attempt.Error.String != "smtp unavailable" {
+				t.Fatalf("attempt = %+v", attempt)
+			}
+
+			switch tt.wantOutcome {
+			case outcomeRetry:
+				next, ok := capsules.retries[10]
+				if !ok || len(capsules.failed) != 0 {
+					t.Fatalf("retries = %v, failed = %v, want one retry", capsules.retries, capsules.failed)
+				}
+				delay := next.Sub(testNow)
+				minDelay := time.Duration(float64(tt.wantDelay) * (1 - retryJitterFraction))
+				if delay < minDelay || delay > tt.wantDelay {
+					t.Fatalf("retry after %s, want between %s and %s", delay, minDelay, tt.wantDelay)
+				}
+			case outcomeFailed:
+				if len(capsules.failed) != 1 || capsules.failed[0] != 10 || len(capsules.retries) != 0 {
+					t.Fatalf("failed = %v, retries = %v, want capsule dead-lettered", capsules.failed, capsules.retries)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessCapsuleSuccess(t *testing.T) {
+	capsules := newFakeCapsuleService()
+	n := &scriptedNotifier{channel: models.DeliveryMethodEmail}
+	s, deliveries := newTestScheduler(testSchedulerConfig(), capsules, n)
+
+	c := testCapsule(10, 2)
+	result := s.processCapsule(context.Background(), testNow, "claim", &c)
+
+	if result.outcome != outcomeSent || len(capsules.sent) != 1 {
+		t.Fatalf("outcome = %q, sent = %v", result.outcome, capsules.sent)
+	}
+	if len(deliveries.attempts) != 1 || deliveries.attempts[0].Status != models.DeliveryStatusSuccess || deliveries.attempts[0].AttemptNumber != 3 {
+		t.Fatalf("attempts = %+v", deliveries.attempts)
+	}
+}
+
+func TestProcessCapsuleAlreadyDelivered(t *testing.T) {
+	capsules := newFakeCapsuleService()
+	n := &scriptedNotifier{channel: models.DeliveryMethodEmail}
+	s, deliveries := newTestScheduler(testSchedulerConfig(), capsules, n)
+	deliveries.delivered[10] = true
+
+	c := testCapsule(10, 1)
+	result := s.processCapsule(context.Background(), testNow, "claim", &c)
+
+	// Tidak dikirim ulang, cukup difinalisasi
+	if result.outcome != outcomeSent || len(n.calls) != 0 || len(capsules.sent) != 1 {
+		t.Fatalf("outcome = %q, sends = %v, sent = %v", result.outcome, n.calls, capsules.sent)
+	}
+}
+
+func TestRunManuallyWhileRunInProgress(t *testing.T) {
+	s, _ := newTestScheduler(testSchedulerConfig(), newFakeCapsuleService(), &scriptedNotifier{channel: models.DeliveryMethodEmail})
+	locks := newMemoryLockRepository()
+	s.lockRepo = locks
+
+	// Run cron yang sedang berjalan memegang mutex
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.RunManually()
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err == nil || err.Error() != "scheduler run already in progress" {
+			t.Fatalf("RunManually error = %v, want run already in progress", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunManually blocked behind the running run")
+	}
+
+	// Run yang ditolak tidak menyentuh lock antar replica
+	if _, err := locks.Get(context.Background(), deliveryLockName); err == nil {
+		t.Fatal("rejected manual run acquired the scheduler lock")
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// runSummary ringkasan satu kali run scheduler
+type runSummary struct {
+	mu sync.Mutex
+
+	startedAt time.Time
+	total     int
+	sent      int
+	retried   int
+	failed    int
+	deferred  int
+	overdue   int
+}
+
+func (r *runSummary) add(result capsuleResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.total++
+	if result.overdue {
+		r.overdue++
+	}
+
+	switch result.outcome {
+	case outcomeSent:
+		r.sent++
+	case outcomeRetry:
+		r.retried++
+	case outcomeFailed:
+		r.failed++
+	case outcomeDeferred:
+		r.deferred++
+	}
+}
+
+// throughput jumlah capsule yang diproses per detik
+func (r *runSummary) throughput(duration time.Duration) float64 {
+	if duration <= 0 {
+		return 0
+	}
+
+	return float64(r.total) / duration.Seconds()
+}
+
+func (r *runSummary) log() {
+	duration := time.Since(r.startedAt)
+
+	log.Printf("Success : %d capsules", r.sent)
+
+	log.Printf("Retry scheduled : %d capsules", r.retried)
+
+	log.Printf("Failed : %d capsules", r.failed)
+
+	log.Printf("Deferred : %d capsules", r.deferred)
+
+	log.Printf("Overdue : %d capsules", r.overdue)
+
+	log.Printf("Total processed : %d capsules in %s (%.2f capsules/s)", r.total, duration.Round(time.Millisecond), r.throughput(duration))
+}
+
+// processBatch mengirim capsule yang sudah diklaim menggunakan worker pool terbatas.
+// Setiap pengiriman menunggu giliran dari rate limiter channel nya masing-masing
+func (s *schedulerService) processBatch(ctx context.Context, now time.Time, claimToken string, capsules []models.Capsule, summary *runSummary) {
+	workers := s.cfg.Schedular.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(capsules) {
+		workers = len(capsules)
+	}
+
+	jobs := make(chan *models.Capsule)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for capsule := range jobs {
+				// Capsule yang tidak sempat dikirim tetap berstatus 'sending'
+				// dan akan dikembalikan ke pending oleh recovery pass setelah lease habis
+				if err := s.limiterFor(capsule.DeliveryMethod).Wait(ctx); err != nil {
+					log.Printf("Run stopped before capsule %d was sent: %v", capsule.ID, err)
+					continue
+				}
+
+				summary.add(s.processCapsule(ctx, now, claimToken, capsule))
+			}
+		}()
+	}
+
+	for i := range capsules {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- &capsules[i]
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+// newChannelLimiters membuat rate limiter untuk setiap channel yang terdaftar,
+// channel tanpa konfigurasi khusus memakai rate default
+func newChannelLimiters(channels []string, limits map[string]float64, defaultRate float64) map[string]*rateLimiter {
+	limiters := make(map[string]*rateLimiter, len(channels))
+
+	for _, channel := range channels {
+		rate, ok := limits[channel]
+		if !ok {
+			rate = defaultRate
+		}
+
+		if limiter := newRateLimiter(rate); limiter != nil {
+			log.Printf("Rate limit for channel %s: %.2f/s", channel, rate)
+			limiters[channel] = limiter
+		}
+	}
+
+	return limiters
+}
+
+// limiterFor mengambil rate limiter untuk channel, nil berarti tanpa batas
+func (s *schedulerService) limiterFor(channel string) *rateLimiter {
+	return s.limiters[channel]
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+// recordingNotifier mencatat waktu pengiriman dan jumlah pengiriman yang berjalan bersamaan.
+// Jika release tidak nil, setiap pengiriman ditahan sampai release ditutup
+type recordingNotifier struct {
+	channel string
+	err     error
+	release chan struct{}
+
+	mu          sync.Mutex
+	sentAt      []time.Time
+	inFlight    int
+	maxInFlight int
+}
+
+func (n *recordingNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, user *models.User, capsule *models.Capsule) error {
+	n.mu.Lock()
+	n.sentAt = append(n.sentAt, time.Now())
+	n.inFlight++
+	if n.inFlight > n.maxInFlight {
+		n.maxInFlight = n.inFlight
+	}
+	n.mu.Unlock()
+
+	if n.release != nil {
+		<-n.release
+	}
+
+	n.mu.Lock()
+	n.inFlight--
+	n.mu.Unlock()
+
+	return n.err
+}
+
+func (n *recordingNotifier) snapshot() (sent, inFlight, maxInFlight int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return len(n.sentAt), n.inFlight, n.maxInFlight
+}
+
+// span jarak antara pengiriman pertama dan terakhir
+func (n *recordingNotifier) span() time.Duration {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.sentAt) == 0 {
+		return 0
+	}
+	return n.sentAt[len(n.sentAt)-1].Sub(n.sentAt[0])
+}
+
+// registerNotifier menambahkan channel ke scheduler test dan menyusun ulang rate limiter nya
+func registerNotifier(s *schedulerService, n *recordingNotifier) {
+	s.notifiers.Register(n)
+	s.limiters = newChannelLimiters(s.notifiers.Channels(), s.cfg.Schedular.RateLimits, s.cfg.Schedular.DefaultRatePerSecond)
+}
+
+func channelCapsules(firstID, count int, channel string) []models.Capsule {
+	capsules := make([]models.Capsule, 0, count)
+	for i := 0; i < count; i++ {
+		c := testCapsule(firstID+i, 0)
+		c.DeliveryMethod = channel
+		capsules = append(capsules, c)
+	}
+	return capsules
+}
+
+func TestProcessBatchSummary(t *testing.T) {
+	capsules := newFakeCapsuleService()
+	s, _ := newTestScheduler(testSchedulerConfig(), capsules, &scriptedNotifier{channel: models.DeliveryMethodEmail})
+	registerNotifier(s, &recordingNotifier{channel: models.DeliveryMethodInApp, err: errors.New("inbox unavailable")})
+	s.userRepo.(*fakeUserRepository).users[2] = &models.User{ID: 2, Email: "unverified@example.com"}
+
+	sent := testCapsule(1, 0)
+	overdue := testCapsule(2, 0)
+	overdue.DueDate = testNow.Add(-2 * time.Hour)
+	retried := testCapsule(3, 0)
+	retried.DeliveryMethod = models.DeliveryMethodInApp
+	failed := testCapsule(4, 2)
+	failed.DeliveryMethod = models.DeliveryMethodInApp
+	deferred := testCapsule(5, 0)
+	deferred.UserID = 2
+
+	summary := &runSummary{startedAt: time.Now()}
+	s.processBatch(context.Background(), testNow, "claim", []models.Capsule{sent, overdue, retried, failed, deferred}, summary)
+
+	if summary.total != 5 || summary.sent != 2 || summary.retried != 1 || summary.failed != 1 || summary.deferred != 1 || summary.overdue != 1 {
+		t.Fatalf("summary = total %d, sent %d, retried %d, failed %d, deferred %d, overdue %d",
+			summary.total, summary.sent, summary.retried, summary.failed, summary.deferred, summary.overdue)
+	}
+	if len(capsules.sent) != 2 || len(capsules.retries) != 1 || len(capsules.failed) != 1 || len(capsules.deferred) != 1 {
+		t.Fatalf("sent %v, retries %v, failed %v, deferred %v", capsules.sent, capsules.retries, capsules.failed, capsules.deferred)
+	}
+}
+
+func TestProcessBatchBoundedWorkers(t *testing.T) {
+	cfg := testSchedulerConfig()
+	cfg.Schedular.Workers = 3
+
+	capsules := newFakeCapsuleService()
+	s, _ := newTestScheduler(cfg, capsules, &scriptedNotifier{channel: models.DeliveryMethodEmail})
+	n := &recordingNotifier{channel: models.DeliveryMethodInApp, release: make(chan struct{})}
+	registerNotifier(s, n)
+
+	summary := &runSummary{startedAt: time.Now()}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.processBatch(context.Background(), testNow, "claim", channelCapsules(1, 12, models.DeliveryMethodInApp), summary)
+	}()
+
+	// Tunggu sampai semua worker sibuk, lalu pastikan tidak ada pengiriman tambahan yang mulai
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, inFlight, _ := n.snapshot(); inFlight == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("workers never reached 3 concurrent sends")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if sent, _, maxInFlight := n.snapshot(); sent != 3 || maxInFlight != 3 {
+		t.Fatalf("started %d sends with %d in flight, want 3 and 3 while workers are blocked", sent, maxInFlight)
+	}
+
+	close(n.release)
+	<-done
+
+	if sent, _, maxInFlight := n.snapshot(); sent != 12 || maxInFlight != 3 {
+		t.Fatalf("sent %d with max %d in flight, want 12 with max 3", sent, maxInFlight)
+	}
+	if summary.total != 12 || summary.sent != 12 {
+		t.Fatalf("summary total %d sent %d, want 12", summary.total, summary.sent)
+	}
+}
+
+func TestProcessBatchPerChannelRateLimit(t *testing.T) {
+	cfg := testSchedulerConfig()
+	cfg.Schedular.Workers = 8
+	// in_app dibatasi 10 per detik, email tanpa batas
+	cfg.Schedular.RateLimits = map[string]float64{models.DeliveryMethodInApp: 10}
+
+	capsules := newFakeCapsuleService()
+	s, _ := newTestScheduler(cfg, capsules, &scriptedNotifier{channel: "unused"})
+	email := &recordingNotifier{channel: models.DeliveryMethodEmail}
+	inApp := &recordingNotifier{channel: models.DeliveryMethodInApp}
+	registerNotifier(s, email)
+	registerNotifier(s, inApp)
+
+	batch := append(channelCapsules(1, 3, models.DeliveryMethodInApp), channelCapsules(10, 4, models.DeliveryMethodEmail)...)
+	summary := &runSummary{startedAt: time.Now()}
+	s.processBatch(context.Background(), testNow, "claim", batch, summary)
+
+	if summary.sent != 7 {
+		t.Fatalf("sent = %d, want 7", summary.sent)
+	}
+
+	// Tiga pengiriman in_app berjarak minimal 100ms
+	if span := inApp.span(); span < 200*time.Millisecond {
+		t.Fatalf("in_app sends spread over %s, want at least 200ms", span)
+	}
+	// Email tidak ikut menunggu limiter in_app
+	if span := email.span(); span >= 100*time.Millisecond {
+		t.Fatalf("email sends spread over %s, want no rate limit delay", span)
+	}
+}
+
+func TestProcessBatchCancelled(t *testing.T) {
+	cfg := testSchedulerConfig()
+	cfg.Schedular.Workers = 2
+	cfg.Schedular.RateLimits = map[string]float64{models.DeliveryMethodInApp: 1}
+
+	capsules := newFakeCapsuleService()
+	s, _ := newTestScheduler(cfg, capsules, &scriptedNotifier{channel: models.DeliveryMethodEmail})
+	n := &recordingNotifier{channel: models.DeliveryMethodInApp}
+	registerNotifier(s, n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	summary := &runSummary{startedAt: time.Now()}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.processBatch(ctx, testNow, "claim", channelCapsules(1, 5, models.DeliveryMethodInApp), summary)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("processBatch did not return after the run context was cancelled")
+	}
+
+	// Hanya slot pertama yang sempat dipakai, sisanya tetap diklaim dan dipulihkan recovery pass
+	if sent, _, _ := n.snapshot(); sent != 1 || summary.total != 1 || len(capsules.sent) != 1 {
+		t.Fatalf("sends %d, summary total %d, marked sent %v, want only the first capsule", sent, summary.total, capsules.sent)
+	}
+}
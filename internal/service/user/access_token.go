@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+// accessTokenTouchInterval jeda minimal pembaruan last_used_at personal access token
+const accessTokenTouchInterval = time.Minute
+
+// accessTokenDisplayLength panjang awalan token yang disimpan untuk ditampilkan
+const accessTokenDisplayLength = 12
+
+// CreateAccessToken membuat personal access token baru. Token asli hanya dikembalikan di sini,
+// yang disimpan hanya hash nya
+func (s *userService) CreateAccessToken(ctx context.Context, userID int, input *models.CreateAccessTokenInput) (*models.CreatedAccessTokenResponse, error) {
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	days := input.ExpiresInDays
+	if days == 0 {
+		days = s.cfg.Auth.AccessTokenDefaultDays
+	}
+	if days > s.cfg.Auth.AccessTokenMaxDays {
+		return nil, fmt.Errorf("token lifetime cannot exceed %d days", s.cfg.Auth.AccessTokenMaxDays)
+	}
+
+	now := s.clock.Now()
+
+	active, err := s.accessTokenRepo.CountActiveByUser(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if active >= s.cfg.Auth.AccessTokenMaxPerUser {
+		return nil, errors.New("access token limit reached")
+	}
+
+	random, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	plainToken := models.PersonalAccessTokenPrefix + random
+
+	token := &models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        strings.TrimSpace(input.Name),
+		TokenPrefix: plainToken[:accessTokenDisplayLength],
+		TokenHash:   utils.HashToken(plainToken),
+		Scopes:      scopes,
+		ExpiresAt:   now.AddDate(0, 0, days),
+		CreatedAt:   now,
+	}
+
+	if err := s.accessTokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAccessTokenResponse{
+		AccessTokenResponse: token.ToResponse(),
+		Token:               plainToken,
+	}, nil
+}
+
+// ListAccessTokens mengambil personal access token aktif milik user
+func (s *userService) ListAccessTokens(ctx context.Context, userID int) ([]models.PersonalAccessToken, error) {
+	return s.accessTokenRepo.ListActiveByUser(ctx, userID, s.clock.Now())
+}
+
+// RevokeAccessToken mencabut personal access token milik user
+func (s *userService) RevokeAccessToken(ctx context.Context, userID, tokenID int) error {
+	return s.accessTokenRepo.Revoke(ctx, tokenID, userID, s.clock.Now())
+}
+
+// AuthenticateAccessToken memvalidasi personal access token dari header Authorization
+// dan mengembalikan pemilik serta scope yang diberikan
+func (s *userService) AuthenticateAccessToken(ctx context.Context, plainToken string) (*models.User, []string, error) {
+	token, err := s.accessTokenRepo.GetByHash(ctx, utils.HashToken(plainToken))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.clock.Now()
+	if !token.IsActive(now) {
+		return nil, nil, errors.New("access token revoked or expired")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user.IsDisabled() {
+		return nil, nil, errors.New("account disabled")
+	}
+
+	// last_used_at hanya informasi, gagal update tidak menolak request
+	_ = s.accessTokenRepo.Touch(ctx, token.ID, now, accessTokenTouchInterval)
+
+	return user, token.Scopes, nil
+}
+
+// normalizeScopes memvalidasi scope dan membuang duplikat
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.IsValidScope(scope) {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+		if seen[scope] {
+			continue
+		}
+
+		seen[scope] = true
+		normalized = append(normalized, scope)
+	}
+
+	return normalized, nil
+}
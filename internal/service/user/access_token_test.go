@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+func TestCreateAccessTokenScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		want    []string
+		wantErr string
+	}{
+		{name: "valid scopes", scopes: []string{models.ScopeCapsulesRead, models.ScopeInboxWrite}, want: []string{models.ScopeCapsulesRead, models.ScopeInboxWrite}},
+		{name: "duplicates and spaces removed", scopes: []string{" capsules:read", models.ScopeCapsulesRead}, want: []string{models.ScopeCapsulesRead}},
+		{name: "unknown scope", scopes: []string{models.ScopeCapsulesRead, "admin:write"}, wantErr: "invalid scope: admin:write"},
+		{name: "empty scope", scopes: []string{""}, wantErr: "invalid scope: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repos := newTestUserService(t)
+
+			created, err := s.CreateAccessToken(context.Background(), testUserID, &models.CreateAccessTokenInput{Name: "cli", Scopes: tt.scopes})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				if len(repos.accessTokens.tokens) != 0 {
+					t.Fatal("access token stored despite invalid scopes")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAccessToken: %v", err)
+			}
+
+			if !strings.HasPrefix(created.Token, models.PersonalAccessTokenPrefix) {
+				t.Fatalf("token %q missing prefix %q", created.Token, models.PersonalAccessTokenPrefix)
+			}
+
+			// Hanya hash yang disimpan, dengan scope yang sudah dinormalisasi
+			stored, ok := repos.accessTokens.tokens[utils.HashToken(created.Token)]
+			if !ok {
+				t.Fatal("access token not stored by hash")
+			}
+			if !reflect.DeepEqual(stored.Scopes, tt.want) {
+				t.Fatalf("scopes = %v, want %v", stored.Scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAccessToken(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	created, err := s.CreateAccessToken(ctx, testUserID, &models.CreateAccessTokenInput{Name: "cli", Scopes: []string{models.ScopeCapsulesRead}, ExpiresInDays: 1})
+	if err != nil {
+		t.Fatalf("CreateAccessToken: %v", err)
+	}
+
+	// Token aktif mengembalikan pemilik dan hanya scope yang diberikan
+	user, scopes, err := s.AuthenticateAccessToken(ctx, created.Token)
+	if err != nil {
+		t.Fatalf("AuthenticateAccessToken: %v", err)
+	}
+	if user.ID != testUserID || !reflect.DeepEqual(scopes, []string{models.ScopeCapsulesRead}) {
+		t.Fatalf("user %d scopes %v, want user %d with capsules:read only", user.ID, scopes, testUserID)
+	}
+
+	if _, _, err := s.AuthenticateAccessToken(ctx, models.PersonalAccessTokenPrefix+"unknown"); err == nil {
+		t.Fatal("unknown token accepted")
+	}
+
+	// Token expired
+	s.clock = fixedClock{now: testNow.Add(25 * time.Hour)}
+	if _, _, err := s.AuthenticateAccessToken(ctx, created.Token); err == nil || err.Error() != "access token revoked or expired" {
+		t.Fatalf("expired token error = %v, want revoked or expired", err)
+	}
+
+	// Token dicabut
+	s.clock = fixedClock{now: testNow}
+	repos.accessTokens.tokens[utils.HashToken(created.Token)].RevokedAt = sql.NullTime{Time: testNow, Valid: true}
+	if _, _, err := s.AuthenticateAccessToken(ctx, created.Token); err == nil || err.Error() != "access token revoked or expired" {
+		t.Fatalf("revoked token error = %v, want revoked or expired", err)
+	}
+}
+
+func TestAuthenticateAccessTokenDisabledAccount(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	created, err := s.CreateAccessToken(ctx, testUserID, &models.CreateAccessTokenInput{Name: "cli", Scopes: []string{models.ScopeCapsulesRead}})
+	if err != nil {
+		t.Fatalf("CreateAccessToken: %v", err)
+	}
+
+	repos.users.users[testUserID].DisabledAt = sql.NullTime{Time: testNow, Valid: true}
+	if _, _, err := s.AuthenticateAccessToken(ctx, created.Token); err == nil || err.Error() != "account disabled" {
+		t.Fatalf("error = %v, want account disabled", err)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword mengganti password setelah password lama dicek ulang.
+// token_version naik, semua sesi dan personal access token dicabut,
+// handler membuat sesi baru untuk perangkat yang sedang dipakai
+func (s *userService) ChangePassword(ctx context.Context, userID int, input *models.ChangePasswordInput) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Akun OIDC tanpa password membuat password pertama lewat alur lupa password (bukti kepemilikan email)
+	if !user.HasPassword() {
+		return nil, errors.New("no password set, use forgot password to create one")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		return nil, errors.New("invalid current password")
+	}
+
+	if input.CurrentPassword == input.NewPassword {
+		return nil, errors.New("new password must be different")
+	}
+
+	hashedPass, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPass)); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+
+	if _, err := s.sessionRepo.RevokeAllByUser(ctx, user.ID, models.SessionRevokedPasswordChange, now); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", user.ID, err)
+	}
+
+	if err := s.accessTokenRepo.RevokeAllByUser(ctx, user.ID, now); err != nil {
+		log.Printf("Failed to revoke access tokens for user %d: %v", user.ID, err)
+	}
+
+	// Link reset yang masih aktif dibuat sebelum password diganti, tidak boleh dipakai lagi
+	if err := s.tokenRepo.RevokeByUser(ctx, user.ID, models.TokenPurposePasswordReset, now); err != nil {
+		log.Printf("Failed to revoke password reset tokens for user %d: %v", user.ID, err)
+	}
+
+	go func() {
+		if err := s.emailService.SendPasswordChangedEmail(user); err != nil {
+			log.Printf("Failed to send password changed email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	// Ambil ulang user agar token_version yang baru dipakai saat membuat sesi
+	return s.userRepo.GetByID(ctx, user.ID)
+}
+
+// RequestEmailChange mengirim link konfirmasi ke alamat email baru.
+// Email baru baru dipakai setelah link nya dibuka
+func (s *userService) RequestEmailChange(ctx context.Context, userID int, input *models.ChangeEmailInput) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasPassword() {
+		return errors.New("no password set, use forgot password to create one")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		return errors.New("invalid password")
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(input.NewEmail))
+	if strings.EqualFold(newEmail, user.Email) {
+		return errors.New("new email must be different")
+	}
+
+	if err := s.ensureEmailAvailable(ctx, newEmail); err != nil {
+		return err
+	}
+
+	ttl := time.Duration(s.cfg.Auth.EmailVerificationTTLHours) * time.Hour
+	token, err := s.issueTokenWithEmail(ctx, user.ID, models.TokenPurposeEmailChange, newEmail, ttl)
+	if err != nil {
+		return err
+	}
+
+	confirmURL := fmt.Sprintf("%s/api/v1/auth/email/confirm?token=%s", s.cfg.App.APIURL, url.QueryEscape(token))
+
+	go func() {
+		if err := s.emailService.SendEmailChangeConfirmation(user, newEmail, confirmURL, ttl); err != nil {
+			log.Printf("Failed to send email change confirmation for user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ConfirmEmailChange mengganti email memakai token dari link konfirmasi
+// lalu memberi tahu alamat lama bahwa email akun sudah diganti.
+// Sesi dan personal access token dicabut, user login ulang dengan email baru
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (*models.User, error) {
+	now := s.clock.Now()
+
+	userToken, err := s.tokenRepo.Consume(ctx, models.TokenPurposeEmailChange, utils.HashToken(token), now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !userToken.NewEmail.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+	newEmail := userToken.NewEmail.String
+
+	user, err := s.userRepo.GetByID(ctx, userToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+	oldEmail := user.Email
+
+	// Alamat bisa saja sudah didaftarkan orang lain selama link belum dibuka
+	if err := s.ensureEmailAvailable(ctx, newEmail); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateEmail(ctx, user.ID, newEmail, now); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.sessionRepo.RevokeAllByUser(ctx, user.ID, models.SessionRevokedEmailChange, now); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", user.ID, err)
+	}
+
+	if err := s.accessTokenRepo.RevokeAllByUser(ctx, user.ID, now); err != nil {
+		log.Printf("Failed to revoke access tokens for user %d: %v", user.ID, err)
+	}
+
+	go func() {
+		if err := s.emailService.SendEmailChangedNotice(user, oldEmail, newEmail); err != nil {
+			log.Printf("Failed to send email changed notice to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return s.userRepo.GetByID(ctx, user.ID)
+}
+
+// ensureEmailAvailable memastikan belum ada user lain dengan email tersebut
+func (s *userService) ensureEmailAvailable(ctx context.Context, email string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return errors.New("email already registered")
+	}
+	if err.Error() != "user not found" {
+		return err
+	}
+
+	return nil
+}
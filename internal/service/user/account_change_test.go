@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+func TestChangePasswordBumpsTokenVersion(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	user, _ := repos.users.GetByID(ctx, testUserID)
+	before, err := s.IssueTokens(ctx, user, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	oldClaims, err := utils.ValidateJWT(before.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+
+	updated, err := s.ChangePassword(ctx, testUserID, &models.ChangePasswordInput{CurrentPassword: testPassword, NewPassword: "new-password"})
+	if err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+
+	// User yang dikembalikan membawa token_version baru untuk sesi pengganti
+	if updated.TokenVersion != oldClaims.TokenVersion+1 {
+		t.Fatalf("token_version = %d, want %d", updated.TokenVersion, oldClaims.TokenVersion+1)
+	}
+
+	// JWT lama tidak berlaku lagi
+	if err := s.ValidateSession(ctx, oldClaims); err == nil || err.Error() != "session revoked" {
+		t.Fatalf("old session error = %v, want session revoked", err)
+	}
+
+	// Sesi baru dengan versi yang baru diterima
+	after, err := s.IssueTokens(ctx, updated, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	newClaims, err := utils.ValidateJWT(after.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if err := s.ValidateSession(ctx, newClaims); err != nil {
+		t.Fatalf("new session: %v", err)
+	}
+}
+
+func TestChangePasswordRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   models.ChangePasswordInput
+		wantErr string
+	}{
+		{name: "wrong current password", input: models.ChangePasswordInput{CurrentPassword: "wrong", NewPassword: "new-password"}, wantErr: "invalid current password"},
+		{name: "same password", input: models.ChangePasswordInput{CurrentPassword: testPassword, NewPassword: testPassword}, wantErr: "new password must be different"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repos := newTestUserService(t)
+
+			_, err := s.ChangePassword(context.Background(), testUserID, &tt.input)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if version := repos.users.users[testUserID].TokenVersion; version != 0 {
+				t.Fatalf("token_version = %d, want unchanged", version)
+			}
+		})
+	}
+}
+
+func TestConfirmEmailChangeLosesRace(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	token, err := s.issueTokenWithEmail(ctx, testUserID, models.TokenPurposeEmailChange, "new@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("issueTokenWithEmail: %v", err)
+	}
+
+	// User lain mendaftar dengan alamat yang sama setelah pengecekan ensureEmailAvailable
+	repos.users.beforeUpdateEmail = func() {
+		repos.users.mu.Lock()
+		defer repos.users.mu.Unlock()
+		repos.users.users[8] = &models.User{ID: 8, Email: "new@example.com"}
+	}
+
+	if _, err := s.ConfirmEmailChange(ctx, token); err == nil || err.Error() != "email already registered" {
+		t.Fatalf("error = %v, want email already registered", err)
+	}
+	if email := repos.users.users[testUserID].Email; email != testEmail {
+		t.Fatalf("email = %q, want unchanged %q", email, testEmail)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DeleteAccount menjadwalkan penghapusan akun setelah masa tenggang.
+// Identitas dicek ulang dengan password (dan kode 2FA jika aktif), link pembatalan dikirim ke email.
+// Selama masa tenggang user masih bisa login dan mengunduh data nya
+func (s *userService) DeleteAccount(ctx context.Context, userID int, input *models.DeleteAccountInput) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsDeletionScheduled() {
+		return nil, errors.New("account deletion already scheduled")
+	}
+
+	if err := s.reauthenticate(ctx, user, input); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	grace := time.Duration(s.cfg.Auth.AccountDeletionGraceDays) * 24 * time.Hour
+	scheduledAt := now.Add(grace)
+
+	if err := s.userRepo.ScheduleDeletion(ctx, user.ID, now, scheduledAt); err != nil {
+		return nil, err
+	}
+
+	// Link pembatalan berlaku sampai akun benar benar dihapus
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeDeletionCancel, grace)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelURL := fmt.Sprintf("%s/api/v1/auth/account/cancel-deletion?token=%s", s.cfg.App.APIURL, url.QueryEscape(token))
+
+	go func() {
+		if err := s.emailService.SendAccountDeletionScheduledEmail(user, scheduledAt, cancelURL); err != nil {
+			log.Printf("Failed to send account deletion email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return s.userRepo.GetByID(ctx, user.ID)
+}
+
+// CancelAccountDeletion membatalkan penghapusan akun memakai token dari link di email
+func (s *userService) CancelAccountDeletion(ctx context.Context, token string) (*models.User, error) {
+	userToken, err := s.tokenRepo.Consume(ctx, models.TokenPurposeDeletionCancel, utils.HashToken(token), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.CancelDeletion(ctx, userToken.UserID); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, userToken.UserID)
+}
+
+// ExportAccount mengumpulkan profil, capsule beserta riwayat pengiriman dan pesan inbox milik user
+func (s *userService) ExportAccount(ctx context.Context, userID int) (*models.AccountExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	capsules, err := s.capsuleRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.deliveryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.inboxRepo.GetByUserID(ctx, userID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewAccountExport(user, capsules, attempts, messages, s.clock.Now()), nil
+}
+
+// reauthenticate memastikan yang meminta aksi sensitif benar benar pemilik akun.
+// Akun dengan password wajib mengisi password, akun dengan 2FA wajib mengisi kode 2FA
+func (s *userService) reauthenticate(ctx context.Context, user *models.User, input *models.DeleteAccountInput) error {
+	// Akun OIDC tanpa password dan tanpa 2FA tidak punya faktor yang bisa dicek ulang
+	if !user.HasPassword() && !user.IsTwoFactorEnabled() {
+		return errors.New("no password set, use forgot password to create one")
+	}
+
+	if user.HasPassword() {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+			return errors.New("invalid password")
+		}
+	}
+
+	if user.IsTwoFactorEnabled() {
+		if input.Code == "" {
+			return errors.New("two factor code required")
+		}
+		if err := s.verifySecondFactor(ctx, user, input.Code); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+func TestCancelAccountDeletion(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestUserService(t)
+
+	scheduled, err := s.DeleteAccount(ctx, testUserID, &models.DeleteAccountInput{Password: testPassword})
+	if err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	wantScheduledAt := testNow.Add(7 * 24 * time.Hour)
+	if !scheduled.IsDeletionScheduled() || !scheduled.DeletionScheduledAt.Time.Equal(wantScheduledAt) {
+		t.Fatalf("deletion scheduled at %v, want %v", scheduled.DeletionScheduledAt, wantScheduledAt)
+	}
+
+	// Link pembatalan dari email berlaku selama masa tenggang
+	token, err := s.issueToken(ctx, testUserID, models.TokenPurposeDeletionCancel, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	user, err := s.CancelAccountDeletion(ctx, token)
+	if err != nil {
+		t.Fatalf("CancelAccountDeletion: %v", err)
+	}
+	if user.IsDeletionScheduled() || user.DeletionRequestedAt.Valid {
+		t.Fatalf("deletion still scheduled: %+v", user)
+	}
+
+	// Link yang sama tidak bisa dipakai lagi
+	if _, err := s.CancelAccountDeletion(ctx, token); err == nil || err.Error() != "invalid or expired token" {
+		t.Fatalf("reused link error = %v, want invalid or expired token", err)
+	}
+}
+
+func TestCancelAccountDeletionRejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, s *userService) string
+		wantErr string
+	}{
+		{
+			name: "grace period over",
+			setup: func(t *testing.T, s *userService) string {
+				if _, err := s.DeleteAccount(context.Background(), testUserID, &models.DeleteAccountInput{Password: testPassword}); err != nil {
+					t.Fatalf("DeleteAccount: %v", err)
+				}
+				token, err := s.issueToken(context.Background(), testUserID, models.TokenPurposeDeletionCancel, 7*24*time.Hour)
+				if err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				s.clock = fixedClock{now: testNow.Add(8 * 24 * time.Hour)}
+				return token
+			},
+			wantErr: "invalid or expired token",
+		},
+		{
+			name: "deletion not scheduled",
+			setup: func(t *testing.T, s *userService) string {
+				token, err := s.issueToken(context.Background(), testUserID, models.TokenPurposeDeletionCancel, time.Hour)
+				if err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				return token
+			},
+			wantErr: "account deletion not scheduled",
+		},
+		{
+			name: "token for another purpose",
+			setup: func(t *testing.T, s *userService) string {
+				token, err := s.issueToken(context.Background(), testUserID, models.TokenPurposePasswordReset, time.Hour)
+				if err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				return token
+			},
+			wantErr: "invalid or expired token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestUserService(t)
+			token := tt.setup(t, s)
+
+			if _, err := s.CancelAccountDeletion(context.Background(), token); err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeleteAccountRequiresPassword(t *testing.T) {
+	s, repos := newTestUserService(t)
+
+	_, err := s.DeleteAccount(context.Background(), testUserID, &models.DeleteAccountInput{Password: "wrong"})
+	if err == nil || err.Error() != "invalid password" {
+		t.Fatalf("error = %v, want invalid password", err)
+	}
+	if repos.users.users[testUserID].IsDeletionScheduled() {
+		t.Fatal("deletion scheduled with a wrong password")
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+// sendVerificationEmail membuat token verifikasi dan mengirim link nya ke email user
+func (s *userService) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	ttl := time.Duration(s.cfg.Auth.EmailVerificationTTLHours) * time.Hour
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeEmailVerification, ttl)
+	if err != nil {
+		return err
+	}
+
+	verifyURL := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", s.cfg.App.APIURL, url.QueryEscape(token))
+
+	go func() {
+		if err := s.emailService.SendVerificationEmail(user, verifyURL, ttl); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ResendVerificationEmail mengirim ulang link verifikasi untuk user yang belum terverifikasi
+func (s *userService) ResendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsEmailVerified() {
+		return errors.New("email already verified")
+	}
+
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// VerifyEmail menandai email user terverifikasi memakai token dari link email
+func (s *userService) VerifyEmail(ctx context.Context, token string) error {
+	now := s.clock.Now()
+
+	userToken, err := s.tokenRepo.Consume(ctx, models.TokenPurposeEmailVerification, utils.HashToken(token), now)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, userToken.UserID, now)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+	loginThrottleRepository "future-letter/internal/repository/loginthrottle"
+)
+
+// LoginThrottledError dikembalikan Login saat percobaan ditolak karena jeda atau penguncian
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "account temporarily locked"
+	}
+	return "too many login attempts"
+}
+
+// loginAttempt percobaan login yang sudah dicatat di throttle akun dan IP sebelum kredensial dicek
+type loginAttempt struct {
+	accountKey string
+	ip         string
+	// accountFailures jumlah percobaan akun termasuk percobaan ini
+	accountFailures int
+	reserved        []throttleKey
+}
+
+type throttleKey struct {
+	kind string
+	key  string
+}
+
+// beginLoginAttempt mencatat percobaan untuk akun dan IP secara atomik sebelum password atau kode dicek,
+// sehingga request paralel tidak bisa melewati jeda. Percobaan ditolak jika akun atau IP masih dalam jeda atau terkunci
+func (s *userService) beginLoginAttempt(ctx context.Context, accountKey, ip string, now time.Time) (*loginAttempt, error) {
+	window := time.Duration(s.cfg.Auth.LoginFailureWindowMinutes) * time.Minute
+
+	keys := []throttleKey{{kind: models.ThrottleKindAccount, key: accountKey}}
+	if ip != "" {
+		keys = append(keys, throttleKey{kind: models.ThrottleKindIP, key: ip})
+	}
+
+	attempt := &loginAttempt{accountKey: accountKey, ip: ip}
+
+	var blocked *LoginThrottledError
+	for _, k := range keys {
+		maxFailures := s.cfg.Auth.LoginMaxFailures
+		if k.kind == models.ThrottleKindIP {
+			maxFailures = s.cfg.Auth.LoginMaxFailuresPerIP
+		}
+
+		throttle, allowed, err := s.loginThrottleRepo.Attempt(ctx, k.kind, k.key, now, window, s.loginBlockPolicy(maxFailures, now))
+		if err != nil {
+			s.releaseLoginAttempt(ctx, attempt, now)
+			return nil, err
+		}
+
+		if !allowed {
+			retryAfter := throttle.BlockedUntil(now).Sub(now)
+			if blocked == nil || retryAfter > blocked.RetryAfter {
+				blocked = &LoginThrottledError{RetryAfter: retryAfter, Locked: throttle.IsLocked(now)}
+			}
+			continue
+		}
+
+		attempt.reserved = append(attempt.reserved, k)
+		if k.kind == models.ThrottleKindAccount {
+			attempt.accountFailures = throttle.Failures
+		}
+	}
+
+	if blocked != nil {
+		s.releaseLoginAttempt(ctx, attempt, now)
+		return nil, blocked
+	}
+
+	return attempt, nil
+}
+
+// loginBlockPolicy jeda progresif sampai maxFailures, setelah itu dikunci LoginLockoutMinutes
+func (s *userService) loginBlockPolicy(maxFailures int, now time.Time) loginThrottleRepository.BlockPolicy {
+	return func(failures int) (time.Time, time.Time) {
+		if failures >= maxFailures {
+			lockedUntil := now.Add(time.Duration(s.cfg.Auth.LoginLockoutMinutes) * time.Minute)
+			return lockedUntil, lockedUntil
+		}
+
+		if delay := s.loginDelay(failures); delay > 0 {
+			return now.Add(delay), time.Time{}
+		}
+
+		return time.Time{}, time.Time{}
+	}
+}
+
+// failLoginAttempt dipanggil saat kredensial salah. Percobaan sudah dihitung di beginLoginAttempt,
+// di sini hanya menangani penguncian akun yang baru saja terjadi.
+// user nil jika email tidak terdaftar, percobaan tetap dihitung agar respons tidak membedakan email
+func (s *userService) failLoginAttempt(ctx context.Context, user *models.User, attempt *loginAttempt, now time.Time) {
+	if attempt.accountFailures != s.cfg.Auth.LoginMaxFailures {
+		return
+	}
+
+	// Penguncian jarang terjadi, sekalian bersihkan catatan lama
+	window := time.Duration(s.cfg.Auth.LoginFailureWindowMinutes) * time.Minute
+	if err := s.loginThrottleRepo.DeleteStale(ctx, now.Add(-window)); err != nil {
+		log.Printf("Failed to delete stale login throttles: %v", err)
+	}
+
+	if user == nil {
+		return
+	}
+
+	lockedUntil := now.Add(time.Duration(s.cfg.Auth.LoginLockoutMinutes) * time.Minute)
+	log.Printf("User %d locked out until %s after repeated failed logins", user.ID, lockedUntil.UTC().Format(time.RFC3339))
+
+	go func() {
+		if err := s.emailService.SendAccountLockedEmail(user, lockedUntil, attempt.ip); err != nil {
+			log.Printf("Failed to send account locked email to user %d: %v", user.ID, err)
+		}
+	}()
+}
+
+// releaseLoginAttempt mengembalikan percobaan yang tidak gagal karena kredensial,
+// misalnya password benar tapi 2FA belum selesai
+func (s *userService) releaseLoginAttempt(ctx context.Context, attempt *loginAttempt, now time.Time) {
+	for _, k := range attempt.reserved {
+		if err := s.loginThrottleRepo.Release(ctx, k.kind, k.key, now); err != nil {
+			log.Printf("Failed to release login attempt: %v", err)
+		}
+	}
+}
+
+// finishLoginAttempt dipanggil setelah login selesai. Catatan akun dihapus, catatan IP hanya
+// dikembalikan satu percobaan agar penyerang tidak bisa mereset nya dengan login ke akun sendiri
+func (s *userService) finishLoginAttempt(ctx context.Context, attempt *loginAttempt, now time.Time) {
+	if err := s.loginThrottleRepo.Reset(ctx, models.ThrottleKindAccount, attempt.accountKey); err != nil {
+		log.Printf("Failed to reset login throttle: %v", err)
+	}
+
+	for _, k := range attempt.reserved {
+		if k.kind == models.ThrottleKindAccount {
+			continue
+		}
+		if err := s.loginThrottleRepo.Release(ctx, k.kind, k.key, now); err != nil {
+			log.Printf("Failed to release login attempt: %v", err)
+		}
+	}
+}
+
+// loginDelay jeda progresif: 1, 2, 4, 8 detik dan seterusnya setelah LoginDelayAfterFailures kali gagal
+func (s *userService) loginDelay(failures int) time.Duration {
+	exponent := failures - s.cfg.Auth.LoginDelayAfterFailures
+	if exponent < 0 {
+		return 0
+	}
+
+	maxDelay := time.Duration(s.cfg.Auth.LoginMaxDelaySeconds) * time.Second
+	delay := time.Duration(math.Pow(2, float64(min(exponent, 30)))) * time.Second
+
+	return min(delay, maxDelay)
+}
+
+// loginAccountKey kunci throttle akun, email dinormalisasi agar variasi huruf besar tidak menghindari batas
+func loginAccountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
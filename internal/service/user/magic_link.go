@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+// RequestMagicLink mengirim link login sekali pakai ke email user.
+// Email yang tidak terdaftar dan permintaan yang melewati rate limit diabaikan
+// tanpa error agar respons selalu sama
+func (s *userService) RequestMagicLink(ctx context.Context, input *models.MagicLinkInput) error {
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil
+		}
+		return err
+	}
+
+	now := s.clock.Now()
+
+	sent, err := s.tokenRepo.CountCreatedSince(ctx, user.ID, models.TokenPurposeMagicLink, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if sent >= s.cfg.Auth.MagicLinkMaxPerHour {
+		log.Printf("Magic link rate limit reached for user %d", user.ID)
+		return nil
+	}
+
+	ttl := time.Duration(s.cfg.Auth.MagicLinkTTLMinutes) * time.Minute
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeMagicLink, ttl)
+	if err != nil {
+		return err
+	}
+
+	// Link membuka halaman frontend yang menukar token lewat POST, bukan endpoint API langsung,
+	// agar scanner link di email tidak memakai token sekali pakai ini
+	loginURL := fmt.Sprintf("%s/magic-link?token=%s", s.cfg.App.BaseURL, url.QueryEscape(token))
+
+	// Kirim email di background agar waktu respons sama untuk email terdaftar maupun tidak
+	go func() {
+		if err := s.emailService.SendMagicLinkEmail(user, loginURL, ttl); err != nil {
+			log.Printf("Failed to send magic link email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ConsumeMagicLink memakai token dari link login dan mengembalikan user nya.
+// Membuka link juga membuktikan kepemilikan email, sehingga email ikut terverifikasi
+func (s *userService) ConsumeMagicLink(ctx context.Context, token string) (*models.User, error) {
+	now := s.clock.Now()
+
+	userToken, err := s.tokenRepo.Consume(ctx, models.TokenPurposeMagicLink, utils.HashToken(token), now)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsEmailVerified() {
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID, now); err != nil {
+			log.Printf("Failed to mark email verified for user %d: %v", user.ID, err)
+		}
+	}
+
+	return user, nil
+}
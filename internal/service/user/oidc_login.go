@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"future-letter/internal/models"
+)
+
+// defaultTimezone timezone untuk user baru yang tidak memilih timezone
+const defaultTimezone = "Asia/Jakarta"
+
+// LoginWithOIDC mencari atau membuat user dari identitas provider yang sudah diverifikasi.
+// Urutan nya: identity yang sudah terhubung, lalu user dengan email yang sama
+// (hanya jika provider sudah memverifikasi email nya), terakhir buat user baru tanpa password
+func (s *userService) LoginWithOIDC(ctx context.Context, identity *models.ExternalIdentity) (*models.User, error) {
+	linked, err := s.identityRepo.GetByProviderSubject(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return s.userRepo.GetByID(ctx, linked.UserID)
+	}
+	if err.Error() != "identity not found" {
+		return nil, err
+	}
+
+	// Tanpa email terverifikasi kita tidak bisa tahu akun mana yang milik user ini
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("email not verified by provider")
+	}
+
+	now := s.clock.Now()
+
+	user, err := s.userRepo.GetByEmail(ctx, identity.Email)
+	switch {
+	case err == nil:
+		if err := s.claimUnverifiedAccount(ctx, user); err != nil {
+			return nil, err
+		}
+
+	case err.Error() == "user not found":
+		name := strings.TrimSpace(identity.Name)
+		if name == "" {
+			name = strings.Split(identity.Email, "@")[0]
+		}
+
+		// Email sudah diverifikasi provider, user baru langsung terverifikasi
+		user = &models.User{
+			Name:            name,
+			Email:           identity.Email,
+			EmailVerifiedAt: sql.NullTime{Time: now.UTC(), Valid: true},
+			Timezone:        defaultTimezone,
+		}
+
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, err
+	}
+
+	err = s.identityRepo.Create(ctx, &models.UserIdentity{
+		UserID:    user.ID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.GetByID(ctx, user.ID)
+}
+
+// claimUnverifiedAccount dijalankan saat akun lokal dengan email yang sama belum pernah diverifikasi.
+// Akun seperti itu bisa saja didaftarkan orang lain, jadi password, 2FA, semua sesi dan access token nya
+// dicabut sebelum diserahkan ke pemilik email yang sudah dibuktikan oleh provider
+func (s *userService) claimUnverifiedAccount(ctx context.Context, user *models.User) error {
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := s.clock.Now()
+
+	if err := s.userRepo.ClearPassword(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.DisableTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if err := s.recoveryRepo.DeleteByUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionRepo.RevokeAllByUser(ctx, user.ID, models.SessionRevokedAccountLinked, now); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", user.ID, err)
+	}
+
+	if err := s.accessTokenRepo.RevokeAllByUser(ctx, user.ID, now); err != nil {
+		log.Printf("Failed to revoke access tokens for user %d: %v", user.ID, err)
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, user.ID, now)
+}
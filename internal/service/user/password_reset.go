@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RequestPasswordReset membuat token reset dan mengirim link nya ke email user.
+// Email yang tidak terdaftar tidak mengembalikan error agar tidak bisa ditebak
+func (s *userService) RequestPasswordReset(ctx context.Context, input *models.ForgotPasswordInput) error {
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil
+		}
+		return err
+	}
+
+	ttl := time.Duration(s.cfg.Auth.PasswordResetTTLMinutes) * time.Minute
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposePasswordReset, ttl)
+	if err != nil {
+		return err
+	}
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.cfg.App.BaseURL, url.QueryEscape(token))
+
+	// Kirim email di background agar waktu respons sama untuk email terdaftar maupun tidak
+	go func() {
+		if err := s.emailService.SendPasswordResetEmail(user, resetURL, ttl); err != nil {
+			log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+// ResetPassword mengganti password memakai token reset.
+// Semua sesi dan personal access token yang sudah ada ikut dicabut
+func (s *userService) ResetPassword(ctx context.Context, input *models.ResetPasswordInput) error {
+	now := s.clock.Now()
+
+	token, err := s.tokenRepo.Consume(ctx, models.TokenPurposePasswordReset, utils.HashToken(input.Token), now)
+	if err != nil {
+		return err
+	}
+
+	hashedPass, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, token.UserID, string(hashedPass)); err != nil {
+		return err
+	}
+
+	// Cabut semua sesi agar perangkat yang mungkin dikuasai orang lain ikut logout
+	if _, err := s.sessionRepo.RevokeAllByUser(ctx, token.UserID, models.SessionRevokedPasswordReset, now); err != nil {
+		log.Printf("Failed to revoke sessions for user %d: %v", token.UserID, err)
+	}
+
+	// Personal access token bisa saja dibuat oleh orang yang sempat menguasai akun
+	if err := s.accessTokenRepo.RevokeAllByUser(ctx, token.UserID, now); err != nil {
+		log.Printf("Failed to revoke access tokens for user %d: %v", token.UserID, err)
+	}
+
+	// Link reset lain yang masih aktif tidak boleh dipakai lagi
+	if err := s.tokenRepo.RevokeByUser(ctx, token.UserID, models.TokenPurposePasswordReset, now); err != nil {
+		log.Printf("Failed to revoke password reset tokens for user %d: %v", token.UserID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"future-letter/internal/models"
+)
+
+func TestResetPasswordTokenSingleUse(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	token, err := s.issueToken(ctx, testUserID, models.TokenPurposePasswordReset, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	if err := s.ResetPassword(ctx, &models.ResetPasswordInput{Token: token, Password: "new-password"}); err != nil {
+		t.Fatalf("first ResetPassword: %v", err)
+	}
+	user, _ := repos.users.GetByID(ctx, testUserID)
+	if user.TokenVersion != 1 {
+		t.Fatalf("token_version = %d, want 1 after reset", user.TokenVersion)
+	}
+
+	// Link yang sama tidak bisa dipakai dua kali
+	err = s.ResetPassword(ctx, &models.ResetPasswordInput{Token: token, Password: "another-password"})
+	if err == nil || err.Error() != "invalid or expired token" {
+		t.Fatalf("second ResetPassword error = %v, want invalid or expired token", err)
+	}
+	user, _ = repos.users.GetByID(ctx, testUserID)
+	if user.TokenVersion != 1 {
+		t.Fatalf("token_version = %d, want password unchanged by the reused link", user.TokenVersion)
+	}
+}
+
+func TestResetPasswordRejectsStaleTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup mengembalikan token yang tidak boleh diterima lagi
+		setup func(t *testing.T, s *userService) string
+	}{
+		{
+			name: "superseded by a newer link",
+			setup: func(t *testing.T, s *userService) string {
+				older, err := s.issueToken(context.Background(), testUserID, models.TokenPurposePasswordReset, 30*time.Minute)
+				if err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				if _, err := s.issueToken(context.Background(), testUserID, models.TokenPurposePasswordReset, 30*time.Minute); err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				return older
+			},
+		},
+		{
+			name: "expired",
+			setup: func(t *testing.T, s *userService) string {
+				token, err := s.issueToken(context.Background(), testUserID, models.TokenPurposePasswordReset, 30*time.Minute)
+				if err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				s.clock = fixedClock{now: testNow.Add(31 * time.Minute)}
+				return token
+			},
+		},
+		{
+			name: "issued for another purpose",
+			setup: func(t *testing.T, s *userService) string {
+				token, err := s.issueToken(context.Background(), testUserID, models.TokenPurposeMagicLink, 30*time.Minute)
+				if err != nil {
+					t.Fatalf("issueToken: %v", err)
+				}
+				return token
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestUserService(t)
+			token := tt.setup(t, s)
+
+			err := s.ResetPassword(context.Background(), &models.ResetPasswordInput{Token: token, Password: "new-password"})
+			if err == nil || err.Error() != "invalid or expired token" {
+				t.Fatalf("ResetPassword error = %v, want invalid or expired token", err)
+			}
+		})
+	}
+}
+
+func TestResetPasswordRevokesSessionsAndAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	user, _ := repos.users.GetByID(ctx, testUserID)
+	if _, err := s.IssueTokens(ctx, user, models.ClientInfo{}); err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	if _, err := s.CreateAccessToken(ctx, testUserID, &models.CreateAccessTokenInput{Name: "cli", Scopes: []string{models.ScopeCapsulesRead}}); err != nil {
+		t.Fatalf("CreateAccessToken: %v", err)
+	}
+
+	token, err := s.issueToken(ctx, testUserID, models.TokenPurposePasswordReset, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+	if err := s.ResetPassword(ctx, &models.ResetPasswordInput{Token: token, Password: "new-password"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+
+	for _, session := range repos.sessions.sessions {
+		if session.IsActive(testNow) || session.RevokedReason.String != models.SessionRevokedPasswordReset {
+			t.Fatalf("session %+v still active after password reset", session)
+		}
+	}
+	for _, pat := range repos.accessTokens.tokens {
+		if pat.IsActive(testNow) {
+			t.Fatalf("access token %d still active after password reset", pat.ID)
+		}
+	}
+}
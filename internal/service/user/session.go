@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+// sessionTouchInterval jeda minimal pembaruan last_seen_at sesi
+const sessionTouchInterval = time.Minute
+
+// IssueTokens membuat sesi baru untuk user yang berhasil login atau register
+func (s *userService) IssueTokens(ctx context.Context, user *models.User, client models.ClientInfo) (*models.AuthTokens, error) {
+	if user.IsDisabled() {
+		return nil, errors.New("account disabled")
+	}
+
+	now := s.clock.Now()
+
+	refreshToken, refresh, err := s.newRefreshToken(now)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  refresh.ExpiresAt,
+	}
+	if client.UserAgent != "" {
+		session.UserAgent = sql.NullString{String: client.UserAgent, Valid: true}
+	}
+	if client.IPAddress != "" {
+		session.IPAddress = sql.NullString{String: client.IPAddress, Valid: true}
+	}
+
+	if err := s.sessionRepo.Create(ctx, session, refresh); err != nil {
+		return nil, err
+	}
+
+	return s.authTokens(user, session.ID, refreshToken, refresh.ExpiresAt)
+}
+
+// RefreshTokens menukar refresh token dengan pasangan token baru (rotasi).
+// Refresh token lama tidak bisa dipakai lagi
+func (s *userService) RefreshTokens(ctx context.Context, refreshToken string, client models.ClientInfo) (*models.AuthTokens, error) {
+	now := s.clock.Now()
+
+	nextToken, next, err := s.newRefreshToken(now)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessionRepo.Rotate(ctx, utils.HashToken(refreshToken), next, client, now)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsDisabled() {
+		return nil, errors.New("account disabled")
+	}
+
+	return s.authTokens(user, session.ID, nextToken, next.ExpiresAt)
+}
+
+// newRefreshToken membuat refresh token opaque baru, yang disimpan hanya hash nya
+func (s *userService) newRefreshToken(now time.Time) (string, *models.RefreshToken, error) {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	refresh := &models.RefreshToken{
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: now.AddDate(0, 0, s.cfg.JWT.RefreshTokenDays),
+	}
+
+	return token, refresh, nil
+}
+
+func (s *userService) authTokens(user *models.User, sessionID int, refreshToken string, refreshExpiresAt time.Time) (*models.AuthTokens, error) {
+	ttl := time.Duration(s.cfg.JWT.AccessTokenMinutes) * time.Minute
+
+	accessToken, err := utils.GenerateToken(user.ID, user.Email, user.Role, user.TokenVersion, sessionID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  s.clock.Now().Add(ttl).UTC(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt.UTC(),
+	}, nil
+}
+
+// ValidateSession memastikan user masih ada dan aktif, versi token dan role nya masih berlaku
+// dan sesi tempat token dibuat belum dicabut
+func (s *userService) ValidateSession(ctx context.Context, claims *utils.JWTClaims) error {
+	user, err := s.userRepo.GetByID(ctx, claims.ID)
+	if err != nil {
+		return err
+	}
+
+	if user.IsDisabled() {
+		return errors.New("account disabled")
+	}
+
+	if user.TokenVersion != claims.TokenVersion {
+		return errors.New("session revoked")
+	}
+
+	// Role berubah, client harus refresh untuk mendapat token dengan role baru
+	if user.Role != claims.Role {
+		return errors.New("session revoked")
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, claims.SessionID)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	if session.UserID != user.ID || !session.IsActive(now) {
+		return errors.New("session revoked")
+	}
+
+	// last_seen_at hanya informasi, gagal update tidak menolak request
+	_ = s.sessionRepo.Touch(ctx, session.ID, now, sessionTouchInterval)
+
+	return nil
+}
+
+// ListSessions mengambil sesi aktif milik user
+func (s *userService) ListSessions(ctx context.Context, userID int) ([]models.Session, error) {
+	return s.sessionRepo.ListActiveByUser(ctx, userID, s.clock.Now())
+}
+
+// Logout mencabut satu sesi
+func (s *userService) Logout(ctx context.Context, userID, sessionID int) error {
+	return s.sessionRepo.Revoke(ctx, sessionID, userID, models.SessionRevokedLogout, s.clock.Now())
+}
+
+// LogoutAll mencabut semua sesi user di semua perangkat
+func (s *userService) LogoutAll(ctx context.Context, userID int) (int, error) {
+	return s.sessionRepo.RevokeAllByUser(ctx, userID, models.SessionRevokedLogoutAll, s.clock.Now())
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+func TestRefreshTokensRotates(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	user, _ := repos.users.GetByID(ctx, testUserID)
+	first, err := s.IssueTokens(ctx, user, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	second, err := s.RefreshTokens(ctx, first.RefreshToken, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// Access token baru tetap milik sesi yang sama
+	claims, err := utils.ValidateJWT(second.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if err := s.ValidateSession(ctx, claims); err != nil {
+		t.Fatalf("ValidateSession after rotation: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+
+	user, _ := repos.users.GetByID(ctx, testUserID)
+	first, err := s.IssueTokens(ctx, user, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	second, err := s.RefreshTokens(ctx, first.RefreshToken, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+
+	// Refresh token lama dipakai lagi, misalnya oleh pencuri token
+	if _, err := s.RefreshTokens(ctx, first.RefreshToken, models.ClientInfo{}); err == nil || err.Error() != "refresh token reused" {
+		t.Fatalf("reused RefreshTokens error = %v, want refresh token reused", err)
+	}
+
+	// Sesi dicabut, refresh token terbaru milik pemilik asli juga tidak berlaku
+	if _, err := s.RefreshTokens(ctx, second.RefreshToken, models.ClientInfo{}); err == nil || err.Error() != "invalid refresh token" {
+		t.Fatalf("RefreshTokens after reuse error = %v, want invalid refresh token", err)
+	}
+
+	claims, err := utils.ValidateJWT(second.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateJWT: %v", err)
+	}
+	if err := s.ValidateSession(ctx, claims); err == nil || err.Error() != "session revoked" {
+		t.Fatalf("ValidateSession error = %v, want session revoked", err)
+	}
+
+	session, _ := repos.sessions.GetByID(ctx, claims.SessionID)
+	if session.RevokedReason.String != models.SessionRevokedTokenReuse {
+		t.Fatalf("revoked reason = %q, want %q", session.RevokedReason.String, models.SessionRevokedTokenReuse)
+	}
+}
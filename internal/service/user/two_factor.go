@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// recoveryCodeCount jumlah recovery code yang dibuat saat 2FA diaktifkan
+const recoveryCodeCount = 10
+
+// SetupTwoFactor membuat secret TOTP baru yang belum aktif sampai dikonfirmasi dengan kode pertama
+func (s *userService) SetupTwoFactor(ctx context.Context, userID int) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsTwoFactorEnabled() {
+		return nil, errors.New("two factor already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetTOTPSecret(ctx, user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.cfg.Auth.TwoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor mengaktifkan 2FA jika kode dari authenticator cocok,
+// lalu mengembalikan recovery code yang hanya ditampilkan sekali
+func (s *userService) ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsTwoFactorEnabled() {
+		return nil, errors.New("two factor already enabled")
+	}
+
+	if !user.TOTPSecret.Valid {
+		return nil, errors.New("two factor setup required")
+	}
+
+	now := s.clock.Now()
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret.String, code, now)
+	if !ok {
+		return nil, errors.New("invalid two factor code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recoveryRepo.Replace(ctx, user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.EnableTOTP(ctx, user.ID, step, now); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor menonaktifkan 2FA, butuh password dan kode 2FA yang valid
+func (s *userService) DisableTwoFactor(ctx context.Context, userID int, input *models.DisableTwoFactorInput) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsTwoFactorEnabled() {
+		return errors.New("two factor not enabled")
+	}
+
+	if user.HasPassword() {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+			return errors.New("invalid password")
+		}
+	}
+
+	if err := s.verifySecondFactor(ctx, user, input.Code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.DisableTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return s.recoveryRepo.DeleteByUser(ctx, user.ID)
+}
+
+// CreateTwoFactorChallenge membuat challenge token setelah password benar untuk user dengan 2FA aktif.
+// Id challenge disimpan di server agar bisa dipakai sekali dan percobaan kodenya dibatasi
+func (s *userService) CreateTwoFactorChallenge(ctx context.Context, user *models.User) (*models.TwoFactorChallenge, error) {
+	now := s.clock.Now()
+	ttl := time.Duration(s.cfg.Auth.TwoFactorChallengeMinutes) * time.Minute
+
+	challengeID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tokenRepo.Create(ctx, &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeTwoFactorChallenge,
+		TokenHash: utils.HashToken(challengeID),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.GenerateChallengeToken(user.ID, user.Email, user.TokenVersion, utils.TokenPurposeTwoFactor, challengeID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorChallenge{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresAt:         now.Add(ttl).UTC(),
+	}, nil
+}
+
+// CompleteTwoFactorLogin menukar challenge token dan kode 2FA dengan user yang login.
+// Kode yang salah dicatat di login throttle akun dan IP, satu challenge hanya boleh dicoba
+// TwoFactorMaxAttempts kali dan tidak bisa dipakai lagi setelah login berhasil
+func (s *userService) CompleteTwoFactorLogin(ctx context.Context, input *models.TwoFactorLoginInput, client models.ClientInfo) (*models.User, error) {
+	claims, err := utils.ValidateChallengeToken(input.ChallengeToken, utils.TokenPurposeTwoFactor)
+	if err != nil || claims.RegisteredClaims.ID == "" {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+	challengeID := claims.RegisteredClaims.ID
+
+	user, err := s.userRepo.GetByID(ctx, claims.ID)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	// Password diganti setelah challenge dibuat
+	if user.TokenVersion != claims.TokenVersion || !user.IsTwoFactorEnabled() {
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	now := s.clock.Now()
+	accountKey := loginAccountKey(user.Email)
+
+	attempt, err := s.beginLoginAttempt(ctx, accountKey, client.IPAddress, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Percobaan challenge dihitung atomik di token challenge sebelum kode dicek sehingga request
+	// paralel tetap terhitung, setelah TwoFactorMaxAttempts percobaan challenge tidak bisa dipakai lagi
+	allowed, err := s.tokenRepo.RecordAttempt(ctx, models.TokenPurposeTwoFactorChallenge, utils.HashToken(challengeID), now, s.cfg.Auth.TwoFactorMaxAttempts)
+	if err != nil {
+		s.releaseLoginAttempt(ctx, attempt, now)
+		return nil, err
+	}
+	if !allowed {
+		s.releaseLoginAttempt(ctx, attempt, now)
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	if err := s.verifySecondFactor(ctx, user, input.Code); err != nil {
+		if err.Error() == "invalid two factor code" {
+			s.failLoginAttempt(ctx, user, attempt, now)
+		} else {
+			s.releaseLoginAttempt(ctx, attempt, now)
+		}
+		return nil, err
+	}
+
+	// Challenge hanya bisa dipakai sekali, request paralel yang kalah ditolak
+	if _, err := s.tokenRepo.Consume(ctx, models.TokenPurposeTwoFactorChallenge, utils.HashToken(challengeID), now); err != nil {
+		s.releaseLoginAttempt(ctx, attempt, now)
+		return nil, errors.New("invalid or expired challenge token")
+	}
+
+	// Login baru dianggap selesai setelah faktor kedua benar
+	s.finishLoginAttempt(ctx, attempt, now)
+
+	return user, nil
+}
+
+// verifySecondFactor menerima kode TOTP 6 digit atau recovery code.
+// Setiap kode hanya bisa dipakai sekali
+func (s *userService) verifySecondFactor(ctx context.Context, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret.String, code, s.clock.Now()); ok {
+		return s.userRepo.UseTOTPStep(ctx, user.ID, step)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	if normalized == "" {
+		return errors.New("invalid two factor code")
+	}
+
+	return s.recoveryRepo.Consume(ctx, user.ID, utils.HashToken(normalized), s.clock.Now())
+}
+
+// generateRecoveryCodes membuat recovery code format "xxxxx-xxxxx" beserta hash nya
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		raw, err := utils.GenerateRandomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, fmt.Sprintf("%s-%s", raw[:5], raw[5:]))
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode menghapus tanda "-" dan spasi agar input user lebih toleran
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+
+	return code
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+const (
+	testTOTPSecret = "JBSWY3DPEHPK3PXP"
+	// wrongTOTPCode kode yang tidak cocok dengan testTOTPSecret pada testNow
+	wrongTOTPCode = "000000"
+)
+
+// enableTestTwoFactor mengaktifkan 2FA untuk user test beserta recovery code yang masih berlaku
+func enableTestTwoFactor(t *testing.T, repos *testRepositories, codes ...string) {
+	t.Helper()
+
+	if _, ok := utils.ValidateTOTP(testTOTPSecret, wrongTOTPCode, testNow); ok {
+		t.Fatalf("%s unexpectedly matches the test secret", wrongTOTPCode)
+	}
+
+	user := repos.users.users[testUserID]
+	user.TOTPSecret = sql.NullString{String: testTOTPSecret, Valid: true}
+	user.TOTPEnabledAt = sql.NullTime{Time: testNow, Valid: true}
+
+	for _, code := range codes {
+		repos.recovery.codes[utils.HashToken(normalizeRecoveryCode(code))] = false
+	}
+}
+
+func createTestChallenge(t *testing.T, s *userService, repos *testRepositories) string {
+	t.Helper()
+
+	user, _ := repos.users.GetByID(context.Background(), testUserID)
+	challenge, err := s.CreateTwoFactorChallenge(context.Background(), user)
+	if err != nil {
+		t.Fatalf("CreateTwoFactorChallenge: %v", err)
+	}
+	return challenge.ChallengeToken
+}
+
+func TestTwoFactorChallengeSingleUse(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+	enableTestTwoFactor(t, repos, "aaaaa-11111", "bbbbb-22222")
+
+	challenge := createTestChallenge(t, s, repos)
+
+	user, err := s.CompleteTwoFactorLogin(ctx, &models.TwoFactorLoginInput{ChallengeToken: challenge, Code: "aaaaa-11111"}, models.ClientInfo{})
+	if err != nil {
+		t.Fatalf("CompleteTwoFactorLogin: %v", err)
+	}
+	if user.ID != testUserID {
+		t.Fatalf("user = %d, want %d", user.ID, testUserID)
+	}
+
+	// Challenge yang sudah dipakai login tidak bisa dipakai lagi, walau kode nya benar
+	_, err = s.CompleteTwoFactorLogin(ctx, &models.TwoFactorLoginInput{ChallengeToken: challenge, Code: "bbbbb-22222"}, models.ClientInfo{})
+	if err == nil || err.Error() != "invalid or expired challenge token" {
+		t.Fatalf("reused challenge error = %v, want invalid or expired challenge token", err)
+	}
+	if repos.recovery.codes[utils.HashToken("bbbbb22222")] {
+		t.Fatal("recovery code consumed by a rejected challenge")
+	}
+}
+
+func TestTwoFactorChallengeAttemptCap(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+	enableTestTwoFactor(t, repos, "aaaaa-11111")
+
+	challenge := createTestChallenge(t, s, repos)
+
+	for i := 1; i <= s.cfg.Auth.TwoFactorMaxAttempts; i++ {
+		_, err := s.CompleteTwoFactorLogin(ctx, &models.TwoFactorLoginInput{ChallengeToken: challenge, Code: wrongTOTPCode}, models.ClientInfo{})
+		if err == nil || err.Error() != "invalid two factor code" {
+			t.Fatalf("attempt %d error = %v, want invalid two factor code", i, err)
+		}
+	}
+
+	// Setelah batas percobaan, kode yang benar pun ditolak dan recovery code tidak terpakai
+	_, err := s.CompleteTwoFactorLogin(ctx, &models.TwoFactorLoginInput{ChallengeToken: challenge, Code: "aaaaa-11111"}, models.ClientInfo{})
+	if err == nil || err.Error() != "invalid or expired challenge token" {
+		t.Fatalf("attempt after cap error = %v, want invalid or expired challenge token", err)
+	}
+	if repos.recovery.codes[utils.HashToken("aaaaa11111")] {
+		t.Fatal("recovery code consumed after the attempt cap")
+	}
+
+	// Batas berlaku per challenge, login ulang mendapat challenge baru
+	fresh := createTestChallenge(t, s, repos)
+	if _, err := s.CompleteTwoFactorLogin(ctx, &models.TwoFactorLoginInput{ChallengeToken: fresh, Code: "aaaaa-11111"}, models.ClientInfo{}); err != nil {
+		t.Fatalf("fresh challenge: %v", err)
+	}
+}
+
+func TestTwoFactorChallengeRejectedAfterPasswordChange(t *testing.T) {
+	ctx := context.Background()
+	s, repos := newTestUserService(t)
+	enableTestTwoFactor(t, repos, "aaaaa-11111")
+
+	challenge := createTestChallenge(t, s, repos)
+	repos.users.users[testUserID].TokenVersion++
+
+	_, err := s.CompleteTwoFactorLogin(ctx, &models.TwoFactorLoginInput{ChallengeToken: challenge, Code: "aaaaa-11111"}, models.ClientInfo{})
+	if err == nil || err.Error() != "invalid or expired challenge token" {
+		t.Fatalf("error = %v, want invalid or expired challenge token", err)
+	}
+}
@@ -5,12 +5,40 @@ import (
 	"context"
 
 	"future-letter/internal/models"
+	"future-letter/internal/utils"
 )
 
 type UserService interface {
 	Register(ctx context.Context, input *models.RegisterInput) (*models.User, error)
-	Login(ctx context.Context, input *models.LoginInput) (*models.User, error)
+	Login(ctx context.Context, input *models.LoginInput, client models.ClientInfo) (*models.User, error)
 	GetProfile(ctx context.Context, userID int) (*models.User, error)
 	UpdateProfile(ctx context.Context, userID int, input *models.UpdateProfileInput) (*models.User, error)
-	DeleteAccount(ctx context.Context, userID int) error
+	DeleteAccount(ctx context.Context, userID int, input *models.DeleteAccountInput) (*models.User, error)
+	CancelAccountDeletion(ctx context.Context, token string) (*models.User, error)
+	ExportAccount(ctx context.Context, userID int) (*models.AccountExport, error)
+	ValidateSession(ctx context.Context, claims *utils.JWTClaims) error
+	IssueTokens(ctx context.Context, user *models.User, client models.ClientInfo) (*models.AuthTokens, error)
+	RefreshTokens(ctx context.Context, refreshToken string, client models.ClientInfo) (*models.AuthTokens, error)
+	ListSessions(ctx context.Context, userID int) ([]models.Session, error)
+	Logout(ctx context.Context, userID, sessionID int) error
+	LogoutAll(ctx context.Context, userID int) (int, error)
+	SetupTwoFactor(ctx context.Context, userID int) (*models.TwoFactorSetupResponse, error)
+	ConfirmTwoFactor(ctx context.Context, userID int, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID int, input *models.DisableTwoFactorInput) error
+	CreateTwoFactorChallenge(ctx context.Context, user *models.User) (*models.TwoFactorChallenge, error)
+	CompleteTwoFactorLogin(ctx context.Context, input *models.TwoFactorLoginInput, client models.ClientInfo) (*models.User, error)
+	RequestMagicLink(ctx context.Context, input *models.MagicLinkInput) error
+	ConsumeMagicLink(ctx context.Context, token string) (*models.User, error)
+	LoginWithOIDC(ctx context.Context, identity *models.ExternalIdentity) (*models.User, error)
+	CreateAccessToken(ctx context.Context, userID int, input *models.CreateAccessTokenInput) (*models.CreatedAccessTokenResponse, error)
+	ListAccessTokens(ctx context.Context, userID int) ([]models.PersonalAccessToken, error)
+	RevokeAccessToken(ctx context.Context, userID, tokenID int) error
+	AuthenticateAccessToken(ctx context.Context, token string) (*models.User, []string, error)
+	RequestPasswordReset(ctx context.Context, input *models.ForgotPasswordInput) error
+	ResetPassword(ctx context.Context, input *models.ResetPasswordInput) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, userID int) error
+	ChangePassword(ctx context.Context, userID int, input *models.ChangePasswordInput) (*models.User, error)
+	RequestEmailChange(ctx context.Context, userID int, input *models.ChangeEmailInput) error
+	ConfirmEmailChange(ctx context.Context, token string) (*models.User, error)
 }
@@ -3,22 +3,75 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"log"
+	"time"
 
+	"future-letter/internal/clock"
+	"future-letter/internal/config"
 	"future-letter/internal/models"
+	accessTokenRepository "future-letter/internal/repository/accesstoken"
+	capsuleRepository "future-letter/internal/repository/capsule"
+	deliveryRepository "future-letter/internal/repository/delivery"
+	identityRepository "future-letter/internal/repository/identity"
+	inboxRepository "future-letter/internal/repository/inbox"
+	loginThrottleRepository "future-letter/internal/repository/loginthrottle"
+	recoveryRepository "future-letter/internal/repository/recovery"
+	sessionRepository "future-letter/internal/repository/session"
+	tokenRepository "future-letter/internal/repository/token"
 	repository "future-letter/internal/repository/user"
+	emailService "future-letter/internal/service/email"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type userService struct {
-	userRepo repository.UserRepository
+	cfg               *config.Config
+	userRepo          repository.UserRepository
+	tokenRepo         tokenRepository.TokenRepository
+	sessionRepo       sessionRepository.SessionRepository
+	recoveryRepo      recoveryRepository.RecoveryCodeRepository
+	identityRepo      identityRepository.IdentityRepository
+	accessTokenRepo   accessTokenRepository.AccessTokenRepository
+	loginThrottleRepo loginThrottleRepository.LoginThrottleRepository
+	capsuleRepo       capsuleRepository.CapsuleRepository
+	deliveryRepo      deliveryRepository.DeliveryRepository
+	inboxRepo         inboxRepository.InboxRepository
+	emailService      *emailService.EmailService
+	clock             clock.Clock
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(
+	cfg *config.Config,
+	userRepo repository.UserRepository,
+	tokenRepo tokenRepository.TokenRepository,
+	sessionRepo sessionRepository.SessionRepository,
+	recoveryRepo recoveryRepository.RecoveryCodeRepository,
+	identityRepo identityRepository.IdentityRepository,
+	accessTokenRepo accessTokenRepository.AccessTokenRepository,
+	loginThrottleRepo loginThrottleRepository.LoginThrottleRepository,
+	capsuleRepo capsuleRepository.CapsuleRepository,
+	deliveryRepo deliveryRepository.DeliveryRepository,
+	inboxRepo inboxRepository.InboxRepository,
+	emailService *emailService.EmailService,
+	clock clock.Clock,
+) UserService {
 	return &userService{
-		userRepo: userRepo,
+		cfg:               cfg,
+		userRepo:          userRepo,
+		tokenRepo:         tokenRepo,
+		sessionRepo:       sessionRepo,
+		recoveryRepo:      recoveryRepo,
+		identityRepo:      identityRepo,
+		accessTokenRepo:   accessTokenRepo,
+		loginThrottleRepo: loginThrottleRepo,
+		capsuleRepo:       capsuleRepo,
+		deliveryRepo:      deliveryRepo,
+		inboxRepo:         inboxRepo,
+		emailService:      emailService,
+		clock:             clock,
 	}
 }
 
@@ -39,7 +92,12 @@ func (s *userService) Register(ctx context.Context, input *models.RegisterInput)
 	// Jika timezone kosong maka akan di isi dengan Asia/Jakarta
 	timezone := input.Timezone
 	if timezone == "" {
-		timezone = "Asia/Jakarta"
+		timezone = defaultTimezone
+	}
+
+	// Timezone dipakai untuk menghitung waktu pengiriman capsule
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, errors.New("invalid timezone")
 	}
 
 	// Buat objek user
@@ -53,6 +111,10 @@ func (s *userService) Register(ctx context.Context, input *models.RegisterInput)
 	// Simpan user ke database
 	err = s.userRepo.Create(ctx, user)
 	if err != nil {
+		// Email yang sama didaftarkan bersamaan, ditolak oleh unique index
+		if err.Error() == "email already registered" {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create user: %v", err)
 	}
 
@@ -62,22 +124,55 @@ func (s *userService) Register(ctx context.Context, input *models.RegisterInput)
 		return nil, fmt.Errorf("something went wrong: %v", err)
 	}
 
+	// Registrasi tetap berhasil walau link verifikasi gagal dibuat, user bisa kirim ulang
+	if err := s.sendVerificationEmail(ctx, fullUser); err != nil {
+		log.Printf("Failed to create verification token for user %d: %v", fullUser.ID, err)
+	}
+
 	return fullUser, nil
 }
 
-// Login untuk masuk ke app
-func (s *userService) Login(ctx context.Context, input *models.LoginInput) (*models.User, error) {
+// Login untuk masuk ke app. Login gagal dicatat per akun dan per IP,
+// percobaan berikutnya diberi jeda progresif sampai akhirnya dikunci sementara
+func (s *userService) Login(ctx context.Context, input *models.LoginInput, client models.ClientInfo) (*models.User, error) {
+	now := s.clock.Now()
+	accountKey := loginAccountKey(input.Email)
+
+	attempt, err := s.beginLoginAttempt(ctx, accountKey, client.IPAddress, now)
+	if err != nil {
+		return nil, err
+	}
+
 	// Dapatkan user berdasarkan email
 	user, err := s.userRepo.GetByEmail(ctx, input.Email)
 	if err != nil {
+		if err.Error() != "user not found" {
+			s.releaseLoginAttempt(ctx, attempt, now)
+			return nil, err
+		}
+		s.failLoginAttempt(ctx, nil, attempt, now)
+		return nil, errors.New("invalid email or password")
+	}
+
+	// Akun yang hanya login lewat OIDC tidak bisa login dengan password
+	if !user.HasPassword() {
+		s.failLoginAttempt(ctx, user, attempt, now)
 		return nil, errors.New("invalid email or password")
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
+		s.failLoginAttempt(ctx, user, attempt, now)
 		return nil, errors.New("invalid email or password")
 	}
 
+	// User dengan 2FA belum selesai login, catatan gagal baru direset setelah kode 2FA benar
+	if user.IsTwoFactorEnabled() {
+		s.releaseLoginAttempt(ctx, attempt, now)
+	} else {
+		s.finishLoginAttempt(ctx, attempt, now)
+	}
+
 	return user, nil
 }
 
@@ -99,10 +194,22 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int, input *mode
 		return nil, fmt.Errorf("failed to get user by id %d: %v", userID, err)
 	}
 
+	if _, err := time.LoadLocation(input.Timezone); err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+
 	// Ubah data
 	user.Name = input.Name
 	user.Timezone = input.Timezone
 
+	// Kontak notifikasi opsional, hanya diubah jika di isi
+	if input.PhoneNumber != "" {
+		user.PhoneNumber = sql.NullString{String: input.PhoneNumber, Valid: true}
+	}
+	if input.TelegramChatID != "" {
+		user.TelegramChatID = sql.NullString{String: input.TelegramChatID, Valid: true}
+	}
+
 	// Save ke database
 	err = s.userRepo.Update(ctx, user)
 	if err != nil {
@@ -117,8 +224,3 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int, input *mode
 
 	return userUpdate, nil
 }
-
-// DeleteAccount menghapus akun yang sudah ada
-func (s *userService) DeleteAccount(ctx context.Context, userID int) error {
-	return s.userRepo.Delete(ctx, userID)
-}
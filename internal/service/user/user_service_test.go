@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"future-letter/internal/config"
+	"future-letter/internal/models"
+	accessTokenRepository "future-letter/internal/repository/accesstoken"
+	loginThrottleRepository "future-letter/internal/repository/loginthrottle"
+	recoveryRepository "future-letter/internal/repository/recovery"
+	sessionRepository "future-letter/internal/repository/session"
+	tokenRepository "future-letter/internal/repository/token"
+	repository "future-letter/internal/repository/user"
+	emailService "future-letter/internal/service/email"
+	"future-letter/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testNow = time.Date(2030, 1, 15, 10, 0, 0, 0, time.UTC)
+
+const (
+	testUserID   = 7
+	testEmail    = "user@example.com"
+	testPassword = "old-password"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+// Fake repository di bawah mengikuti aturan query MySQL nya masing masing,
+// method yang tidak dipakai test akan panic
+
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[int]*models.User
+	// beforeUpdateEmail dijalankan sebelum UpdateEmail, untuk mensimulasikan request lain yang bersamaan
+	beforeUpdateEmail func()
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	user.Password = passwordHash
+	user.TokenVersion++
+	return nil
+}
+
+// UpdateEmail menolak email yang sudah dipakai user lain seperti unique index uq_users_email
+func (r *fakeUserRepository) UpdateEmail(ctx context.Context, userID int, email string, verifiedAt time.Time) error {
+	if r.beforeUpdateEmail != nil {
+		r.beforeUpdateEmail()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.ID != userID && user.Email == email {
+			return errors.New("email already registered")
+		}
+	}
+	r.users[userID].Email = email
+	r.users[userID].EmailVerifiedAt = sql.NullTime{Time: verifiedAt, Valid: true}
+	return nil
+}
+
+func (r *fakeUserRepository) UseTOTPStep(ctx context.Context, userID int, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := r.users[userID]
+	if user.TOTPLastStep.Valid && user.TOTPLastStep.Int64 >= step {
+		return errors.New("invalid two factor code")
+	}
+	user.TOTPLastStep = sql.NullInt64{Int64: step, Valid: true}
+	return nil
+}
+
+func (r *fakeUserRepository) ScheduleDeletion(ctx context.Context, userID int, requestedAt, scheduledAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := r.users[userID]
+	if user.DeletionScheduledAt.Valid {
+		return errors.New("account deletion already scheduled")
+	}
+	user.DeletionRequestedAt = sql.NullTime{Time: requestedAt, Valid: true}
+	user.DeletionScheduledAt = sql.NullTime{Time: scheduledAt, Valid: true}
+	return nil
+}
+
+func (r *fakeUserRepository) CancelDeletion(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user := r.users[userID]
+	if !user.DeletionScheduledAt.Valid {
+		return errors.New("account deletion not scheduled")
+	}
+	user.DeletionRequestedAt = sql.NullTime{}
+	user.DeletionScheduledAt = sql.NullTime{}
+	return nil
+}
+
+type fakeTokenRepository struct {
+	tokenRepository.TokenRepository
+
+	mu       sync.Mutex
+	tokens   []*models.UserToken
+	attempts map[string]int
+}
+
+func (r *fakeTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = len(r.tokens) + 1
+	copied := *token
+	r.tokens = append(r.tokens, &copied)
+	return nil
+}
+
+func (r *fakeTokenRepository) find(purpose, tokenHash string) *models.UserToken {
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == tokenHash {
+			return token
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) Consume(ctx context.Context, purpose, tokenHash string, now time.Time) (*models.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := r.find(purpose, tokenHash)
+	if token == nil || token.UsedAt.Valid || !token.ExpiresAt.After(now) {
+		return nil, errors.New("invalid or expired token")
+	}
+	token.UsedAt = sql.NullTime{Time: now, Valid: true}
+
+	copied := *token
+	return &copied, nil
+}
+
+func (r *fakeTokenRepository) RevokeByUser(ctx context.Context, userID int, purpose string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && !token.UsedAt.Valid {
+			token.UsedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+	return nil
+}
+
+func (r *fakeTokenRepository) RecordAttempt(ctx context.Context, purpose, tokenHash string, now time.Time, maxAttempts int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token := r.find(purpose, tokenHash)
+	if token == nil || token.UsedAt.Valid || !token.ExpiresAt.After(now) || r.attempts[tokenHash] >= maxAttempts {
+		return false, nil
+	}
+	r.attempts[tokenHash]++
+	return true, nil
+}
+
+type fakeSessionRepository struct {
+	sessionRepository.SessionRepository
+
+	mu       sync.Mutex
+	sessions map[int]*models.Session
+	refresh  map[string]*models.RefreshToken
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, session *models.Session, refreshToken *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.ID = len(r.sessions) + 1
+	copied := *session
+	r.sessions[session.ID] = &copied
+
+	refreshToken.SessionID = session.ID
+	refreshToken.ID = len(r.refresh) + 1
+	storedRefresh := *refreshToken
+	r.refresh[refreshToken.TokenHash] = &storedRefresh
+	return nil
+}
+
+func (r *fakeSessionRepository) GetByID(ctx context.Context, id int) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepository) Touch(ctx context.Context, id int, now time.Time, minInterval time.Duration) error {
+	return nil
+}
+
+func (r *fakeSessionRepository) Rotate(ctx context.Context, tokenHash string, next *models.RefreshToken, client models.ClientInfo, now time.Time) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.refresh[tokenHash]
+	if !ok {
+		return nil, errors.New("invalid refresh token")
+	}
+	session := r.sessions[current.SessionID]
+
+	// Reuse detection: token lama dipakai lagi, sesi dicabut
+	if current.UsedAt.Valid {
+		if !session.RevokedAt.Valid {
+			session.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			session.RevokedReason = sql.NullString{String: models.SessionRevokedTokenReuse, Valid: true}
+		}
+		return nil, errors.New("refresh token reused")
+	}
+
+	if !current.ExpiresAt.After(now) || !session.IsActive(now) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	current.UsedAt = sql.NullTime{Time: now, Valid: true}
+	next.SessionID = session.ID
+	storedNext := *next
+	r.refresh[next.TokenHash] = &storedNext
+
+	session.LastSeenAt = now
+	session.ExpiresAt = next.ExpiresAt
+
+	copied := *session
+	return &copied, nil
+}
+
+func (r *fakeSessionRepository) RevokeAllByUser(ctx context.Context, userID int, reason string, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revoked := 0
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsActive(now) {
+			session.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			session.RevokedReason = sql.NullString{String: reason, Valid: true}
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+type fakeAccessTokenRepository struct {
+	accessTokenRepository.AccessTokenRepository
+
+	mu     sync.Mutex
+	tokens map[string]*models.PersonalAccessToken
+}
+
+func (r *fakeAccessTokenRepository) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = len(r.tokens) + 1
+	copied := *token
+	r.tokens[token.TokenHash] = &copied
+	return nil
+}
+
+func (r *fakeAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, errors.New("access token not found")
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (r *fakeAccessTokenRepository) CountActiveByUser(ctx context.Context, userID int, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active := 0
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.IsActive(now) {
+			active++
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeAccessTokenRepository) Touch(ctx context.Context, id int, now time.Time, minInterval time.Duration) error {
+	return nil
+}
+
+func (r *fakeAccessTokenRepository) RevokeAllByUser(ctx context.Context, userID int, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.UserID == userID && !token.RevokedAt.Valid {
+			token.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+	return nil
+}
+
+type fakeRecoveryRepository struct {
+	recoveryRepository.RecoveryCodeRepository
+
+	mu sync.Mutex
+	// codes hash recovery code, true jika sudah dipakai
+	codes map[string]bool
+}
+
+func (r *fakeRecoveryRepository) Consume(ctx context.Context, userID int, codeHash string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	used, ok := r.codes[codeHash]
+	if !ok || used {
+		return errors.New("invalid two factor code")
+	}
+	r.codes[codeHash] = true
+	return nil
+}
+
+func testUserConfig() *config.Config {
+	return &config.Config{
+		App: config.AppConfig{BaseURL: "https://app.example.com", APIURL: "https://api.example.com"},
+		JWT: config.JWTConfig{AccessTokenMinutes: 15, RefreshTokenDays: 30},
+		Auth: config.AuthConfig{
+			PasswordResetTTLMinutes:   30,
+			TwoFactorIssuer:           "Future Letter",
+			TwoFactorChallengeMinutes: 5,
+			TwoFactorMaxAttempts:      3,
+			AccessTokenDefaultDays:    30,
+			AccessTokenMaxDays:        365,
+			AccessTokenMaxPerUser:     10,
+			LoginFailureWindowMinutes: 15,
+			LoginMaxFailures:          50,
+			LoginMaxFailuresPerIP:     100,
+			LoginDelayAfterFailures:   50,
+			LoginMaxDelaySeconds:      30,
+			LoginLockoutMinutes:       15,
+			AccountDeletionGraceDays:  7,
+		},
+		// Email dikirim di background, SMTP yang tidak ada langsung gagal dan hanya di log
+		Email: config.EmailConfig{SMTPHost: "127.0.0.1", SMTPPort: 1},
+	}
+}
+
+// testRepositories repository fake yang dipakai newTestUserService
+type testRepositories struct {
+	users        *fakeUserRepository
+	tokens       *fakeTokenRepository
+	sessions     *fakeSessionRepository
+	accessTokens *fakeAccessTokenRepository
+	recovery     *fakeRecoveryRepository
+}
+
+// newTestUserService service dengan satu user (testUserID) yang memakai password testPassword
+func newTestUserService(t *testing.T) (*userService, *testRepositories) {
+	t.Helper()
+
+	utils.InitJWT("test-secret")
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+
+	repos := &testRepositories{
+		users: &fakeUserRepository{users: map[int]*models.User{
+			testUserID: {
+				ID:              testUserID,
+				Name:            "Test User",
+				Email:           testEmail,
+				Password:        string(hashed),
+				Role:            models.RoleUser,
+				EmailVerifiedAt: sql.NullTime{Time: testNow, Valid: true},
+			},
+		}},
+		tokens:       &fakeTokenRepository{attempts: make(map[string]int)},
+		sessions:     &fakeSessionRepository{sessions: make(map[int]*models.Session), refresh: make(map[string]*models.RefreshToken)},
+		accessTokens: &fakeAccessTokenRepository{tokens: make(map[string]*models.PersonalAccessToken)},
+		recovery:     &fakeRecoveryRepository{codes: make(map[string]bool)},
+	}
+
+	cfg := testUserConfig()
+	s := &userService{
+		cfg:               cfg,
+		userRepo:          repos.users,
+		tokenRepo:         repos.tokens,
+		sessionRepo:       repos.sessions,
+		recoveryRepo:      repos.recovery,
+		accessTokenRepo:   repos.accessTokens,
+		loginThrottleRepo: loginThrottleRepository.NewMemoryLoginThrottleRepository(),
+		emailService:      emailService.NewEmailService(cfg),
+		clock:             fixedClock{now: testNow},
+	}
+
+	return s, repos
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"future-letter/internal/models"
+	"future-letter/internal/utils"
+)
+
+// issueToken membuat token sekali pakai baru untuk user.
+// Token lama dengan kegunaan yang sama dicabut agar hanya link terakhir yang berlaku
+func (s *userService) issueToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	return s.issueTokenWithEmail(ctx, userID, purpose, "", ttl)
+}
+
+// issueTokenWithEmail sama seperti issueToken, dengan alamat email baru yang ikut disimpan (untuk email_change)
+func (s *userService) issueTokenWithEmail(ctx context.Context, userID int, purpose, newEmail string, ttl time.Duration) (string, error) {
+	now := s.clock.Now()
+
+	if err := s.tokenRepo.RevokeByUser(ctx, userID, purpose, now); err != nil {
+		return "", err
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.tokenRepo.Create(ctx, &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(token),
+		NewEmail:  sql.NullString{String: newEmail, Valid: newEmail != ""},
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
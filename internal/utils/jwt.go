@@ -10,35 +10,81 @@ import (
 type JWTClaims struct {
 	ID    int    `json:"id"`
 	Email string `json:"email"`
+	// Role role user saat token dibuat, token dengan role lama ditolak sehingga client harus refresh
+	Role string `json:"role"`
+	// TokenVersion harus sama dengan token_version user, jika berbeda sesi dianggap sudah dicabut
+	TokenVersion int `json:"tv"`
+	// SessionID sesi tempat access token ini dibuat, sesi yang dicabut membuat token tidak berlaku
+	SessionID int `json:"sid"`
+	// Purpose kosong untuk access token, terisi untuk token sementara seperti challenge 2FA
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Purpose token sementara yang tidak boleh dipakai sebagai access token
+const (
+	TokenPurposeTwoFactor = "2fa_challenge"
+)
+
 var jwtsecret []byte
 
 func InitJWT(secret string) {
 	jwtsecret = []byte(secret)
 }
 
-// GenerateToken membuat JWT token baru untuk user
-func GenerateToken(userID int, email string, expiryHours int) (string, error) {
+// GenerateToken membuat JWT access token baru untuk sesi user
+func GenerateToken(userID int, email, role string, tokenVersion, sessionID int, ttl time.Duration) (string, error) {
+	return signToken(&JWTClaims{
+		ID:           userID,
+		Email:        email,
+		Role:         role,
+		TokenVersion: tokenVersion,
+		SessionID:    sessionID,
+	}, ttl)
+}
+
+// GenerateChallengeToken membuat JWT berumur pendek untuk langkah login berikutnya (misalnya 2FA).
+// Token ini ditolak oleh AuthRequired karena purpose nya terisi.
+// challengeID disimpan sebagai jti agar server bisa membatasi percobaan dan memakai challenge sekali saja
+func GenerateChallengeToken(userID int, email string, tokenVersion int, purpose, challengeID string, ttl time.Duration) (string, error) {
+	claims := &JWTClaims{
+		ID:           userID,
+		Email:        email,
+		TokenVersion: tokenVersion,
+		Purpose:      purpose,
+	}
+	claims.RegisteredClaims.ID = challengeID
+
+	return signToken(claims, ttl)
+}
+
+// ValidateChallengeToken memvalidasi challenge token dengan purpose tertentu
+func ValidateChallengeToken(tokenString, purpose string) (*JWTClaims, error) {
+	claims, err := ValidateJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Purpose != purpose {
+		return nil, errors.New("invalid token purpose")
+	}
+
+	return claims, nil
+}
+
+func signToken(claims *JWTClaims, ttl time.Duration) (string, error) {
 	// Cek jika jwt sudah diinisialisasi
 	if len(jwtsecret) == 0 {
 		return "", errors.New("JWT secret not initialize")
 	}
 
-	// Buat claims / data yang disimpan di token
-	claims := &JWTClaims{
-		ID:    userID,
-		Email: email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			// ExpiresAt -> kapan token expire
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expiryHours))),
-			// IssuedAt -> kapan token dibuat
-			IssuedAt: jwt.NewNumericDate(time.Now()),
-			// NotBefore -> waktu token mulai valid
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
-	}
+	// Waktu berlaku token
+	// ExpiresAt -> kapan token expire
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(ttl))
+	// IssuedAt -> kapan token dibuat
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	// NotBefore -> waktu token mulai valid
+	claims.NotBefore = jwt.NewNumericDate(time.Now())
 
 	// buat token baru
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -87,18 +133,6 @@ func ValidateJWT(tokenString string) (*JWTClaims, error) {
 	return claims, nil
 }
 
-func RefreshToken(oldTokenString string, expiryHours int) (string, error) {
-	// validasi token lama
-	claims, err := ValidateJWT(oldTokenString)
-	if err != nil {
-		return "", err
-	}
-
-	// Generate token baru dengan claims yang sama
-	// gunakan expiry time baru
-	return GenerateToken(claims.ID, claims.Email, expiryHours)
-}
-
 func ExtractUserIDFromToken(tokenString string) (int, error) {
 	claims, err := ValidateJWT(tokenString)
 	if err != nil {
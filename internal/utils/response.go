@@ -32,6 +32,15 @@ func CreatedResponse(c *gin.Context, message string, data any) {
 	})
 }
 
+// AcceptedResponse mengirim response untuk proses yang berjalan di background dengan HTTP 202
+func AcceptedResponse(c *gin.Context, message string, data any) {
+	c.JSON(http.StatusAccepted, Response{
+		Status:  true,
+		Message: message,
+		Data:    data,
+	})
+}
+
 // ErrorResponse mengirim response error dengan HTTP custom
 func ErrorResponse(c *gin.Context, statusCode int, message string) {
 	c.JSON(statusCode, Response{
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret secret SHA1 dari RFC 6238 Appendix B ("12345678901234567890")
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// Vektor uji RFC 6238 Appendix B (SHA1). RFC memakai 8 digit, kode 6 digit adalah 6 digit terakhirnya
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{unix: 59, code: "287082"},
+	{unix: 1111111109, code: "081804"},
+	{unix: 1111111111, code: "050471"},
+	{unix: 1234567890, code: "005924"},
+	{unix: 2000000000, code: "279037"},
+	{unix: 20000000000, code: "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+
+	for _, tt := range rfc6238Vectors {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.code {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+}
+
+func TestValidateTOTPRFC6238(t *testing.T) {
+	for _, tt := range rfc6238Vectors {
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("ValidateTOTP(T=%d, %s) rejected a valid code", tt.unix, tt.code)
+			continue
+		}
+		if step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP(T=%d) step = %d, want %d", tt.unix, step, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	// Kode untuk T=1111111109 berlaku di langkah 37037036
+	const code = "081804"
+	codeTime := time.Unix(1111111109, 0)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{name: "same step", now: codeTime, want: true},
+		{name: "one step later", now: codeTime.Add(totpPeriod * time.Second), want: true},
+		{name: "one step earlier", now: codeTime.Add(-totpPeriod * time.Second), want: true},
+		{name: "two steps later", now: codeTime.Add(2 * totpPeriod * time.Second), want: false},
+		{name: "two steps earlier", now: codeTime.Add(-2 * totpPeriod * time.Second), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfc6238Secret, code, tt.now); ok != tt.want {
+				t.Fatalf("ValidateTOTP() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{name: "too short", secret: rfc6238Secret, code: "28708"},
+		{name: "eight digit RFC code", secret: rfc6238Secret, code: "94287082"},
+		{name: "wrong code", secret: rfc6238Secret, code: "000000"},
+		{name: "invalid secret", secret: "not base32!", code: "287082"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+				t.Fatalf("ValidateTOTP(%q) accepted invalid input", tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPAcceptsLowercaseSecretAndSpaces(t *testing.T) {
+	lower := []byte(rfc6238Secret)
+	for i, c := range lower {
+		if c >= 'A' && c <= 'Z' {
+			lower[i] = c + ('a' - 'A')
+		}
+	}
+
+	if _, ok := ValidateTOTP(string(lower), " 287082 ", time.Unix(59, 0)); !ok {
+		t.Fatal("ValidateTOTP() rejected lowercase secret or padded code")
+	}
+}
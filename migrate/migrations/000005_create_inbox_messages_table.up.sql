@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS inbox_messages (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    capsule_id INT NOT NULL,
+    read_at TIMESTAMP NULL,
+    delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_inbox_messages_capsule_id (capsule_id),
+    INDEX idx_inbox_messages_user_read (user_id, read_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (capsule_id) REFERENCES capsules(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP COLUMN telegram_chat_id,
+    DROP COLUMN phone_number;
+
+UPDATE capsules SET delivery_method = 'email' WHERE delivery_method NOT IN ('email', 'in_app');
+ALTER TABLE capsules MODIFY delivery_method ENUM('email', 'in_app') DEFAULT 'email';
@@ -0,0 +1,5 @@
+ALTER TABLE capsules MODIFY delivery_method VARCHAR(30) NOT NULL DEFAULT 'email';
+
+ALTER TABLE users
+    ADD COLUMN phone_number VARCHAR(32) NULL AFTER timezone,
+    ADD COLUMN telegram_chat_id VARCHAR(64) NULL AFTER phone_number;
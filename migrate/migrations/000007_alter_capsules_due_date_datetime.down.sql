@@ -0,0 +1,10 @@
+-- Kembalikan capsule pending ke tanggal lokal user sebelum jam nya dibuang
+UPDATE capsules c
+JOIN users u ON u.id = c.user_id
+SET c.due_date = DATE(COALESCE(
+    CONVERT_TZ(c.due_date, '+00:00', u.timezone),
+    c.due_date
+))
+WHERE c.status = 'pending';
+
+ALTER TABLE capsules MODIFY due_date DATE NOT NULL;
@@ -0,0 +1,13 @@
+-- due_date sekarang menyimpan waktu pengiriman dalam UTC, bukan hanya tanggal
+ALTER TABLE capsules MODIFY due_date DATETIME NOT NULL;
+
+-- Capsule lama hanya punya tanggal, jadikan jam 08:00 waktu lokal user lalu simpan dalam UTC.
+-- CONVERT_TZ mengembalikan NULL jika timezone tidak dikenal (atau tabel timezone MySQL kosong),
+-- fallback nya 08:00 UTC, sama dengan loadUserLocation
+UPDATE capsules c
+JOIN users u ON u.id = c.user_id
+SET c.due_date = COALESCE(
+    CONVERT_TZ(c.due_date + INTERVAL 8 HOUR, u.timezone, '+00:00'),
+    c.due_date + INTERVAL 8 HOUR
+)
+WHERE c.status = 'pending';
@@ -0,0 +1 @@
+ALTER TABLE capsules DROP COLUMN overdue;
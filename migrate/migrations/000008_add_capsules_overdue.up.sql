@@ -0,0 +1 @@
+ALTER TABLE capsules ADD COLUMN overdue BOOLEAN NOT NULL DEFAULT FALSE AFTER sent_at;
@@ -0,0 +1,8 @@
+UPDATE capsules SET status = 'pending' WHERE status = 'failed';
+
+ALTER TABLE capsules
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN attempt_count,
+    MODIFY status ENUM('pending', 'sent', 'cancelled') DEFAULT 'pending';
+
+DROP TABLE IF EXISTS delivery_attempts;
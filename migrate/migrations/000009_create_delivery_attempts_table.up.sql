@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    capsule_id INT NOT NULL,
+    channel VARCHAR(30) NOT NULL,
+    attempt_number INT NOT NULL,
+    status ENUM('success', 'failed') NOT NULL,
+    error TEXT NULL,
+    overdue BOOLEAN NOT NULL DEFAULT FALSE,
+    attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_delivery_attempts_capsule_id (capsule_id, attempt_number),
+    FOREIGN KEY (capsule_id) REFERENCES capsules(id) ON DELETE CASCADE
+);
+
+ALTER TABLE capsules
+    MODIFY status ENUM('pending', 'sent', 'cancelled', 'failed') DEFAULT 'pending',
+    ADD COLUMN attempt_count INT NOT NULL DEFAULT 0 AFTER overdue,
+    ADD COLUMN next_attempt_at DATETIME NULL AFTER attempt_count;
@@ -0,0 +1,12 @@
+DROP INDEX idx_delivery_attempts_capsule_status ON delivery_attempts;
+
+ALTER TABLE delivery_attempts DROP COLUMN idempotency_key;
+
+DROP INDEX idx_capsules_status_lease ON capsules;
+
+UPDATE capsules SET status = 'pending' WHERE status = 'sending';
+
+ALTER TABLE capsules
+    DROP COLUMN lease_expires_at,
+    DROP COLUMN claim_token,
+    MODIFY status ENUM('pending', 'sent', 'cancelled', 'failed') DEFAULT 'pending';
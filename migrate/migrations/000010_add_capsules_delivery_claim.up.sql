@@ -0,0 +1,11 @@
+ALTER TABLE capsules
+    MODIFY status ENUM('pending', 'sending', 'sent', 'cancelled', 'failed') DEFAULT 'pending',
+    ADD COLUMN claim_token VARCHAR(64) NULL AFTER next_attempt_at,
+    ADD COLUMN lease_expires_at DATETIME NULL AFTER claim_token;
+
+CREATE INDEX idx_capsules_status_lease ON capsules(status, lease_expires_at);
+
+ALTER TABLE delivery_attempts
+    ADD COLUMN idempotency_key VARCHAR(100) NOT NULL DEFAULT '' AFTER channel;
+
+CREATE INDEX idx_delivery_attempts_capsule_status ON delivery_attempts(capsule_id, status);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS scheduler_locks (
+    name VARCHAR(100) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    renewed_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
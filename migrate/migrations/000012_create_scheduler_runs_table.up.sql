@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS scheduler_runs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    trigger_source ENUM('cron', 'manual') NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    status ENUM('running', 'completed', 'failed') NOT NULL DEFAULT 'running',
+    total INT NOT NULL DEFAULT 0,
+    sent INT NOT NULL DEFAULT 0,
+    retried INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    overdue INT NOT NULL DEFAULT 0,
+    error TEXT NULL,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME NULL,
+    INDEX idx_scheduler_runs_started_at (started_at)
+);
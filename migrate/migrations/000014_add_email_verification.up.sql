@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL AFTER email;
+
+-- User yang sudah ada dianggap terverifikasi agar pengiriman capsule mereka tidak tertahan
+UPDATE users SET email_verified_at = created_at;
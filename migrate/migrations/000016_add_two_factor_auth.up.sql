@@ -0,0 +1,14 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR(64) NULL AFTER token_version,
+    ADD COLUMN totp_enabled_at DATETIME NULL AFTER totp_secret,
+    ADD COLUMN totp_last_step BIGINT NULL AFTER totp_enabled_at;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_recovery_codes_user_code (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,25 @@
+-- Akun yang hanya login lewat provider OIDC tidak punya password
+ALTER TABLE users MODIFY password VARCHAR(255) NULL;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_user_identities_provider_subject (provider, subject),
+    INDEX idx_user_identities_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS oauth_states (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    state_hash CHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_oauth_states_state_hash (state_hash),
+    INDEX idx_oauth_states_expires_at (expires_at)
+);
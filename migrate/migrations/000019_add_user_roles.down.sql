@@ -0,0 +1,6 @@
+DROP INDEX idx_users_role ON users;
+
+ALTER TABLE users
+    DROP COLUMN disabled_reason,
+    DROP COLUMN disabled_at,
+    DROP COLUMN role;
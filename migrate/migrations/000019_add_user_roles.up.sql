@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN role ENUM('user', 'admin', 'support') NOT NULL DEFAULT 'user' AFTER telegram_chat_id,
+    ADD COLUMN disabled_at DATETIME NULL AFTER role,
+    ADD COLUMN disabled_reason VARCHAR(255) NULL AFTER disabled_at;
+
+CREATE INDEX idx_users_role ON users(role);
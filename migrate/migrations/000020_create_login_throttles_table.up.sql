@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS login_throttles (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    kind ENUM('account', 'ip') NOT NULL,
+    throttle_key VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    window_started_at DATETIME NOT NULL,
+    next_attempt_at DATETIME NULL,
+    locked_until DATETIME NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE KEY uq_login_throttles_kind_key (kind, throttle_key),
+    INDEX idx_login_throttles_updated_at (updated_at)
+);
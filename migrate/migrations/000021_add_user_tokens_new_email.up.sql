@@ -0,0 +1,2 @@
+-- Alamat email baru yang menunggu konfirmasi pada token email_change
+ALTER TABLE user_tokens ADD COLUMN new_email VARCHAR(255) NULL AFTER token_hash;
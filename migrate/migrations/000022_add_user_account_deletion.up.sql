@@ -0,0 +1,6 @@
+-- Penghapusan akun dijadwalkan setelah masa tenggang, akun dihapus permanen saat deletion_scheduled_at terlewati
+ALTER TABLE users
+    ADD COLUMN deletion_requested_at DATETIME NULL AFTER disabled_reason,
+    ADD COLUMN deletion_scheduled_at DATETIME NULL AFTER deletion_requested_at;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS data_exports (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    status ENUM('pending', 'processing', 'completed', 'failed', 'expired') NOT NULL DEFAULT 'pending',
+    file_path VARCHAR(512) NULL,
+    file_size BIGINT NULL,
+    error VARCHAR(500) NULL,
+    started_at DATETIME NULL,
+    completed_at DATETIME NULL,
+    expires_at DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_data_exports_user_id (user_id, created_at),
+    INDEX idx_data_exports_status (status, expires_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP INDEX ft_capsules_title_message ON capsules;
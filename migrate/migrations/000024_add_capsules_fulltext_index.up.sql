@@ -0,0 +1 @@
+ALTER TABLE capsules ADD FULLTEXT INDEX ft_capsules_title_message (title, message);
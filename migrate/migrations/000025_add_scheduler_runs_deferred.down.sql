@@ -0,0 +1 @@
+ALTER TABLE scheduler_runs DROP COLUMN deferred;
@@ -0,0 +1,2 @@
+-- Jumlah capsule yang ditunda pada satu run scheduler (misal email user belum terverifikasi)
+ALTER TABLE scheduler_runs ADD COLUMN deferred INT NOT NULL DEFAULT 0 AFTER failed;
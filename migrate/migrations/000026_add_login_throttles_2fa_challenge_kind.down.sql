@@ -0,0 +1,3 @@
+DELETE FROM login_throttles WHERE kind = '2fa_challenge';
+
+ALTER TABLE login_throttles MODIFY kind ENUM('account', 'ip') NOT NULL;
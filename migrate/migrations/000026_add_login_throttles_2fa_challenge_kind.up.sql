@@ -0,0 +1,2 @@
+-- Percobaan kode per challenge 2FA ikut disimpan di login_throttles
+ALTER TABLE login_throttles MODIFY kind ENUM('account', 'ip', '2fa_challenge') NOT NULL;
@@ -0,0 +1,2 @@
+-- Jumlah percobaan kode pada token challenge 2FA
+ALTER TABLE user_tokens ADD COLUMN attempts INT NOT NULL DEFAULT 0 AFTER new_email;
@@ -0,0 +1 @@
+ALTER TABLE login_throttles MODIFY kind ENUM('account', 'ip', '2fa_challenge') NOT NULL;
@@ -0,0 +1,4 @@
+-- Percobaan kode per challenge 2FA sekarang disimpan di user_tokens.attempts
+DELETE FROM login_throttles WHERE kind = '2fa_challenge';
+
+ALTER TABLE login_throttles MODIFY kind ENUM('account', 'ip') NOT NULL;
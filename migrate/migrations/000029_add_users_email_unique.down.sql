@@ -0,0 +1 @@
+ALTER TABLE users DROP INDEX uq_users_email, ADD INDEX idx_users_email (email);
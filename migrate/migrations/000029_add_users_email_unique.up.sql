@@ -0,0 +1,3 @@
+-- Email harus unik, pengecekan di aplikasi saja tidak cukup untuk register atau ganti email yang bersamaan.
+-- Email ganda yang sudah ada harus diselesaikan dulu sebelum migration ini dijalankan
+ALTER TABLE users DROP INDEX idx_users_email, ADD UNIQUE INDEX uq_users_email (email);